@@ -0,0 +1,265 @@
+// Package client is a Go client for the v2 REST API of the thundernetes controller
+// it allocates game servers and looks up and terminates sessions
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiV2Path = "/api/v2"
+
+	defaultMaxRetries      = 3
+	defaultRetryBackoff    = 200 * time.Millisecond
+	defaultMaxRetryBackoff = 5 * time.Second
+)
+
+// Options configures a Client, the zero value is valid
+type Options struct {
+	// CACertificates contains the PEM encoded certificates of the CAs that the API server certificate is verified with
+	// the system roots are used if it's empty
+	CACertificates []byte
+	// ClientCertificate is presented to the API server, it's required if the server uses TLS security
+	ClientCertificate *tls.Certificate
+	// ServerName overrides the host name that the API server certificate is verified with
+	ServerName string
+	// Token is sent as a bearer token, it's required if the server uses token authentication
+	Token string
+	// MaxRetries is the number of times a request that failed with a 429 or 5xx status code is retried
+	// it defaults to 3, a negative value disables the retries
+	MaxRetries int
+	// RetryBackoff is the time before the first retry, it's doubled on every retry up to MaxRetryBackoff
+	// a Retry-After that is returned by the API server is used instead, if it's longer
+	RetryBackoff time.Duration
+	// MaxRetryBackoff is the maximum time between two retries
+	MaxRetryBackoff time.Duration
+	// HTTPClient is used to send the requests instead of a client that is created from the TLS options
+	HTTPClient *http.Client
+}
+
+// Client calls the v2 REST API of the thundernetes controller
+// it's safe for concurrent use
+type Client struct {
+	baseURL         string
+	httpClient      *http.Client
+	token           string
+	maxRetries      int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+}
+
+// New creates a Client for the API server at baseURL, e.g. https://10.0.0.1:5000
+func New(baseURL string, opts *Options) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: the scheme must be http or https and the host must be set", baseURL)
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	c := &Client{
+		baseURL:         strings.TrimSuffix(u.String(), "/") + apiV2Path,
+		httpClient:      opts.HTTPClient,
+		token:           opts.Token,
+		maxRetries:      opts.MaxRetries,
+		retryBackoff:    opts.RetryBackoff,
+		maxRetryBackoff: opts.MaxRetryBackoff,
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = defaultMaxRetries
+	}
+	if c.retryBackoff <= 0 {
+		c.retryBackoff = defaultRetryBackoff
+	}
+	if c.maxRetryBackoff <= 0 {
+		c.maxRetryBackoff = defaultMaxRetryBackoff
+	}
+	if c.httpClient == nil {
+		tlsConfig, err := newTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		c.httpClient = &http.Client{Transport: transport}
+	}
+	return c, nil
+}
+
+// newTLSConfig creates the TLS configuration of the Client from the options
+func newTLSConfig(opts *Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: opts.ServerName,
+	}
+	if len(opts.CACertificates) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(opts.CACertificates) {
+			return nil, errors.New("the CA certificates do not contain any PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.ClientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*opts.ClientCertificate}
+	}
+	return tlsConfig, nil
+}
+
+// Allocate allocates a StandingBy game server of the build for the session
+// if the session is already allocated, the game server that hosts it is returned
+func (c *Client) Allocate(ctx context.Context, req *AllocateRequest) (*Allocation, error) {
+	var rs Allocation
+	if err := c.do(ctx, http.MethodPost, "/allocate", req, &rs); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// BatchAllocate allocates a StandingBy game server for each of the requests
+// the results are in the same order as the requests, a failed allocation is reported in its result as an *APIError
+// the failed allocations of a batch are not retried
+func (c *Client) BatchAllocate(ctx context.Context, reqs []AllocateRequest) ([]BatchResult, error) {
+	var rs batchAllocateResponse
+	if err := c.do(ctx, http.MethodPost, "/allocate/batch", &batchAllocateRequest{Allocations: reqs}, &rs); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, 0, len(rs.Results))
+	for _, r := range rs.Results {
+		if r.Error != nil {
+			results = append(results, BatchResult{Err: newAPIError(r.Error)})
+			continue
+		}
+		results = append(results, BatchResult{Allocation: r.Allocation})
+	}
+	return results, nil
+}
+
+// GetSession returns the game server that hosts the session
+func (c *Client) GetSession(ctx context.Context, sessionID string) (*GameServer, error) {
+	var rs GameServer
+	if err := c.do(ctx, http.MethodGet, "/sessions/"+url.PathEscape(sessionID), nil, &rs); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// Terminate terminates the session by deleting the game server that hosts it
+func (c *Client) Terminate(ctx context.Context, sessionID string) error {
+	return c.do(ctx, http.MethodDelete, "/sessions/"+url.PathEscape(sessionID), nil, nil)
+}
+
+// GetPlayerSessions returns the existing game servers that have the player in their initial or connected players
+func (c *Client) GetPlayerSessions(ctx context.Context, playerID string) ([]GameServer, error) {
+	var rs playerSessionsResponse
+	if err := c.do(ctx, http.MethodGet, "/players/"+url.PathEscape(playerID)+"/sessions", nil, &rs); err != nil {
+		return nil, err
+	}
+	return rs.Sessions, nil
+}
+
+// do sends a request and decodes the JSON response into out, if it's not nil
+// requests that fail with a temporary *APIError are retried with an exponential backoff
+// if the context is done while waiting for a retry, the last error is returned
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("cannot serialize the request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.doOnce(ctx, method, path, body, out)
+		var ae *APIError
+		if err == nil || !errors.As(err, &ae) || !ae.temporary() || c.maxRetries < 0 || attempt >= c.maxRetries {
+			return err
+		}
+		timer := time.NewTimer(c.backoff(attempt, ae.RetryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the time to wait before the retry that follows the attempt
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	d := c.retryBackoff
+	for i := 0; i < attempt && d < c.maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > c.maxRetryBackoff {
+		d = c.maxRetryBackoff
+	}
+	if retryAfter > d {
+		return retryAfter
+	}
+	return d
+}
+
+// doOnce sends a single request
+// it returns an *APIError if the API server returned an error status code
+func (c *Client) doOnce(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		var ae *APIError
+		var rs errorResponse
+		if err := json.Unmarshal(b, &rs); err == nil && rs.Error.Status != 0 {
+			ae = newAPIError(&rs.Error)
+		} else {
+			ae = newAPIErrorFromText(res.StatusCode, b)
+		}
+		if s, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && s > 0 {
+			ae.RetryAfter = time.Duration(s) * time.Second
+		}
+		return ae
+	}
+
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("cannot deserialize the response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	sessionID1 = "d5f075a4-517b-4bf4-8123-dfa0021aa169"
+	buildID1   = "acb84898-cf73-46e2-8057-314ac557d85d"
+)
+
+var _ = Describe("Client tests", func() {
+	var (
+		server   *httptest.Server
+		handler  http.HandlerFunc
+		requests int32
+	)
+
+	BeforeEach(func() {
+		atomic.StoreInt32(&requests, 0)
+		handler = nil
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			atomic.AddInt32(&requests, 1)
+			handler(w, r)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	// newClient returns a Client that trusts the certificate of the test server
+	newClient := func(opts Options) *Client {
+		opts.CACertificates = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		if opts.RetryBackoff == 0 {
+			opts.RetryBackoff = time.Millisecond
+		}
+		c, err := New(server.URL, &opts)
+		Expect(err).ToNot(HaveOccurred())
+		return c
+	}
+
+	writeJSON := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+
+	writeError := func(w http.ResponseWriter, status int, code string, details ...FieldError) {
+		writeJSON(w, status, &errorResponse{Error: errorBody{Status: status, Code: code, Message: "error message", Details: details}})
+	}
+
+	It("should reject an invalid base URL", func() {
+		_, err := New("localhost:5000", nil)
+		Expect(err).To(HaveOccurred())
+		_, err = New("ftp://localhost", nil)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should reject CA certificates that are not PEM encoded", func() {
+		_, err := New(server.URL, &Options{CACertificates: []byte("not a certificate")})
+		Expect(err).To(HaveOccurred())
+	})
+	It("should not trust a server certificate that is not signed by the CA", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, &Allocation{})
+		}
+		c, err := New(server.URL, nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = c.Allocate(context.Background(), &AllocateRequest{SessionID: sessionID1, BuildID: buildID1})
+		Expect(err).To(HaveOccurred())
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(0)))
+	})
+	It("should allocate", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.URL.Path).To(Equal("/api/v2/allocate"))
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer token1"))
+			var req AllocateRequest
+			Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+			Expect(req.SessionID).To(Equal(sessionID1))
+			Expect(req.InitialPlayers).To(Equal([]string{"player1"}))
+			writeJSON(w, http.StatusOK, &Allocation{IPV4Address: "10.0.0.1", Ports: "gameport:10000", SessionID: req.SessionID})
+		}
+		c := newClient(Options{Token: "token1"})
+		rs, err := c.Allocate(context.Background(), &AllocateRequest{SessionID: sessionID1, BuildID: buildID1, InitialPlayers: []string{"player1"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rs).To(Equal(&Allocation{IPV4Address: "10.0.0.1", Ports: "gameport:10000", SessionID: sessionID1}))
+	})
+	It("should return a typed error with the invalid fields without retrying", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusBadRequest, "InvalidArgument", FieldError{Field: "buildID", Message: "must be a UUID"})
+		}
+		c := newClient(Options{})
+		_, err := c.Allocate(context.Background(), &AllocateRequest{SessionID: sessionID1, BuildID: "NOT_A_GUID"})
+		Expect(errors.Is(err, ErrInvalidArgument)).To(BeTrue())
+		Expect(errors.Is(err, ErrNotFound)).To(BeFalse())
+		var ae *APIError
+		Expect(errors.As(err, &ae)).To(BeTrue())
+		Expect(ae.StatusCode).To(Equal(http.StatusBadRequest))
+		Expect(ae.Details).To(Equal([]FieldError{{Field: "buildID", Message: "must be a UUID"}}))
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+	})
+	It("should retry on 429 and 5xx until the request succeeds", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			switch atomic.LoadInt32(&requests) {
+			case 1:
+				writeError(w, http.StatusTooManyRequests, "ResourceExhausted")
+			case 2:
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+			default:
+				writeJSON(w, http.StatusOK, &Allocation{SessionID: sessionID1})
+			}
+		}
+		c := newClient(Options{})
+		rs, err := c.Allocate(context.Background(), &AllocateRequest{SessionID: sessionID1, BuildID: buildID1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rs.SessionID).To(Equal(sessionID1))
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+	})
+	It("should return the last error when the retries are exhausted", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+		c := newClient(Options{MaxRetries: 2})
+		_, err := c.GetSession(context.Background(), sessionID1)
+		Expect(errors.Is(err, ErrInternal)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("unavailable"))
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+	})
+	It("should not retry when the retries are disabled", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "2")
+			writeError(w, http.StatusTooManyRequests, "ResourceExhausted")
+		}
+		c := newClient(Options{MaxRetries: -1})
+		_, err := c.Allocate(context.Background(), &AllocateRequest{SessionID: sessionID1, BuildID: buildID1})
+		Expect(errors.Is(err, ErrResourceExhausted)).To(BeTrue())
+		var ae *APIError
+		Expect(errors.As(err, &ae)).To(BeTrue())
+		Expect(ae.RetryAfter).To(Equal(2 * time.Second))
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+	})
+	It("should stop retrying when the context is done", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			writeError(w, http.StatusTooManyRequests, "ResourceExhausted")
+		}
+		c := newClient(Options{})
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := c.Allocate(ctx, &AllocateRequest{SessionID: sessionID1, BuildID: buildID1})
+		Expect(errors.Is(err, ErrResourceExhausted)).To(BeTrue())
+		Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+		Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+	})
+	It("should return a result for each allocation of a batch", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/api/v2/allocate/batch"))
+			var req batchAllocateRequest
+			Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+			Expect(req.Allocations).To(HaveLen(2))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"results":[{"allocation":{"sessionID":"` + sessionID1 + `"}},{"error":{"status":429,"code":"ResourceExhausted","message":"not enough standingBy","retryAfterSeconds":1}}]}`))
+		}
+		c := newClient(Options{})
+		results, err := c.BatchAllocate(context.Background(), []AllocateRequest{
+			{SessionID: sessionID1, BuildID: buildID1},
+			{SessionID: "9bb3bbb2-5031-42fd-8982-5a3f76ef2c8a", BuildID: buildID1},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Err).ToNot(HaveOccurred())
+		Expect(results[0].Allocation.SessionID).To(Equal(sessionID1))
+		Expect(results[1].Allocation).To(BeNil())
+		Expect(errors.Is(results[1].Err, ErrResourceExhausted)).To(BeTrue())
+		Expect(results[1].Err.(*APIError).RetryAfter).To(Equal(time.Second))
+	})
+	It("should look up and terminate a session", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/api/v2/sessions/" + sessionID1))
+			switch r.Method {
+			case http.MethodGet:
+				writeJSON(w, http.StatusOK, &GameServer{Name: "gs1", SessionID: sessionID1, State: "Active"})
+			case http.MethodDelete:
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}
+		c := newClient(Options{})
+		gs, err := c.GetSession(context.Background(), sessionID1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gs.Name).To(Equal("gs1"))
+		Expect(gs.State).To(Equal("Active"))
+		Expect(c.Terminate(context.Background(), sessionID1)).To(Succeed())
+	})
+	It("should return NotFound for an unknown session", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusNotFound, "NotFound")
+		}
+		c := newClient(Options{})
+		err := c.Terminate(context.Background(), sessionID1)
+		Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+	})
+	It("should return the sessions of a player", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/api/v2/players/player1/sessions"))
+			writeJSON(w, http.StatusOK, &playerSessionsResponse{PlayerID: "player1", Sessions: []GameServer{{Name: "gs1"}}})
+		}
+		c := newClient(Options{})
+		sessions, err := c.GetPlayerSessions(context.Background(), "player1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sessions).To(Equal([]GameServer{{Name: "gs1"}}))
+	})
+	It("should double the backoff up to the maximum and honour a longer Retry-After", func() {
+		c, err := New("https://localhost:5000", &Options{RetryBackoff: 100 * time.Millisecond, MaxRetryBackoff: time.Second})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.backoff(0, 0)).To(Equal(100 * time.Millisecond))
+		Expect(c.backoff(2, 0)).To(Equal(400 * time.Millisecond))
+		Expect(c.backoff(10, 0)).To(Equal(time.Second))
+		Expect(c.backoff(0, 3*time.Second)).To(Equal(3 * time.Second))
+	})
+})
+
+func TestClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Client Suite")
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the sentinel errors can be used with errors.Is to check the Code of an *APIError
+var (
+	ErrInvalidArgument    = &APIError{Code: "InvalidArgument"}
+	ErrUnauthenticated    = &APIError{Code: "Unauthenticated"}
+	ErrPermissionDenied   = &APIError{Code: "PermissionDenied"}
+	ErrNotFound           = &APIError{Code: "NotFound"}
+	ErrMethodNotAllowed   = &APIError{Code: "MethodNotAllowed"}
+	ErrFailedPrecondition = &APIError{Code: "FailedPrecondition"}
+	ErrResourceExhausted  = &APIError{Code: "ResourceExhausted"}
+	ErrInternal           = &APIError{Code: "Internal"}
+)
+
+// APIError is an error returned by the thundernetes API
+// Code is the machine-readable name of the StatusCode, e.g. ResourceExhausted for 429
+// Details contains the invalid fields of the request, if it was rejected by the validation
+// RetryAfter is the time after which the request can be retried, if the API returned it
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    []FieldError
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is returns true if the target is an *APIError with the same Code
+// so that errors.Is(err, client.ErrNotFound) can be used
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Code == e.Code
+}
+
+// temporary returns true if the request that returned the error can be retried
+func (e *APIError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// newAPIError creates an APIError from the JSON error body of the API
+func newAPIError(b *errorBody) *APIError {
+	e := &APIError{
+		StatusCode: b.Status,
+		Code:       b.Code,
+		Message:    b.Message,
+		Details:    b.Details,
+		RetryAfter: time.Duration(b.RetryAfterSeconds) * time.Second,
+	}
+	if e.Code == "" {
+		e.Code = codeForStatus(e.StatusCode)
+	}
+	return e
+}
+
+// newAPIErrorFromText creates an APIError for a response that does not have a JSON error body
+// e.g. an error returned by a load balancer in front of the API
+func newAPIErrorFromText(statusCode int, body []byte) *APIError {
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		msg = http.StatusText(statusCode)
+	}
+	return &APIError{StatusCode: statusCode, Code: codeForStatus(statusCode), Message: msg}
+}
+
+// codeForStatus returns the Code that the API uses for an HTTP status code
+func codeForStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "InvalidArgument"
+	case http.StatusUnauthorized:
+		return "Unauthenticated"
+	case http.StatusForbidden:
+		return "PermissionDenied"
+	case http.StatusNotFound:
+		return "NotFound"
+	case http.StatusMethodNotAllowed:
+		return "MethodNotAllowed"
+	case http.StatusTooManyRequests:
+		return "ResourceExhausted"
+	default:
+		return "Internal"
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/playfab/thundernetes/client
+
+go 1.16
+
+require (
+	github.com/onsi/ginkgo v1.16.4
+	github.com/onsi/gomega v1.14.0
+)
@@ -0,0 +1,76 @@
+package client
+
+// AllocateRequest contains the details of an allocation
+// SessionID and BuildID must be UUIDs
+type AllocateRequest struct {
+	SessionID      string   `json:"sessionID"`
+	BuildID        string   `json:"buildID"`
+	SessionCookie  string   `json:"sessionCookie,omitempty"`
+	InitialPlayers []string `json:"initialPlayers,omitempty"`
+}
+
+// Allocation contains the details of an allocated game server
+// Ports is a comma separated list of the exposed ports, e.g. "gameport:10000"
+type Allocation struct {
+	IPV4Address string `json:"ipv4Address"`
+	Ports       string `json:"ports"`
+	SessionID   string `json:"sessionID"`
+}
+
+// BatchResult is the result of a single allocation of a batch
+// exactly one of Allocation and Err is set
+type BatchResult struct {
+	Allocation *Allocation
+	Err        error
+}
+
+// GameServer contains the details of a game server that are returned by the session and player lookups
+type GameServer struct {
+	Name             string   `json:"name"`
+	Namespace        string   `json:"namespace"`
+	BuildName        string   `json:"buildName"`
+	BuildID          string   `json:"buildID"`
+	NodeName         string   `json:"nodeName"`
+	IPV4Address      string   `json:"ipv4Address"`
+	Ports            string   `json:"ports"`
+	State            string   `json:"state"`
+	Health           string   `json:"health"`
+	SessionID        string   `json:"sessionID,omitempty"`
+	SessionCookie    string   `json:"sessionCookie,omitempty"`
+	InitialPlayers   []string `json:"initialPlayers,omitempty"`
+	ConnectedPlayers []string `json:"connectedPlayers,omitempty"`
+}
+
+// FieldError describes an invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type batchAllocateRequest struct {
+	Allocations []AllocateRequest `json:"allocations"`
+}
+
+type batchAllocateResponse struct {
+	Results []struct {
+		Allocation *Allocation `json:"allocation"`
+		Error      *errorBody  `json:"error"`
+	} `json:"results"`
+}
+
+type playerSessionsResponse struct {
+	PlayerID string       `json:"playerID"`
+	Sessions []GameServer `json:"sessions"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Status            int          `json:"status"`
+	Code              string       `json:"code"`
+	Message           string       `json:"message"`
+	Details           []FieldError `json:"details"`
+	RetryAfterSeconds int          `json:"retryAfterSeconds"`
+}
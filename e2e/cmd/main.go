@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	allocationclient "github.com/playfab/thundernetes/client"
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -33,15 +36,9 @@ const (
 	loopTimes                 int    = 30
 	delayInSecondsForLoopTest int    = 1
 	LabelBuildID                     = "BuildID"
-	invalidStatusCode         string = "invalid status code"
 	containerName             string = "netcore-sample" // this must be the same as the GameServer name
 )
 
-type AllocationResult struct {
-	IPV4Address string `json:"IPv4Address"`
-	SessionID   string `json:"SessionID"`
-}
-
 type buildState struct {
 	activeCount     int
 	standingByCount int
@@ -93,6 +90,20 @@ func main() {
 	if err != nil {
 		handleError(err)
 	}
+	// the certificate is self-signed, so it's also the CA of the API server certificate
+	caCert, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		handleError(err)
+	}
+	// the retries are disabled, since some tests expect a 429
+	ac, err := allocationclient.New("https://localhost:5000", &allocationclient.Options{
+		CACertificates:    caCert,
+		ClientCertificate: &cert,
+		MaxRetries:        -1,
+	})
+	if err != nil {
+		handleError(err)
+	}
 
 	build1 := &mpsv1alpha1.GameServerBuild{
 		ObjectMeta: metav1.ObjectMeta{
@@ -287,15 +298,20 @@ func main() {
 
 	fmt.Println("Allocating on Build1")
 	sessionID1 := uuid.New().String()
-	if err := allocate(test1BuildID, sessionID1, cert); err != nil {
+	if err := allocate(ctx, ac, test1BuildID, sessionID1); err != nil {
 		handleError(err)
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 3, activeCount: 1, podCount: 4})
 	validateBuildState(ctx, buildState{buildID: test2BuildID, buildName: testBuild2Name, standingByCount: 3, activeCount: 0, podCount: 3})
 	validateThatAllocatedServersHaveReadyForPlayersUnblocked(ctx, test1BuildID)
 
+	fmt.Println("Looking up the session on Build1")
+	if err := validateSession(ctx, ac, test1BuildID, sessionID1); err != nil {
+		handleError(err)
+	}
+
 	fmt.Println("Allocating on Build1 with same sessionID - should not convert another standingBy to active")
-	if err := allocate(test1BuildID, sessionID1, cert); err != nil {
+	if err := allocate(ctx, ac, test1BuildID, sessionID1); err != nil {
 		handleError(err)
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 3, activeCount: 1, podCount: 4})
@@ -303,7 +319,7 @@ func main() {
 
 	fmt.Println("Allocating on Build1 with a new sessionID")
 	sessionID1_1 := uuid.New().String()
-	if err := allocate(test1BuildID, sessionID1_1, cert); err != nil {
+	if err := allocate(ctx, ac, test1BuildID, sessionID1_1); err != nil {
 		handleError(err)
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 2, activeCount: 2, podCount: 4})
@@ -312,7 +328,7 @@ func main() {
 
 	fmt.Println("Allocating on Build2")
 	sessionID2 := uuid.New().String()
-	if err := allocate(test2BuildID, sessionID2, cert); err != nil {
+	if err := allocate(ctx, ac, test2BuildID, sessionID2); err != nil {
 		handleError(err)
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 2, activeCount: 2, podCount: 4})
@@ -321,7 +337,7 @@ func main() {
 
 	fmt.Println("Allocating on Build1 with a new sessionID")
 	sessionID1_2 := uuid.New().String()
-	if err := allocate(test1BuildID, sessionID1_2, cert); err != nil {
+	if err := allocate(ctx, ac, test1BuildID, sessionID1_2); err != nil {
 		handleError(err)
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 1, activeCount: 3, podCount: 4})
@@ -330,7 +346,7 @@ func main() {
 
 	fmt.Println("Allocating on Build1 with a new sessionID")
 	sessionID1_3 := uuid.New().String()
-	if err := allocate(test1BuildID, sessionID1_3, cert); err != nil {
+	if err := allocate(ctx, ac, test1BuildID, sessionID1_3); err != nil {
 		handleError(err)
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 0, activeCount: 4, podCount: 4})
@@ -339,8 +355,8 @@ func main() {
 
 	fmt.Println("Allocating on Build1 with a new sessionID, expecting 429")
 	sessionID1_4 := uuid.New().String()
-	if err := allocate(test1BuildID, sessionID1_4, cert); err.Error() != fmt.Sprintf("%s 429", invalidStatusCode) {
-		handleError(err)
+	if err := allocate(ctx, ac, test1BuildID, sessionID1_4); !errors.Is(err, allocationclient.ErrResourceExhausted) {
+		handleError(fmt.Errorf("expected a ResourceExhausted error, got %v", err))
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 0, activeCount: 4, podCount: 4})
 	validateBuildState(ctx, buildState{buildID: test2BuildID, buildName: testBuild2Name, standingByCount: 3, activeCount: 1, podCount: 4})
@@ -375,24 +391,24 @@ func main() {
 
 	fmt.Println("Allocating on Build1 with a non-Guid sessionID, expecting 400")
 	sessionID1_5 := "notAGuid"
-	if err := allocate(test1BuildID, sessionID1_5, cert); err.Error() != fmt.Sprintf("%s 400", invalidStatusCode) {
-		handleError(err)
+	if err := allocate(ctx, ac, test1BuildID, sessionID1_5); !errors.Is(err, allocationclient.ErrInvalidArgument) {
+		handleError(fmt.Errorf("expected a InvalidArgument error, got %v", err))
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 1, activeCount: 4, podCount: 5})
 	validateBuildState(ctx, buildState{buildID: test2BuildID, buildName: testBuild2Name, standingByCount: 3, activeCount: 1, podCount: 4})
 
 	fmt.Println("Allocating with a non-Guid BuildID, expecting 400")
 	sessionID1_6 := uuid.New().String()
-	if err := allocate("notAGuid", sessionID1_6, cert); err.Error() != fmt.Sprintf("%s 400", invalidStatusCode) {
-		handleError(err)
+	if err := allocate(ctx, ac, "notAGuid", sessionID1_6); !errors.Is(err, allocationclient.ErrInvalidArgument) {
+		handleError(fmt.Errorf("expected a InvalidArgument error, got %v", err))
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 1, activeCount: 4, podCount: 5})
 	validateBuildState(ctx, buildState{buildID: test2BuildID, buildName: testBuild2Name, standingByCount: 3, activeCount: 1, podCount: 4})
 
 	fmt.Println("Allocating with a non existent BuildID, expecting 404")
 	sessionID1_7 := uuid.New().String()
-	if err := allocate(uuid.New().String(), sessionID1_7, cert); err.Error() != fmt.Sprintf("%s 404", invalidStatusCode) {
-		handleError(err)
+	if err := allocate(ctx, ac, uuid.New().String(), sessionID1_7); !errors.Is(err, allocationclient.ErrNotFound) {
+		handleError(fmt.Errorf("expected a NotFound error, got %v", err))
 	}
 	validateBuildState(ctx, buildState{buildID: test1BuildID, buildName: testBuild1Name, standingByCount: 1, activeCount: 4, podCount: 5})
 	validateBuildState(ctx, buildState{buildID: test2BuildID, buildName: testBuild2Name, standingByCount: 3, activeCount: 1, podCount: 4})
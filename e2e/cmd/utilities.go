@@ -3,17 +3,14 @@ package main
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
-	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
-	"net/http"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	allocationclient "github.com/playfab/thundernetes/client"
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -110,41 +107,18 @@ func loopCheck(fn func(context.Context, string, string, buildState) error, ctx c
 	return err
 }
 
-func allocate(buildID, sessionID string, cert tls.Certificate) error {
-	// curl --key ~/private.pem --cert ~/public.pem --insecure -H 'Content-Type: application/json' -d '{"buildID":"85ffe8da-c82f-4035-86c5-9d2b5f42d6f5","sessionID":"85ffe8da-c82f-4035-86c5-9d2b5f42d6f5"}' https://${IP}:5000/api/v1/allocate
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
-		Certificates:       []tls.Certificate{cert},
-	}
-
-	transport := &http.Transport{TLSClientConfig: tlsConfig}
-	client := &http.Client{Transport: transport}
-
-	postBody, _ := json.Marshal(map[string]interface{}{
-		"buildID":        buildID,
-		"sessionID":      sessionID,
-		"sessionCookie":  "randomCookie",
-		"initialPlayers": []string{"player1", "player2"},
+// allocate allocates a GameServer for the session on the build and validates the allocation details
+func allocate(ctx context.Context, ac *allocationclient.Client, buildID, sessionID string) error {
+	ar, err := ac.Allocate(ctx, &allocationclient.AllocateRequest{
+		BuildID:        buildID,
+		SessionID:      sessionID,
+		SessionCookie:  "randomCookie",
+		InitialPlayers: []string{"player1", "player2"},
 	})
-	postBodyBytes := bytes.NewBuffer(postBody)
-	resp, err := client.Post("https://localhost:5000/api/v1/allocate", "application/json", postBodyBytes)
-	//Handle Error
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%s %d", invalidStatusCode, resp.StatusCode)
-	}
-	//Read the response body
-	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
 
-	ar := &AllocationResult{}
-	json.Unmarshal(body, ar)
-
 	if ar.IPV4Address == "" {
 		return fmt.Errorf("invalid IPV4Address %s", ar.IPV4Address)
 	}
@@ -156,6 +130,21 @@ func allocate(buildID, sessionID string, cert tls.Certificate) error {
 	return nil
 }
 
+// validateSession validates that the session is hosted by an Active GameServer of the build
+func validateSession(ctx context.Context, ac *allocationclient.Client, buildID, sessionID string) error {
+	gs, err := ac.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if gs.BuildID != buildID {
+		return fmt.Errorf("session %s is hosted by build %s, expected %s", sessionID, gs.BuildID, buildID)
+	}
+	if gs.State != string(mpsv1alpha1.GameServerStateActive) {
+		return fmt.Errorf("session %s is hosted by a GameServer in state %s", sessionID, gs.State)
+	}
+	return nil
+}
+
 func validateBuildState(ctx context.Context, state buildState) {
 	fmt.Printf("    Verifying that %d pods are in state %s for build %s\n", state.podCount, v1.PodRunning, state.buildName)
 	err := loopCheck(verifyPods, ctx, state)
@@ -35,10 +35,14 @@ fi
 echo "-----Creating temp certificates for TLS security on the operator's API server-----"
 export TLS_PRIVATE=/tmp/${RANDOM}.pem
 export TLS_PUBLIC=/tmp/${RANDOM}.pem
-openssl req -x509 -newkey rsa:4096 -nodes -keyout ${TLS_PRIVATE} -out ${TLS_PUBLIC} -days 365 -subj '/CN=localhost'
+openssl req -x509 -newkey rsa:4096 -nodes -keyout ${TLS_PRIVATE} -out ${TLS_PUBLIC} -days 365 -subj '/CN=localhost' -addext 'subjectAltName = DNS:localhost'
 kubectl create namespace thundernetes-system
 kubectl create secret tls tls-secret -n thundernetes-system --cert=${TLS_PUBLIC} --key=${TLS_PRIVATE}
 
+echo "-----Installing cert-manager for the certificate of the admission webhooks-----"
+kubectl apply -f https://github.com/jetstack/cert-manager/releases/download/v1.5.3/cert-manager.yaml
+kubectl wait --for=condition=available --timeout=300s deployment --all -n cert-manager
+
 echo "-----Compiling, building and deploying to local Kubernetes cluster-----"
 IMG=${IMAGE_NAME_OPERATOR}:${OPERATOR_TAG} API_SERVICE_SECURITY=usetls make -C "${DIR}"/../operator deploy
 
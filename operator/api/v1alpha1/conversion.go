@@ -0,0 +1,10 @@
+package v1alpha1
+
+// v1alpha1 is the storage version of the mps.playfab.com API group, so it's the hub of the conversions
+// the other versions convert to and from it, see https://book.kubebuilder.io/multiversion-tutorial/conversion-concepts.html
+
+// Hub marks the GameServerBuild as a conversion hub
+func (*GameServerBuild) Hub() {}
+
+// Hub marks the GameServer as a conversion hub
+func (*GameServer) Hub() {}
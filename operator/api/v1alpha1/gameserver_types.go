@@ -41,6 +41,19 @@ const (
 	Unhealthy GameServerHealth = "Unhealthy"
 )
 
+// NextScheduledMaintenanceAnnotation is set on a Node to the RFC3339 time of its next scheduled maintenance,
+// e.g. by the tool that schedules the maintenance, so that the game servers on the Node can warn their players
+const NextScheduledMaintenanceAnnotation = "mps.playfab.com/next-scheduled-maintenance"
+
+// CrashRetainedLabel is set to "true" on a Crashed GameServer that is retained for debugging, since the RetainCrashed of its GameServerBuild is set
+// the crash of a retained GameServer has been counted, the GameServer is deleted once newer crashes replace it
+const CrashRetainedLabel = "mps.playfab.com/crash-retained"
+
+// IsCrashRetained returns true if the GameServer has the CrashRetainedLabel
+func (gs *GameServer) IsCrashRetained() bool {
+	return gs.Labels[CrashRetainedLabel] == "true"
+}
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -68,17 +81,33 @@ type GameServerSpec struct {
 type GameServerStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
-	Health         GameServerHealth `json:"health,omitempty"`
-	State          GameServerState  `json:"state,omitempty"`
-	PublicIP       string           `json:"publicIP,omitempty"`
-	Ports          string           `json:"ports,omitempty"`
-	SessionID      string           `json:"sessionID,omitempty"`
-	SessionCookie  string           `json:"sessionCookie,omitempty"`
-	InitialPlayers []string         `json:"initialPlayers,omitempty"`
+	Health           GameServerHealth `json:"health,omitempty"`
+	State            GameServerState  `json:"state,omitempty"`
+	PublicIP         string           `json:"publicIP,omitempty"`
+	Ports            string           `json:"ports,omitempty"`
+	NodeName         string           `json:"nodeName,omitempty"`
+	SessionID        string           `json:"sessionID,omitempty"`
+	SessionCookie    string           `json:"sessionCookie,omitempty"`
+	InitialPlayers   []string         `json:"initialPlayers,omitempty"`
+	ConnectedPlayers []string         `json:"connectedPlayers,omitempty"`
+	// PlayerHistory are the IDs of all the players that were allowed in the session, including the ones that were removed afterwards
+	PlayerHistory []string `json:"playerHistory,omitempty"`
+	// StandingByTime is when the game server reached the StandingBy state, the creation time is metadata.creationTimestamp
+	StandingByTime *metav1.Time `json:"standingByTime,omitempty"`
+	// AllocatedTime is when the game server was allocated for a session and became Active
+	AllocatedTime *metav1.Time `json:"allocatedTime,omitempty"`
+	// CompletionTime is when the game server process exited, i.e. when the game server reached the GameCompleted or the Crashed state
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// NextScheduledMaintenance is the next scheduled maintenance of the Node of the game server, it's passed to the game server in the heartbeat responses
+	NextScheduledMaintenance *metav1.Time `json:"nextScheduledMaintenance,omitempty"`
+	// TerminationRequestedTime is when the controller asked the game server to terminate its session, since it exceeded the MaxActiveDuration of its build
+	// the sidecar passes the Terminate operation to the game server in the heartbeat responses
+	TerminationRequestedTime *metav1.Time `json:"terminationRequestedTime,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:storageversion
 //+kubebuilder:resource:singular=gameserver,path=gameservers,scope=Namespaced,shortName=gs
 //+kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.health`
 //+kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the validating webhook of the GameServer with the manager
+func (gs *GameServer) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(gs).
+		Complete()
+}
+
+// the GameServers are created by the controller itself, so its webhook ignores failures
+// this way the controller can still create GameServers while its webhook server is not available, e.g. while it's restarting
+//+kubebuilder:webhook:path=/validate-mps-playfab-com-v1alpha1-gameserver,mutating=false,failurePolicy=ignore,sideEffects=None,groups=mps.playfab.com,resources=gameservers,verbs=create;update,versions=v1alpha1,name=vgameserver.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &GameServer{}
+
+// ValidateCreate validates the spec of a new GameServer
+// GameServers are usually created by the controller from a valid GameServerBuild, this catches the ones that are created directly
+func (gs *GameServer) ValidateCreate() error {
+	return gs.toAggregateError(gs.validateSpec())
+}
+
+// ValidateUpdate validates that the BuildID and the TitleID of an updated GameServer did not change
+// the status updates of the sidecar and the controller use the status subresource, so they are not validated here
+func (gs *GameServer) ValidateUpdate(old runtime.Object) error {
+	oldGs := old.(*GameServer)
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, validateImmutable(gs.Spec.BuildID, oldGs.Spec.BuildID, specPath.Child("buildID"))...)
+	allErrs = append(allErrs, validateImmutable(gs.Spec.TitleID, oldGs.Spec.TitleID, specPath.Child("titleID"))...)
+	return gs.toAggregateError(allErrs)
+}
+
+// ValidateDelete does not validate anything, a GameServer can always be deleted
+func (gs *GameServer) ValidateDelete() error {
+	return nil
+}
+
+// validateSpec returns the errors of the GameServer spec
+func (gs *GameServer) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, validateBuildID(gs.Spec.BuildID, specPath.Child("buildID"))...)
+	allErrs = append(allErrs, validateTitleID(gs.Spec.TitleID, specPath.Child("titleID"))...)
+	allErrs = append(allErrs, validatePortsToExpose(gs.Spec.PortsToExpose, &gs.Spec.PodSpec, specPath.Child("portsToExpose"))...)
+	return allErrs
+}
+
+// toAggregateError returns an Invalid API error for the GameServer, or nil if there are no errors
+func (gs *GameServer) toAggregateError(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(schema.GroupKind{Group: GroupVersion.Group, Kind: "GameServer"}, gs.Name, allErrs)
+}
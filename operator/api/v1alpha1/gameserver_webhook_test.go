@@ -0,0 +1,40 @@
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("GameServer webhook tests", func() {
+	It("should validate the spec of a new GameServer", func() {
+		gs := newTestGameServer()
+		Expect(gs.ValidateCreate()).To(Succeed())
+
+		gs.Spec.BuildID = "build1"
+		gs.Spec.PortsToExpose[0].PortName = "unknown"
+		expectInvalid(gs.ValidateCreate(), "spec.buildID", "spec.portsToExpose[0].portName")
+	})
+	It("should reject changing the BuildID and the TitleID", func() {
+		old := newTestGameServer()
+		gs := newTestGameServer()
+		gs.Labels = map[string]string{"label1": "value1"}
+		Expect(gs.ValidateUpdate(old)).To(Succeed())
+
+		gs.Spec.BuildID = testBuildID2
+		gs.Spec.TitleID = "1E04"
+		expectInvalid(gs.ValidateUpdate(old), "spec.buildID", "spec.titleID")
+	})
+})
+
+func newTestGameServer() *GameServer {
+	return &GameServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "gs1", Namespace: "default"},
+		Spec: GameServerSpec{
+			BuildID:       testBuildID,
+			TitleID:       "1E03",
+			PortsToExpose: []PortToExpose{{ContainerName: "gameserver", PortName: "gameport"}},
+			PodSpec:       newTestPodSpec(),
+		},
+	}
+}
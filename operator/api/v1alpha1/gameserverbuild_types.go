@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -33,6 +35,49 @@ const (
 	BuildUnhealthy GameServerBuildHealth = "Unhealthy"
 )
 
+// CordonedAnnotation is set to "true" on a GameServerBuild to stop the controller from creating new GameServers for it
+// the existing GameServers are not affected, so the StandingBy ones can still be allocated
+const CordonedAnnotation = "mps.playfab.com/cordoned"
+
+// IsCordoned returns true if the GameServerBuild has the CordonedAnnotation
+func (gsb *GameServerBuild) IsCordoned() bool {
+	return gsb.Annotations[CordonedAnnotation] == "true"
+}
+
+// ForceDeleteAnnotation is set to "true" on a GameServerBuild to delete it without waiting for its Active GameServers
+// it can be set before or after the GameServerBuild is deleted
+const ForceDeleteAnnotation = "mps.playfab.com/force-delete"
+
+// IsForceDeleted returns true if the GameServerBuild has the ForceDeleteAnnotation
+func (gsb *GameServerBuild) IsForceDeleted() bool {
+	return gsb.Annotations[ForceDeleteAnnotation] == "true"
+}
+
+// DefaultDrainTimeout is the default DrainTimeout of a GameServerBuild
+const DefaultDrainTimeout = time.Hour
+
+// GetDrainTimeout returns the DrainTimeout of the GameServerBuild, or its default
+func (gsb *GameServerBuild) GetDrainTimeout() time.Duration {
+	if gsb.Spec.DrainTimeout == nil {
+		return DefaultDrainTimeout
+	}
+	return gsb.Spec.DrainTimeout.Duration
+}
+
+// The condition types of a GameServerBuild
+const (
+	// BuildConditionReady is True when the requested StandingBy GameServers, or as many as Max allows, are ready
+	BuildConditionReady = "Ready"
+	// BuildConditionScalingLimited is True when the requested StandingBy GameServers cannot be created, since Max is reached or the build is cordoned or paused
+	BuildConditionScalingLimited = "ScalingLimited"
+	// BuildConditionUnhealthy is True when CrashesToMarkUnhealthy GameServers crashed
+	BuildConditionUnhealthy = "Unhealthy"
+	// BuildConditionProgressing is True while GameServers are being initialized, created or deleted to match the spec
+	BuildConditionProgressing = "Progressing"
+	// BuildConditionDraining is True while the deleted GameServerBuild waits for its Active GameServers to finish their game sessions
+	BuildConditionDraining = "Draining"
+)
+
 // GameServerBuildSpec defines the desired state of GameServerBuild
 type GameServerBuildSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -67,6 +112,117 @@ type GameServerBuildSpec struct {
 
 	// BuildMetadata is the metadata for this GameServerBuild
 	BuildMetadata []BuildMetadataItem `json:"buildMetadata,omitempty"`
+
+	// StandingByPrediction enables the prediction of the StandingBy GameServers from the allocation history of the GameServerBuild
+	// the controller raises the effective StandingBy ahead of the predicted allocations, never below StandingBy
+	StandingByPrediction *StandingByPrediction `json:"standingByPrediction,omitempty"`
+
+	//+kubebuilder:default=LeastUtilizedNode
+	// ScaleDownPolicy decides which StandingBy GameServers are deleted first when StandingBy or Max is decreased
+	ScaleDownPolicy ScaleDownPolicy `json:"scaleDownPolicy,omitempty"`
+
+	//+kubebuilder:default=Random
+	// AllocationStrategy decides which StandingBy GameServer is allocated for a new game session
+	AllocationStrategy AllocationStrategy `json:"allocationStrategy,omitempty"`
+
+	// DrainTimeout is how long a deleted GameServerBuild waits for its Active GameServers to finish their game sessions, it defaults to 1h
+	// the remaining Active GameServers are deleted once it expires
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+
+	// Paused freezes the GameServerBuild, the controller does not create or delete its GameServers and no new game sessions are allocated
+	// the Active game sessions continue and the status is still updated
+	Paused bool `json:"paused,omitempty"`
+
+	// StandingByTTL is the maximum time a GameServer stays StandingBy, older StandingBy GameServers are deleted and replaced
+	StandingByTTL *metav1.Duration `json:"standingByTTL,omitempty"`
+
+	// MaxActiveDuration is the maximum duration of a game session, the GameServers that exceed it are asked to terminate through the GSDK
+	// and are deleted if they are still Active after a grace period
+	MaxActiveDuration *metav1.Duration `json:"maxActiveDuration,omitempty"`
+
+	//+kubebuilder:validation:Minimum=0
+	// RetainCrashed is the number of the most recent Crashed GameServers, and their pods, that are kept for debugging instead of being deleted
+	// the retained GameServers are not counted as StandingBy or Active, they keep their host ports reserved until they are deleted
+	RetainCrashed int `json:"retainCrashed,omitempty"`
+}
+
+//+kubebuilder:validation:Enum=Random;Packed;Distributed
+// AllocationStrategy decides which StandingBy GameServer is allocated for a new game session
+type AllocationStrategy string
+
+const (
+	// AllocationRandom allocates a random StandingBy GameServer
+	AllocationRandom AllocationStrategy = "Random"
+	// AllocationPacked allocates a StandingBy GameServer on the Node with the most Active GameServers,
+	// so that the game sessions are consolidated and the other Nodes can be scaled down
+	AllocationPacked AllocationStrategy = "Packed"
+	// AllocationDistributed allocates a StandingBy GameServer on the Node with the fewest Active GameServers,
+	// so that the game sessions are spread across the Nodes
+	AllocationDistributed AllocationStrategy = "Distributed"
+)
+
+//+kubebuilder:validation:Enum=LeastUtilizedNode;Newest;Oldest
+// ScaleDownPolicy decides which StandingBy GameServers are deleted first on scale down
+// Unhealthy GameServers are always deleted first
+type ScaleDownPolicy string
+
+const (
+	// ScaleDownLeastUtilizedNode deletes the GameServers on the Nodes with the fewest GameServers first, so that the Nodes can be scaled down sooner
+	ScaleDownLeastUtilizedNode ScaleDownPolicy = "LeastUtilizedNode"
+	// ScaleDownNewest deletes the most recently created GameServers first
+	ScaleDownNewest ScaleDownPolicy = "Newest"
+	// ScaleDownOldest deletes the least recently created GameServers first
+	ScaleDownOldest ScaleDownPolicy = "Oldest"
+)
+
+// StandingByPrediction configures the prediction of the StandingBy GameServers
+// the allocations are counted in windows, the allocations of the next window are forecast from the moving average
+// of the last windows and from the window one Seasonality ago, e.g. the same minutes of the previous hour
+type StandingByPrediction struct {
+	//+kubebuilder:validation:Minimum=0
+	// MaxStandingBy is the maximum effective StandingBy, it must not be lower than StandingBy
+	MaxStandingBy int `json:"maxStandingBy"`
+	// Window is the duration of the windows in which the allocations are counted and forecast, it defaults to 5m
+	// it should be about the time it takes for a new GameServer to become StandingBy
+	Window *metav1.Duration `json:"window,omitempty"`
+	// Seasonality is the period after which the allocations repeat, it defaults to 1h
+	// it must be a multiple of Window and at most 288 windows, e.g. 24h with 5m windows
+	Seasonality *metav1.Duration `json:"seasonality,omitempty"`
+}
+
+const (
+	// DefaultStandingByPredictionWindow is the default Window of the StandingByPrediction
+	DefaultStandingByPredictionWindow = 5 * time.Minute
+	// DefaultStandingByPredictionSeasonality is the default Seasonality of the StandingByPrediction
+	DefaultStandingByPredictionSeasonality = time.Hour
+	// MinStandingByPredictionWindow is the minimum Window of the StandingByPrediction
+	MinStandingByPredictionWindow = time.Minute
+	// MaxStandingByPredictionWindows is the maximum number of windows in a Seasonality, it limits the size of the AllocationHistory
+	MaxStandingByPredictionWindows = 288
+)
+
+// GetWindow returns the Window of the StandingByPrediction, or its default
+func (p *StandingByPrediction) GetWindow() time.Duration {
+	if p.Window == nil {
+		return DefaultStandingByPredictionWindow
+	}
+	return p.Window.Duration
+}
+
+// GetSeasonality returns the Seasonality of the StandingByPrediction, or its default
+func (p *StandingByPrediction) GetSeasonality() time.Duration {
+	if p.Seasonality == nil {
+		return DefaultStandingByPredictionSeasonality
+	}
+	return p.Seasonality.Duration
+}
+
+// AllocationHistory contains the number of allocations of a GameServerBuild in consecutive windows
+type AllocationHistory struct {
+	// WindowStart is the start of the last window, which is the current one
+	WindowStart metav1.Time `json:"windowStart"`
+	// Allocations contains the number of allocations in each window, the oldest first
+	Allocations []int `json:"allocations"`
 }
 
 // GameServerBuildStatus defines the observed state of GameServerBuild
@@ -79,15 +235,28 @@ type GameServerBuildStatus struct {
 	CurrentActive                 int                   `json:"currentActive"`
 	CrashesCount                  int                   `json:"crashesCount"`
 	Health                        GameServerBuildHealth `json:"health"`
+	// ObservedGeneration is the generation of the GameServerBuild spec that the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe the state of the GameServerBuild
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Selector is the label selector of the GameServers and the Pods of the GameServerBuild, it is used by the scale subresource
+	Selector string `json:"selector,omitempty"`
+	// EffectiveStandingBy is the StandingBy that the controller maintains when StandingByPrediction is set, it's the predicted StandingBy within its bounds
+	EffectiveStandingBy int `json:"effectiveStandingBy,omitempty"`
+	// AllocationHistory contains the allocations of the last Seasonality, it's recorded only when StandingByPrediction is set
+	AllocationHistory *AllocationHistory `json:"allocationHistory,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.standingBy,statuspath=.status.currentStandingBy,selectorpath=.status.selector
+//+kubebuilder:storageversion
 //+kubebuilder:resource:singular=gameserverbuild,path=gameserverbuilds,scope=Namespaced,shortName=gsb
 //+kubebuilder:printcolumn:name="StandBy",type=string,JSONPath=`.status.currentStandingByReadyDesired`
 //+kubebuilder:printcolumn:name="Active",type=string,JSONPath=`.status.currentActive`
 //+kubebuilder:printcolumn:name="Crashes",type=string,JSONPath=`.status.crashesCount`
 //+kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.health`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
 
 // GameServerBuild is the Schema for the gameserverbuilds API
 type GameServerBuild struct {
@@ -109,8 +278,10 @@ type GameServerBuildList struct {
 
 // PortToExpose is a tuple of container/port names that correspond to the ports that will be exposed on the VM
 type PortToExpose struct {
-	ContainerName string `json:"containerName"`
-	PortName      string `json:"portName"`
+	// ContainerName is the name of the container, it can be omitted if the PodSpec has a single container
+	ContainerName string `json:"containerName,omitempty"`
+	// PortName is the name of the container port
+	PortName string `json:"portName"`
 }
 
 func init() {
@@ -0,0 +1,124 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the defaulting and the validating webhooks of the GameServerBuild with the manager
+func (gsb *GameServerBuild) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(gsb).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-mps-playfab-com-v1alpha1-gameserverbuild,mutating=true,failurePolicy=fail,sideEffects=None,groups=mps.playfab.com,resources=gameserverbuilds,verbs=create;update,versions=v1alpha1,name=mgameserverbuild.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Defaulter = &GameServerBuild{}
+
+// Default sets the default values of the GameServerBuild
+// the ContainerName of a PortToExpose defaults to the only container of the PodSpec
+func (gsb *GameServerBuild) Default() {
+	if len(gsb.Spec.PodSpec.Containers) != 1 {
+		return
+	}
+	for i := range gsb.Spec.PortsToExpose {
+		if gsb.Spec.PortsToExpose[i].ContainerName == "" {
+			gsb.Spec.PortsToExpose[i].ContainerName = gsb.Spec.PodSpec.Containers[0].Name
+		}
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-mps-playfab-com-v1alpha1-gameserverbuild,mutating=false,failurePolicy=fail,sideEffects=None,groups=mps.playfab.com,resources=gameserverbuilds,verbs=create;update,versions=v1alpha1,name=vgameserverbuild.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &GameServerBuild{}
+
+// ValidateCreate validates the spec of a new GameServerBuild
+func (gsb *GameServerBuild) ValidateCreate() error {
+	return gsb.toAggregateError(gsb.validateSpec())
+}
+
+// ValidateUpdate validates the spec of an updated GameServerBuild
+// the spec is validated only if it changed, so that the GameServerBuilds that were created before the webhook can still be updated
+// BuildID and TitleID cannot change, since the GameServers and the allocations of the GameServerBuild are tracked by them
+func (gsb *GameServerBuild) ValidateUpdate(old runtime.Object) error {
+	oldGsb := old.(*GameServerBuild)
+	var allErrs field.ErrorList
+	if !equality.Semantic.DeepEqual(gsb.Spec, oldGsb.Spec) {
+		allErrs = gsb.validateSpec()
+	}
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, validateImmutable(gsb.Spec.BuildID, oldGsb.Spec.BuildID, specPath.Child("buildID"))...)
+	allErrs = append(allErrs, validateImmutable(gsb.Spec.TitleID, oldGsb.Spec.TitleID, specPath.Child("titleID"))...)
+	return gsb.toAggregateError(allErrs)
+}
+
+// ValidateDelete does not validate anything, a GameServerBuild can always be deleted
+func (gsb *GameServerBuild) ValidateDelete() error {
+	return nil
+}
+
+// validateSpec returns the errors of the GameServerBuild spec
+func (gsb *GameServerBuild) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, validateBuildID(gsb.Spec.BuildID, specPath.Child("buildID"))...)
+	allErrs = append(allErrs, validateTitleID(gsb.Spec.TitleID, specPath.Child("titleID"))...)
+	if gsb.Spec.StandingBy > gsb.Spec.Max {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("standingBy"), gsb.Spec.StandingBy, "must not be greater than max"))
+	}
+	allErrs = append(allErrs, validatePortsToExpose(gsb.Spec.PortsToExpose, &gsb.Spec.PodSpec, specPath.Child("portsToExpose"))...)
+	if gsb.Spec.StandingByPrediction != nil {
+		allErrs = append(allErrs, validateStandingByPrediction(gsb.Spec.StandingByPrediction, gsb.Spec.StandingBy, specPath.Child("standingByPrediction"))...)
+	}
+	allErrs = append(allErrs, validatePositiveDuration(gsb.Spec.DrainTimeout, specPath.Child("drainTimeout"), true)...)
+	allErrs = append(allErrs, validatePositiveDuration(gsb.Spec.StandingByTTL, specPath.Child("standingByTTL"), false)...)
+	allErrs = append(allErrs, validatePositiveDuration(gsb.Spec.MaxActiveDuration, specPath.Child("maxActiveDuration"), false)...)
+	return allErrs
+}
+
+// validateStandingByPrediction returns the errors of the StandingByPrediction
+// the Seasonality must be a whole number of windows, so that the window one Seasonality ago is in the AllocationHistory
+func validateStandingByPrediction(p *StandingByPrediction, standingBy int, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if p.MaxStandingBy < standingBy {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxStandingBy"), p.MaxStandingBy, "must not be lower than standingBy"))
+	}
+	window, seasonality := p.GetWindow(), p.GetSeasonality()
+	if window < MinStandingByPredictionWindow {
+		return append(allErrs, field.Invalid(fldPath.Child("window"), window.String(), fmt.Sprintf("must be at least %s", MinStandingByPredictionWindow)))
+	}
+	if seasonality%window != 0 || seasonality < window {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("seasonality"), seasonality.String(), "must be a multiple of window"))
+	} else if seasonality/window > MaxStandingByPredictionWindows {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("seasonality"), seasonality.String(), fmt.Sprintf("must be at most %d windows", MaxStandingByPredictionWindows)))
+	}
+	return allErrs
+}
+
+// validatePositiveDuration returns an error if the optional duration is negative, or zero when allowZero is false
+func validatePositiveDuration(d *metav1.Duration, fldPath *field.Path, allowZero bool) field.ErrorList {
+	if d == nil || d.Duration > 0 || (allowZero && d.Duration == 0) {
+		return nil
+	}
+	if allowZero {
+		return field.ErrorList{field.Invalid(fldPath, d.Duration.String(), "must not be negative")}
+	}
+	return field.ErrorList{field.Invalid(fldPath, d.Duration.String(), "must be positive")}
+}
+
+// toAggregateError returns an Invalid API error for the GameServerBuild, or nil if there are no errors
+func (gsb *GameServerBuild) toAggregateError(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(schema.GroupKind{Group: GroupVersion.Group, Kind: "GameServerBuild"}, gsb.Name, allErrs)
+}
@@ -0,0 +1,119 @@
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("GameServerBuild webhook tests", func() {
+	It("should accept a valid GameServerBuild", func() {
+		Expect(newTestGameServerBuild().ValidateCreate()).To(Succeed())
+	})
+	It("should reject a BuildID that is not a UUID", func() {
+		gsb := newTestGameServerBuild()
+		gsb.Spec.BuildID = "build1"
+		expectInvalid(gsb.ValidateCreate(), "spec.buildID")
+	})
+	It("should reject an empty TitleID", func() {
+		gsb := newTestGameServerBuild()
+		gsb.Spec.TitleID = ""
+		expectInvalid(gsb.ValidateCreate(), "spec.titleID")
+	})
+	It("should reject more StandingBy than Max GameServers", func() {
+		gsb := newTestGameServerBuild()
+		gsb.Spec.StandingBy = 5
+		expectInvalid(gsb.ValidateCreate(), "spec.standingBy")
+	})
+	It("should validate the StandingByPrediction", func() {
+		gsb := newTestGameServerBuild()
+		gsb.Spec.StandingByPrediction = &StandingByPrediction{MaxStandingBy: 4}
+		Expect(gsb.ValidateCreate()).To(Succeed())
+
+		gsb.Spec.StandingByPrediction = &StandingByPrediction{
+			MaxStandingBy: 1,
+			Window:        &metav1.Duration{Duration: 10 * time.Minute},
+			Seasonality:   &metav1.Duration{Duration: 25 * time.Minute},
+		}
+		expectInvalid(gsb.ValidateCreate(), "spec.standingByPrediction.maxStandingBy", "spec.standingByPrediction.seasonality")
+
+		gsb.Spec.StandingByPrediction = &StandingByPrediction{
+			MaxStandingBy: 4,
+			Window:        &metav1.Duration{Duration: time.Minute},
+			Seasonality:   &metav1.Duration{Duration: 24 * time.Hour},
+		}
+		expectInvalid(gsb.ValidateCreate(), "spec.standingByPrediction.seasonality")
+
+		gsb.Spec.StandingByPrediction = &StandingByPrediction{MaxStandingBy: 4, Window: &metav1.Duration{Duration: 30 * time.Second}}
+		expectInvalid(gsb.ValidateCreate(), "spec.standingByPrediction.window")
+	})
+	It("should reject a negative DrainTimeout", func() {
+		gsb := newTestGameServerBuild()
+		gsb.Spec.DrainTimeout = &metav1.Duration{Duration: -time.Minute}
+		expectInvalid(gsb.ValidateCreate(), "spec.drainTimeout")
+	})
+	It("should reject a StandingByTTL or a MaxActiveDuration that is not positive", func() {
+		gsb := newTestGameServerBuild()
+		gsb.Spec.StandingByTTL = &metav1.Duration{Duration: 24 * time.Hour}
+		gsb.Spec.MaxActiveDuration = &metav1.Duration{Duration: 2 * time.Hour}
+		Expect(gsb.ValidateCreate()).To(Succeed())
+
+		gsb.Spec.StandingByTTL = &metav1.Duration{}
+		gsb.Spec.MaxActiveDuration = &metav1.Duration{Duration: -time.Hour}
+		expectInvalid(gsb.ValidateCreate(), "spec.standingByTTL", "spec.maxActiveDuration")
+	})
+	It("should reject PortsToExpose that are not in the PodSpec", func() {
+		gsb := newTestGameServerBuild()
+		gsb.Spec.PortsToExpose = []PortToExpose{
+			{ContainerName: "unknown", PortName: "gameport"},
+			{ContainerName: "gameserver", PortName: "unknown"},
+		}
+		expectInvalid(gsb.ValidateCreate(), "spec.portsToExpose[0].containerName", "spec.portsToExpose[1].portName")
+	})
+	It("should reject changing the BuildID and the TitleID", func() {
+		old := newTestGameServerBuild()
+		gsb := newTestGameServerBuild()
+		gsb.Spec.StandingBy = 3
+		Expect(gsb.ValidateUpdate(old)).To(Succeed())
+
+		gsb.Spec.BuildID = testBuildID2
+		gsb.Spec.TitleID = "1E04"
+		expectInvalid(gsb.ValidateUpdate(old), "spec.buildID", "spec.titleID")
+	})
+	It("should not validate the unchanged spec of an existing GameServerBuild", func() {
+		old := newTestGameServerBuild()
+		old.Spec.StandingBy = 5
+		gsb := old.DeepCopy()
+		gsb.Labels = map[string]string{"label1": "value1"}
+		Expect(gsb.ValidateUpdate(old)).To(Succeed())
+	})
+	It("should default the container name of the PortsToExpose if there is a single container", func() {
+		gsb := newTestGameServerBuild()
+		gsb.Spec.PortsToExpose = []PortToExpose{{PortName: "gameport"}}
+		gsb.Default()
+		Expect(gsb.Spec.PortsToExpose).To(Equal([]PortToExpose{{ContainerName: "gameserver", PortName: "gameport"}}))
+		Expect(gsb.ValidateCreate()).To(Succeed())
+
+		gsb.Spec.PodSpec.Containers = append(gsb.Spec.PodSpec.Containers, corev1.Container{Name: "other"})
+		gsb.Spec.PortsToExpose = []PortToExpose{{PortName: "gameport"}}
+		gsb.Default()
+		Expect(gsb.Spec.PortsToExpose[0].ContainerName).To(BeEmpty())
+	})
+})
+
+func newTestGameServerBuild() *GameServerBuild {
+	return &GameServerBuild{
+		ObjectMeta: metav1.ObjectMeta{Name: "build1", Namespace: "default"},
+		Spec: GameServerBuildSpec{
+			BuildID:       testBuildID,
+			TitleID:       "1E03",
+			StandingBy:    2,
+			Max:           4,
+			PortsToExpose: []PortToExpose{{ContainerName: "gameserver", PortName: "gameport"}},
+			PodSpec:       newTestPodSpec(),
+		},
+	}
+}
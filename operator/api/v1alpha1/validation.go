@@ -0,0 +1,69 @@
+package v1alpha1
+
+import (
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// uuidRegex matches the UUIDs that the allocation API accepts as BuildIDs
+var uuidRegex = regexp.MustCompile("^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-4[a-fA-F0-9]{3}-[8|9|aA|bB][a-fA-F0-9]{3}-[a-fA-F0-9]{12}$")
+
+// validateBuildID returns an error if the BuildID is not a UUID, since GameServers can only be allocated by a UUID BuildID
+func validateBuildID(buildID string, fldPath *field.Path) field.ErrorList {
+	if !uuidRegex.MatchString(buildID) {
+		return field.ErrorList{field.Invalid(fldPath, buildID, "must be a UUID")}
+	}
+	return nil
+}
+
+// validateTitleID returns an error if the TitleID is empty
+func validateTitleID(titleID string, fldPath *field.Path) field.ErrorList {
+	if titleID == "" {
+		return field.ErrorList{field.Required(fldPath, "the TitleID is required")}
+	}
+	return nil
+}
+
+// validatePortsToExpose returns an error for every PortToExpose that does not reference a container and a port name of the PodSpec
+// the controller ignores these entries, so the port would never be exposed on the VM
+func validatePortsToExpose(portsToExpose []PortToExpose, podSpec *corev1.PodSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, portToExpose := range portsToExpose {
+		container := findContainer(podSpec, portToExpose.ContainerName)
+		if container == nil {
+			allErrs = append(allErrs, field.NotFound(fldPath.Index(i).Child("containerName"), portToExpose.ContainerName))
+			continue
+		}
+		found := false
+		for _, port := range container.Ports {
+			if port.Name == portToExpose.PortName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allErrs = append(allErrs, field.NotFound(fldPath.Index(i).Child("portName"), portToExpose.PortName))
+		}
+	}
+	return allErrs
+}
+
+// validateImmutable returns an error if an immutable field was changed
+func validateImmutable(newValue, oldValue string, fldPath *field.Path) field.ErrorList {
+	if newValue != oldValue {
+		return field.ErrorList{field.Invalid(fldPath, newValue, "field is immutable")}
+	}
+	return nil
+}
+
+// findContainer returns the container of the PodSpec with the specified name, or nil if there is none
+func findContainer(podSpec *corev1.PodSpec, name string) *corev1.Container {
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == name {
+			return &podSpec.Containers[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
+)
+
+const (
+	testBuildID  = "85ffe8da-c82f-4035-86c5-9d2b5f42d6f6"
+	testBuildID2 = "acb84898-cf73-46e2-8057-314ac557d85d"
+)
+
+func newTestPodSpec() corev1.PodSpec {
+	return corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name:  "gameserver",
+				Image: "gameserver:0.1",
+				Ports: []corev1.ContainerPort{{Name: "gameport", ContainerPort: 80}},
+			},
+		},
+	}
+}
+
+// expectInvalid verifies that the error is an Invalid API error for the specified fields
+func expectInvalid(err error, fields ...string) {
+	Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an Invalid error, got %v", err)
+	statusErr := err.(*apierrors.StatusError)
+	var causes []string
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		causes = append(causes, cause.Field)
+	}
+	Expect(causes).To(ConsistOf(fields))
+}
+
+func TestWebhooks(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t,
+		"Webhook Suite",
+		[]Reporter{printer.NewlineReporter{}})
+}
@@ -21,9 +21,31 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationHistory) DeepCopyInto(out *AllocationHistory) {
+	*out = *in
+	in.WindowStart.DeepCopyInto(&out.WindowStart)
+	if in.Allocations != nil {
+		in, out := &in.Allocations, &out.Allocations
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationHistory.
+func (in *AllocationHistory) DeepCopy() *AllocationHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BuildMetadataItem) DeepCopyInto(out *BuildMetadataItem) {
 	*out = *in
@@ -72,7 +94,7 @@ func (in *GameServerBuild) DeepCopyInto(out *GameServerBuild) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerBuild.
@@ -139,6 +161,26 @@ func (in *GameServerBuildSpec) DeepCopyInto(out *GameServerBuildSpec) {
 		*out = make([]BuildMetadataItem, len(*in))
 		copy(*out, *in)
 	}
+	if in.StandingByPrediction != nil {
+		in, out := &in.StandingByPrediction, &out.StandingByPrediction
+		*out = new(StandingByPrediction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DrainTimeout != nil {
+		in, out := &in.DrainTimeout, &out.DrainTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.StandingByTTL != nil {
+		in, out := &in.StandingByTTL, &out.StandingByTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxActiveDuration != nil {
+		in, out := &in.MaxActiveDuration, &out.MaxActiveDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerBuildSpec.
@@ -154,6 +196,18 @@ func (in *GameServerBuildSpec) DeepCopy() *GameServerBuildSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GameServerBuildStatus) DeepCopyInto(out *GameServerBuildStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllocationHistory != nil {
+		in, out := &in.AllocationHistory, &out.AllocationHistory
+		*out = new(AllocationHistory)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerBuildStatus.
@@ -232,6 +286,36 @@ func (in *GameServerStatus) DeepCopyInto(out *GameServerStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ConnectedPlayers != nil {
+		in, out := &in.ConnectedPlayers, &out.ConnectedPlayers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PlayerHistory != nil {
+		in, out := &in.PlayerHistory, &out.PlayerHistory
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StandingByTime != nil {
+		in, out := &in.StandingByTime, &out.StandingByTime
+		*out = (*in).DeepCopy()
+	}
+	if in.AllocatedTime != nil {
+		in, out := &in.AllocatedTime, &out.AllocatedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduledMaintenance != nil {
+		in, out := &in.NextScheduledMaintenance, &out.NextScheduledMaintenance
+		*out = (*in).DeepCopy()
+	}
+	if in.TerminationRequestedTime != nil {
+		in, out := &in.TerminationRequestedTime, &out.TerminationRequestedTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerStatus.
@@ -258,3 +342,28 @@ func (in *PortToExpose) DeepCopy() *PortToExpose {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StandingByPrediction) DeepCopyInto(out *StandingByPrediction) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Seasonality != nil {
+		in, out := &in.Seasonality, &out.Seasonality
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StandingByPrediction.
+func (in *StandingByPrediction) DeepCopy() *StandingByPrediction {
+	if in == nil {
+		return nil
+	}
+	out := new(StandingByPrediction)
+	in.DeepCopyInto(out)
+	return out
+}
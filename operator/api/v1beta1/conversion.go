@@ -0,0 +1,230 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// v1alpha1 is the hub of the conversions, so the v1beta1 types convert to and from it
+
+var _ conversion.Convertible = &GameServerBuild{}
+
+// ConvertTo converts the GameServerBuild to the v1alpha1 version
+// the CurrentStandingByReadyDesired field of v1alpha1 is computed from the StandingBy counts, like the controller does
+// the desired StandingBy is the EffectiveStandingBy when the StandingBy prediction is enabled
+func (src *GameServerBuild) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.GameServerBuild)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = v1alpha1.GameServerBuildSpec{
+		StandingBy:             src.Spec.StandingBy,
+		Max:                    src.Spec.Max,
+		PodSpec:                src.Spec.PodSpec,
+		TitleID:                src.Spec.TitleID,
+		BuildID:                src.Spec.BuildID,
+		CrashesToMarkUnhealthy: src.Spec.CrashesToMarkUnhealthy,
+		ScaleDownPolicy:        v1alpha1.ScaleDownPolicy(src.Spec.ScaleDownPolicy),
+		AllocationStrategy:     v1alpha1.AllocationStrategy(src.Spec.AllocationStrategy),
+		DrainTimeout:           src.Spec.DrainTimeout,
+		Paused:                 src.Spec.Paused,
+		StandingByTTL:          src.Spec.StandingByTTL,
+		MaxActiveDuration:      src.Spec.MaxActiveDuration,
+		RetainCrashed:          src.Spec.RetainCrashed,
+	}
+	for _, p := range src.Spec.PortsToExpose {
+		dst.Spec.PortsToExpose = append(dst.Spec.PortsToExpose, v1alpha1.PortToExpose{ContainerName: p.ContainerName, PortName: p.PortName})
+	}
+	for _, m := range src.Spec.BuildMetadata {
+		dst.Spec.BuildMetadata = append(dst.Spec.BuildMetadata, v1alpha1.BuildMetadataItem{Key: m.Key, Value: m.Value})
+	}
+	if p := src.Spec.StandingByPrediction; p != nil {
+		dst.Spec.StandingByPrediction = &v1alpha1.StandingByPrediction{MaxStandingBy: p.MaxStandingBy, Window: p.Window, Seasonality: p.Seasonality}
+	}
+
+	desiredStandingBy := src.Spec.StandingBy
+	if src.Spec.StandingByPrediction != nil && src.Status.EffectiveStandingBy > 0 {
+		desiredStandingBy = src.Status.EffectiveStandingBy
+	}
+	dst.Status = v1alpha1.GameServerBuildStatus{
+		CurrentInitializing:           src.Status.CurrentInitializing,
+		CurrentStandingBy:             src.Status.CurrentStandingBy,
+		CurrentStandingByReadyDesired: fmt.Sprintf("%d/%d", src.Status.CurrentStandingBy, desiredStandingBy),
+		CurrentActive:                 src.Status.CurrentActive,
+		CrashesCount:                  src.Status.CrashesCount,
+		Health:                        v1alpha1.GameServerBuildHealth(src.Status.Health),
+		ObservedGeneration:            src.Status.ObservedGeneration,
+		Conditions:                    src.Status.Conditions,
+		Selector:                      src.Status.Selector,
+		EffectiveStandingBy:           src.Status.EffectiveStandingBy,
+	}
+	if h := src.Status.AllocationHistory; h != nil {
+		dst.Status.AllocationHistory = &v1alpha1.AllocationHistory{WindowStart: h.WindowStart, Allocations: h.Allocations}
+	}
+	return nil
+}
+
+// ConvertFrom converts the GameServerBuild from the v1alpha1 version
+func (dst *GameServerBuild) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.GameServerBuild)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = GameServerBuildSpec{
+		StandingBy:             src.Spec.StandingBy,
+		Max:                    src.Spec.Max,
+		PodSpec:                src.Spec.PodSpec,
+		TitleID:                src.Spec.TitleID,
+		BuildID:                src.Spec.BuildID,
+		CrashesToMarkUnhealthy: src.Spec.CrashesToMarkUnhealthy,
+		ScaleDownPolicy:        ScaleDownPolicy(src.Spec.ScaleDownPolicy),
+		AllocationStrategy:     AllocationStrategy(src.Spec.AllocationStrategy),
+		DrainTimeout:           src.Spec.DrainTimeout,
+		Paused:                 src.Spec.Paused,
+		StandingByTTL:          src.Spec.StandingByTTL,
+		MaxActiveDuration:      src.Spec.MaxActiveDuration,
+		RetainCrashed:          src.Spec.RetainCrashed,
+	}
+	for _, p := range src.Spec.PortsToExpose {
+		dst.Spec.PortsToExpose = append(dst.Spec.PortsToExpose, PortToExpose{ContainerName: p.ContainerName, PortName: p.PortName})
+	}
+	for _, m := range src.Spec.BuildMetadata {
+		dst.Spec.BuildMetadata = append(dst.Spec.BuildMetadata, BuildMetadataItem{Key: m.Key, Value: m.Value})
+	}
+	if p := src.Spec.StandingByPrediction; p != nil {
+		dst.Spec.StandingByPrediction = &StandingByPrediction{MaxStandingBy: p.MaxStandingBy, Window: p.Window, Seasonality: p.Seasonality}
+	}
+
+	dst.Status = GameServerBuildStatus{
+		CurrentInitializing: src.Status.CurrentInitializing,
+		CurrentStandingBy:   src.Status.CurrentStandingBy,
+		CurrentActive:       src.Status.CurrentActive,
+		CrashesCount:        src.Status.CrashesCount,
+		Health:              GameServerBuildHealth(src.Status.Health),
+		ObservedGeneration:  src.Status.ObservedGeneration,
+		Conditions:          src.Status.Conditions,
+		Selector:            src.Status.Selector,
+		EffectiveStandingBy: src.Status.EffectiveStandingBy,
+	}
+	if h := src.Status.AllocationHistory; h != nil {
+		dst.Status.AllocationHistory = &AllocationHistory{WindowStart: h.WindowStart, Allocations: h.Allocations}
+	}
+	return nil
+}
+
+var _ conversion.Convertible = &GameServer{}
+
+// ConvertTo converts the GameServer to the v1alpha1 version
+// the structured ports are converted to the "containerPort:hostPort,..." string of v1alpha1
+func (src *GameServer) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.GameServer)
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = v1alpha1.GameServerSpec{
+		PodSpec: src.Spec.PodSpec,
+		TitleID: src.Spec.TitleID,
+		BuildID: src.Spec.BuildID,
+	}
+	for _, p := range src.Spec.PortsToExpose {
+		dst.Spec.PortsToExpose = append(dst.Spec.PortsToExpose, v1alpha1.PortToExpose{ContainerName: p.ContainerName, PortName: p.PortName})
+	}
+	for _, m := range src.Spec.BuildMetadata {
+		dst.Spec.BuildMetadata = append(dst.Spec.BuildMetadata, v1alpha1.BuildMetadataItem{Key: m.Key, Value: m.Value})
+	}
+
+	dst.Status = v1alpha1.GameServerStatus{
+		Health:                   v1alpha1.GameServerHealth(src.Status.Health),
+		State:                    v1alpha1.GameServerState(src.Status.State),
+		PublicIP:                 src.Status.PublicIP,
+		Ports:                    formatPorts(src.Status.Ports),
+		NodeName:                 src.Status.NodeName,
+		SessionID:                src.Status.SessionID,
+		SessionCookie:            src.Status.SessionCookie,
+		InitialPlayers:           src.Status.InitialPlayers,
+		ConnectedPlayers:         src.Status.ConnectedPlayers,
+		PlayerHistory:            src.Status.PlayerHistory,
+		StandingByTime:           src.Status.StandingByTime,
+		AllocatedTime:            src.Status.AllocatedTime,
+		CompletionTime:           src.Status.CompletionTime,
+		NextScheduledMaintenance: src.Status.NextScheduledMaintenance,
+		TerminationRequestedTime: src.Status.TerminationRequestedTime,
+	}
+	return nil
+}
+
+// ConvertFrom converts the GameServer from the v1alpha1 version
+func (dst *GameServer) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.GameServer)
+	dst.ObjectMeta = src.ObjectMeta
+
+	ports, err := parsePorts(src.Status.Ports)
+	if err != nil {
+		return fmt.Errorf("cannot convert the ports of GameServer %s/%s: %w", src.Namespace, src.Name, err)
+	}
+
+	dst.Spec = GameServerSpec{
+		PodSpec: src.Spec.PodSpec,
+		TitleID: src.Spec.TitleID,
+		BuildID: src.Spec.BuildID,
+	}
+	for _, p := range src.Spec.PortsToExpose {
+		dst.Spec.PortsToExpose = append(dst.Spec.PortsToExpose, PortToExpose{ContainerName: p.ContainerName, PortName: p.PortName})
+	}
+	for _, m := range src.Spec.BuildMetadata {
+		dst.Spec.BuildMetadata = append(dst.Spec.BuildMetadata, BuildMetadataItem{Key: m.Key, Value: m.Value})
+	}
+
+	dst.Status = GameServerStatus{
+		Health:                   GameServerHealth(src.Status.Health),
+		State:                    GameServerState(src.Status.State),
+		PublicIP:                 src.Status.PublicIP,
+		Ports:                    ports,
+		NodeName:                 src.Status.NodeName,
+		SessionID:                src.Status.SessionID,
+		SessionCookie:            src.Status.SessionCookie,
+		InitialPlayers:           src.Status.InitialPlayers,
+		ConnectedPlayers:         src.Status.ConnectedPlayers,
+		PlayerHistory:            src.Status.PlayerHistory,
+		StandingByTime:           src.Status.StandingByTime,
+		AllocatedTime:            src.Status.AllocatedTime,
+		CompletionTime:           src.Status.CompletionTime,
+		NextScheduledMaintenance: src.Status.NextScheduledMaintenance,
+		TerminationRequestedTime: src.Status.TerminationRequestedTime,
+	}
+	return nil
+}
+
+// formatPorts returns the v1alpha1 representation of the ports, e.g. "80:10000,443:10001"
+func formatPorts(ports []GameServerPort) string {
+	tuples := make([]string, 0, len(ports))
+	for _, p := range ports {
+		tuples = append(tuples, fmt.Sprintf("%d:%d", p.ContainerPort, p.HostPort))
+	}
+	return strings.Join(tuples, ",")
+}
+
+// parsePorts parses the v1alpha1 representation of the ports
+func parsePorts(s string) ([]GameServerPort, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ports []GameServerPort
+	for _, tuple := range strings.Split(s, ",") {
+		parts := strings.Split(tuple, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid port tuple %q", tuple)
+		}
+		containerPort, err := strconv.ParseInt(parts[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid container port in %q: %w", tuple, err)
+		}
+		hostPort, err := strconv.ParseInt(parts[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host port in %q: %w", tuple, err)
+		}
+		ports = append(ports, GameServerPort{ContainerPort: int32(containerPort), HostPort: int32(hostPort)})
+	}
+	return ports, nil
+}
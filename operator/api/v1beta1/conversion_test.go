@@ -0,0 +1,190 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/playfab/thundernetes/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
+)
+
+const testBuildID = "85ffe8da-c82f-4035-86c5-9d2b5f42d6f6"
+
+var _ = Describe("conversion tests", func() {
+	testObjectMeta := metav1.ObjectMeta{
+		Name:        "build1",
+		Namespace:   "default",
+		Labels:      map[string]string{"BuildID": testBuildID},
+		Annotations: map[string]string{v1alpha1.CordonedAnnotation: "true"},
+		Generation:  3,
+	}
+	testPodSpec := corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name:  "gameserver",
+			Image: "gameserver:0.1",
+			Ports: []corev1.ContainerPort{{Name: "gameport", ContainerPort: 80}},
+		}},
+	}
+	testConditions := []metav1.Condition{{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: 3,
+		LastTransitionTime: metav1.Unix(1628000000, 0),
+		Reason:             "StandingByReady",
+		Message:            "the requested StandingBy GameServers are ready",
+	}}
+
+	standingByTime := metav1.Unix(1628000000, 0)
+	allocatedTime := metav1.Unix(1628000060, 0)
+	completionTime := metav1.Unix(1628000600, 0)
+
+	It("should convert a v1alpha1 GameServerBuild to v1beta1 and back", func() {
+		hub := &v1alpha1.GameServerBuild{
+			ObjectMeta: testObjectMeta,
+			Spec: v1alpha1.GameServerBuildSpec{
+				StandingBy:             2,
+				Max:                    4,
+				PodSpec:                testPodSpec,
+				TitleID:                "1E03",
+				BuildID:                testBuildID,
+				PortsToExpose:          []v1alpha1.PortToExpose{{ContainerName: "gameserver", PortName: "gameport"}},
+				CrashesToMarkUnhealthy: 5,
+				BuildMetadata:          []v1alpha1.BuildMetadataItem{{Key: "key1", Value: "value1"}},
+				StandingByPrediction:   &v1alpha1.StandingByPrediction{MaxStandingBy: 4, Window: &metav1.Duration{Duration: 5 * time.Minute}},
+				ScaleDownPolicy:        v1alpha1.ScaleDownOldest,
+				AllocationStrategy:     v1alpha1.AllocationPacked,
+				DrainTimeout:           &metav1.Duration{Duration: 30 * time.Minute},
+				Paused:                 true,
+				StandingByTTL:          &metav1.Duration{Duration: 24 * time.Hour},
+				MaxActiveDuration:      &metav1.Duration{Duration: 2 * time.Hour},
+				RetainCrashed:          3,
+			},
+			Status: v1alpha1.GameServerBuildStatus{
+				CurrentInitializing:           1,
+				CurrentStandingBy:             1,
+				CurrentStandingByReadyDesired: "1/3",
+				CurrentActive:                 2,
+				CrashesCount:                  3,
+				Health:                        v1alpha1.BuildHealthy,
+				ObservedGeneration:            3,
+				Conditions:                    testConditions,
+				Selector:                      "BuildName=build1",
+				EffectiveStandingBy:           3,
+				AllocationHistory:             &v1alpha1.AllocationHistory{WindowStart: metav1.Unix(1628000000, 0), Allocations: []int{1, 0, 3}},
+			},
+		}
+		spoke := &GameServerBuild{}
+		Expect(spoke.ConvertFrom(hub)).To(Succeed())
+		Expect(spoke.Spec.PortsToExpose).To(Equal([]PortToExpose{{ContainerName: "gameserver", PortName: "gameport"}}))
+		Expect(spoke.Status.CurrentStandingBy).To(Equal(1))
+		Expect(spoke.Status.Conditions).To(Equal(testConditions))
+
+		converted := &v1alpha1.GameServerBuild{}
+		Expect(spoke.ConvertTo(converted)).To(Succeed())
+		Expect(converted).To(Equal(hub))
+	})
+	It("should convert a v1beta1 GameServerBuild to v1alpha1 and back", func() {
+		spoke := &GameServerBuild{
+			ObjectMeta: testObjectMeta,
+			Spec: GameServerBuildSpec{
+				StandingBy:    2,
+				Max:           4,
+				PodSpec:       testPodSpec,
+				TitleID:       "1E03",
+				BuildID:       testBuildID,
+				PortsToExpose: []PortToExpose{{ContainerName: "gameserver", PortName: "gameport"}},
+				BuildMetadata: []BuildMetadataItem{{Key: "key1", Value: "value1"}},
+			},
+			Status: GameServerBuildStatus{
+				CurrentStandingBy:  2,
+				Health:             BuildUnhealthy,
+				ObservedGeneration: 2,
+				Conditions:         testConditions,
+			},
+		}
+		hub := &v1alpha1.GameServerBuild{}
+		Expect(spoke.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Status.CurrentStandingByReadyDesired).To(Equal("2/2"))
+		Expect(hub.Status.Health).To(Equal(v1alpha1.BuildUnhealthy))
+
+		converted := &GameServerBuild{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted).To(Equal(spoke))
+	})
+	It("should convert a v1alpha1 GameServer to v1beta1 and back", func() {
+		hub := &v1alpha1.GameServer{
+			ObjectMeta: testObjectMeta,
+			Spec: v1alpha1.GameServerSpec{
+				PodSpec:       testPodSpec,
+				TitleID:       "1E03",
+				BuildID:       testBuildID,
+				PortsToExpose: []v1alpha1.PortToExpose{{ContainerName: "gameserver", PortName: "gameport"}},
+				BuildMetadata: []v1alpha1.BuildMetadataItem{{Key: "key1", Value: "value1"}},
+			},
+			Status: v1alpha1.GameServerStatus{
+				Health:           v1alpha1.Healthy,
+				State:            v1alpha1.GameServerStateActive,
+				PublicIP:         "20.0.0.1",
+				Ports:            "80:10000,443:10001",
+				NodeName:         "node1",
+				SessionID:        "d5f075a4-517b-4bf4-8123-dfa0021aa169",
+				SessionCookie:    "cookie1",
+				InitialPlayers:   []string{"player1", "player2"},
+				ConnectedPlayers: []string{"player1"},
+				PlayerHistory:    []string{"player0", "player1", "player2"},
+				StandingByTime:   &standingByTime,
+				AllocatedTime:    &allocatedTime,
+			},
+		}
+		spoke := &GameServer{}
+		Expect(spoke.ConvertFrom(hub)).To(Succeed())
+		Expect(spoke.Status.Ports).To(Equal([]GameServerPort{{ContainerPort: 80, HostPort: 10000}, {ContainerPort: 443, HostPort: 10001}}))
+		Expect(spoke.Status.State).To(Equal(GameServerStateActive))
+
+		converted := &v1alpha1.GameServer{}
+		Expect(spoke.ConvertTo(converted)).To(Succeed())
+		Expect(converted).To(Equal(hub))
+	})
+	It("should convert a v1beta1 GameServer to v1alpha1 and back", func() {
+		spoke := &GameServer{
+			ObjectMeta: testObjectMeta,
+			Spec: GameServerSpec{
+				PodSpec: testPodSpec,
+				TitleID: "1E03",
+				BuildID: testBuildID,
+			},
+			Status: GameServerStatus{
+				State:                    GameServerStateCrashed,
+				Ports:                    []GameServerPort{{ContainerPort: 80, HostPort: 10000}},
+				StandingByTime:           &standingByTime,
+				CompletionTime:           &completionTime,
+				NextScheduledMaintenance: &completionTime,
+			},
+		}
+		hub := &v1alpha1.GameServer{}
+		Expect(spoke.ConvertTo(hub)).To(Succeed())
+		Expect(hub.Status.Ports).To(Equal("80:10000"))
+
+		converted := &GameServer{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted).To(Equal(spoke))
+	})
+	It("should return an error for invalid v1alpha1 ports", func() {
+		for _, ports := range []string{"80", "80:port", "port:10000", "80:10000,"} {
+			hub := &v1alpha1.GameServer{Status: v1alpha1.GameServerStatus{Ports: ports}}
+			Expect((&GameServer{}).ConvertFrom(hub)).ToNot(Succeed(), ports)
+		}
+	})
+})
+
+func TestConversion(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t,
+		"Conversion Suite",
+		[]Reporter{printer.NewlineReporter{}})
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:validation:Enum=Healthy;Unhealthy
+// GameServerHealth describes the health of the game server
+type GameServerHealth string
+
+const (
+	Healthy   GameServerHealth = "Healthy"
+	Unhealthy GameServerHealth = "Unhealthy"
+)
+
+//+kubebuilder:validation:Enum=Active;StandingBy;Crashed;GameCompleted
+// GameServerState describes the state of the game server
+type GameServerState string
+
+const (
+	GameServerStateStandingBy    GameServerState = "StandingBy"
+	GameServerStateActive        GameServerState = "Active"
+	GameServerStateCrashed       GameServerState = "Crashed"
+	GameServerStateGameCompleted GameServerState = "GameCompleted"
+)
+
+// GameServerSpec defines the desired state of GameServer
+type GameServerSpec struct {
+	// PodSpec describes the pod specification of the game server
+	PodSpec corev1.PodSpec `json:"podSpec,omitempty"`
+	//+kubebuilder:validation:Required
+	// TitleID is the TitleID this GameServer belongs to
+	TitleID string `json:"titleID,omitempty"`
+	//+kubebuilder:validation:Required
+	// BuildID is the BuildID for this GameServer
+	BuildID string `json:"buildID,omitempty"`
+	//+kubebuilder:validation:Required
+	// PortsToExpose is an array of tuples of container/port names that correspond to the ports that will be exposed on the VM
+	PortsToExpose []PortToExpose `json:"portsToExpose,omitempty"`
+	// BuildMetadata is the metadata for the GameServerBuild this GameServer belongs to
+	BuildMetadata []BuildMetadataItem `json:"buildMetadata,omitempty"`
+}
+
+// GameServerPort is a container port of the GameServer and the port of the VM that it's exposed on
+type GameServerPort struct {
+	// ContainerPort is the port of the game server container
+	ContainerPort int32 `json:"containerPort"`
+	// HostPort is the port of the VM that the container port is exposed on
+	HostPort int32 `json:"hostPort"`
+}
+
+// GameServerStatus defines the observed state of GameServer
+type GameServerStatus struct {
+	// Health is the health of the game server, as reported by the sidecar
+	Health GameServerHealth `json:"health,omitempty"`
+	// State is the state of the game server, as reported by the sidecar
+	State GameServerState `json:"state,omitempty"`
+	// PublicIP is the public IP of the node of the game server
+	PublicIP string `json:"publicIP,omitempty"`
+	// Ports are the container ports of the game server and the ports of the VM that they are exposed on
+	Ports []GameServerPort `json:"ports,omitempty"`
+	// NodeName is the name of the node of the game server
+	NodeName string `json:"nodeName,omitempty"`
+	// SessionID is the ID of the session that the game server is allocated for
+	SessionID string `json:"sessionID,omitempty"`
+	// SessionCookie is the cookie of the session that the game server is allocated for
+	SessionCookie string `json:"sessionCookie,omitempty"`
+	// InitialPlayers are the IDs of the players that the session was allocated with
+	InitialPlayers []string `json:"initialPlayers,omitempty"`
+	// ConnectedPlayers are the IDs of the players that are connected to the game server, as reported by the sidecar
+	ConnectedPlayers []string `json:"connectedPlayers,omitempty"`
+	// PlayerHistory are the IDs of all the players that were allowed in the session, including the ones that were removed afterwards
+	PlayerHistory []string `json:"playerHistory,omitempty"`
+	// StandingByTime is when the game server reached the StandingBy state, the creation time is metadata.creationTimestamp
+	StandingByTime *metav1.Time `json:"standingByTime,omitempty"`
+	// AllocatedTime is when the game server was allocated for a session and became Active
+	AllocatedTime *metav1.Time `json:"allocatedTime,omitempty"`
+	// CompletionTime is when the game server process exited, i.e. when the game server reached the GameCompleted or the Crashed state
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// NextScheduledMaintenance is the next scheduled maintenance of the Node of the game server, it's passed to the game server in the heartbeat responses
+	NextScheduledMaintenance *metav1.Time `json:"nextScheduledMaintenance,omitempty"`
+	// TerminationRequestedTime is when the controller asked the game server to terminate its session, since it exceeded the MaxActiveDuration of its build
+	// the sidecar passes the Terminate operation to the game server in the heartbeat responses
+	TerminationRequestedTime *metav1.Time `json:"terminationRequestedTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:singular=gameserver,path=gameservers,scope=Namespaced,shortName=gs
+//+kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.health`
+//+kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+//+kubebuilder:printcolumn:name="PublicIP",type=string,JSONPath=`.status.publicIP`
+//+kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.status.nodeName`
+//+kubebuilder:printcolumn:name="SessionID",type=string,JSONPath=`.status.sessionID`
+
+// GameServer is the Schema for the gameservers API
+type GameServer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GameServerSpec   `json:"spec,omitempty"`
+	Status GameServerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GameServerList contains a list of GameServer
+type GameServerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GameServer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GameServer{}, &GameServerList{})
+}
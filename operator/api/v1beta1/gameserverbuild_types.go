@@ -0,0 +1,233 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:validation:Enum=Healthy;Unhealthy
+// GameServerBuildHealth describes the health of the game server build
+type GameServerBuildHealth string
+
+const (
+	BuildHealthy   GameServerBuildHealth = "Healthy"
+	BuildUnhealthy GameServerBuildHealth = "Unhealthy"
+)
+
+// The condition types of a GameServerBuild
+const (
+	// BuildConditionReady is True when the requested StandingBy GameServers, or as many as Max allows, are ready
+	BuildConditionReady = "Ready"
+	// BuildConditionScalingLimited is True when the requested StandingBy GameServers cannot be created, since Max is reached or the build is cordoned or paused
+	BuildConditionScalingLimited = "ScalingLimited"
+	// BuildConditionUnhealthy is True when CrashesToMarkUnhealthy GameServers crashed
+	BuildConditionUnhealthy = "Unhealthy"
+	// BuildConditionProgressing is True while GameServers are being initialized, created or deleted to match the spec
+	BuildConditionProgressing = "Progressing"
+	// BuildConditionDraining is True while the deleted GameServerBuild waits for its Active GameServers to finish their game sessions
+	BuildConditionDraining = "Draining"
+)
+
+// GameServerBuildSpec defines the desired state of GameServerBuild
+type GameServerBuildSpec struct {
+	//+kubebuilder:validation:Minimum=0
+	// StandingBy is the requested number of standingBy servers
+	StandingBy int `json:"standingBy,omitempty"`
+	//+kubebuilder:validation:Minimum=0
+	// Max is the maximum number of servers in any state
+	Max int `json:"max,omitempty"`
+
+	// PodSpec describes the pod specification of the game server
+	PodSpec corev1.PodSpec `json:"podSpec,omitempty"`
+
+	//+kubebuilder:validation:Required
+	// TitleID is the TitleID this Build belongs to
+	TitleID string `json:"titleID,omitempty"`
+
+	//+kubebuilder:validation:Required
+	// BuildID is the BuildID for this Build, it must be a UUID
+	BuildID string `json:"buildID,omitempty"`
+
+	//+kubebuilder:validation:Required
+	// PortsToExpose is an array of tuples of container/port names that correspond to the ports that will be exposed on the VM
+	PortsToExpose []PortToExpose `json:"portsToExpose,omitempty"`
+
+	//+kubebuilder:default=5
+	//+kubebuilder:validation:Minimum=0
+	// CrashesToMarkUnhealthy is the number of crashes needed to mark the build unhealthy
+	CrashesToMarkUnhealthy int `json:"crashesToMarkUnhealthy,omitempty"`
+
+	// BuildMetadata is the metadata for this GameServerBuild
+	BuildMetadata []BuildMetadataItem `json:"buildMetadata,omitempty"`
+
+	// StandingByPrediction enables the prediction of the StandingBy GameServers from the allocation history of the GameServerBuild
+	// the controller raises the effective StandingBy ahead of the predicted allocations, never below StandingBy
+	StandingByPrediction *StandingByPrediction `json:"standingByPrediction,omitempty"`
+
+	//+kubebuilder:default=LeastUtilizedNode
+	// ScaleDownPolicy decides which StandingBy GameServers are deleted first when StandingBy or Max is decreased
+	ScaleDownPolicy ScaleDownPolicy `json:"scaleDownPolicy,omitempty"`
+
+	//+kubebuilder:default=Random
+	// AllocationStrategy decides which StandingBy GameServer is allocated for a new game session
+	AllocationStrategy AllocationStrategy `json:"allocationStrategy,omitempty"`
+
+	// DrainTimeout is how long a deleted GameServerBuild waits for its Active GameServers to finish their game sessions, it defaults to 1h
+	// the remaining Active GameServers are deleted once it expires
+	DrainTimeout *metav1.Duration `json:"drainTimeout,omitempty"`
+
+	// Paused freezes the GameServerBuild, the controller does not create or delete its GameServers and no new game sessions are allocated
+	// the Active game sessions continue and the status is still updated
+	Paused bool `json:"paused,omitempty"`
+
+	// StandingByTTL is the maximum time a GameServer stays StandingBy, older StandingBy GameServers are deleted and replaced
+	StandingByTTL *metav1.Duration `json:"standingByTTL,omitempty"`
+
+	// MaxActiveDuration is the maximum duration of a game session, the GameServers that exceed it are asked to terminate through the GSDK
+	// and are deleted if they are still Active after a grace period
+	MaxActiveDuration *metav1.Duration `json:"maxActiveDuration,omitempty"`
+
+	//+kubebuilder:validation:Minimum=0
+	// RetainCrashed is the number of the most recent Crashed GameServers, and their pods, that are kept for debugging instead of being deleted
+	// the retained GameServers are not counted as StandingBy or Active, they keep their host ports reserved until they are deleted
+	RetainCrashed int `json:"retainCrashed,omitempty"`
+}
+
+//+kubebuilder:validation:Enum=Random;Packed;Distributed
+// AllocationStrategy decides which StandingBy GameServer is allocated for a new game session
+type AllocationStrategy string
+
+const (
+	// AllocationRandom allocates a random StandingBy GameServer
+	AllocationRandom AllocationStrategy = "Random"
+	// AllocationPacked allocates a StandingBy GameServer on the Node with the most Active GameServers,
+	// so that the game sessions are consolidated and the other Nodes can be scaled down
+	AllocationPacked AllocationStrategy = "Packed"
+	// AllocationDistributed allocates a StandingBy GameServer on the Node with the fewest Active GameServers,
+	// so that the game sessions are spread across the Nodes
+	AllocationDistributed AllocationStrategy = "Distributed"
+)
+
+//+kubebuilder:validation:Enum=LeastUtilizedNode;Newest;Oldest
+// ScaleDownPolicy decides which StandingBy GameServers are deleted first on scale down
+// Unhealthy GameServers are always deleted first
+type ScaleDownPolicy string
+
+const (
+	// ScaleDownLeastUtilizedNode deletes the GameServers on the Nodes with the fewest GameServers first, so that the Nodes can be scaled down sooner
+	ScaleDownLeastUtilizedNode ScaleDownPolicy = "LeastUtilizedNode"
+	// ScaleDownNewest deletes the most recently created GameServers first
+	ScaleDownNewest ScaleDownPolicy = "Newest"
+	// ScaleDownOldest deletes the least recently created GameServers first
+	ScaleDownOldest ScaleDownPolicy = "Oldest"
+)
+
+// StandingByPrediction configures the prediction of the StandingBy GameServers
+// the allocations are counted in windows, the allocations of the next window are forecast from the moving average
+// of the last windows and from the window one Seasonality ago, e.g. the same minutes of the previous hour
+type StandingByPrediction struct {
+	//+kubebuilder:validation:Minimum=0
+	// MaxStandingBy is the maximum effective StandingBy, it must not be lower than StandingBy
+	MaxStandingBy int `json:"maxStandingBy"`
+	// Window is the duration of the windows in which the allocations are counted and forecast, it defaults to 5m
+	// it should be about the time it takes for a new GameServer to become StandingBy
+	Window *metav1.Duration `json:"window,omitempty"`
+	// Seasonality is the period after which the allocations repeat, it defaults to 1h
+	// it must be a multiple of Window and at most 288 windows, e.g. 24h with 5m windows
+	Seasonality *metav1.Duration `json:"seasonality,omitempty"`
+}
+
+// AllocationHistory contains the number of allocations of a GameServerBuild in consecutive windows
+type AllocationHistory struct {
+	// WindowStart is the start of the last window, which is the current one
+	WindowStart metav1.Time `json:"windowStart"`
+	// Allocations contains the number of allocations in each window, the oldest first
+	Allocations []int `json:"allocations"`
+}
+
+// GameServerBuildStatus defines the observed state of GameServerBuild
+type GameServerBuildStatus struct {
+	// CurrentInitializing is the number of GameServers that are not StandingBy or Active yet
+	CurrentInitializing int `json:"currentInitializing,omitempty"`
+	// CurrentStandingBy is the number of StandingBy GameServers
+	CurrentStandingBy int `json:"currentStandingBy,omitempty"`
+	// CurrentActive is the number of Active GameServers
+	CurrentActive int `json:"currentActive,omitempty"`
+	// CrashesCount is the number of GameServers that crashed
+	CrashesCount int `json:"crashesCount,omitempty"`
+	// Health is the health of the GameServerBuild, it's Unhealthy after CrashesToMarkUnhealthy crashes
+	Health GameServerBuildHealth `json:"health,omitempty"`
+	// ObservedGeneration is the generation of the GameServerBuild spec that the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe the state of the GameServerBuild
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Selector is the label selector of the GameServers and the Pods of the GameServerBuild, it is used by the scale subresource
+	Selector string `json:"selector,omitempty"`
+	// EffectiveStandingBy is the StandingBy that the controller maintains when StandingByPrediction is set, it's the predicted StandingBy within its bounds
+	EffectiveStandingBy int `json:"effectiveStandingBy,omitempty"`
+	// AllocationHistory contains the allocations of the last Seasonality, it's recorded only when StandingByPrediction is set
+	AllocationHistory *AllocationHistory `json:"allocationHistory,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.standingBy,statuspath=.status.currentStandingBy,selectorpath=.status.selector
+//+kubebuilder:resource:singular=gameserverbuild,path=gameserverbuilds,scope=Namespaced,shortName=gsb
+//+kubebuilder:printcolumn:name="StandingBy",type=integer,JSONPath=`.status.currentStandingBy`
+//+kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.standingBy`
+//+kubebuilder:printcolumn:name="Active",type=integer,JSONPath=`.status.currentActive`
+//+kubebuilder:printcolumn:name="Crashes",type=integer,JSONPath=`.status.crashesCount`
+//+kubebuilder:printcolumn:name="Health",type=string,JSONPath=`.status.health`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+
+// GameServerBuild is the Schema for the gameserverbuilds API
+type GameServerBuild struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GameServerBuildSpec   `json:"spec,omitempty"`
+	Status GameServerBuildStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GameServerBuildList contains a list of GameServerBuild
+type GameServerBuildList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GameServerBuild `json:"items"`
+}
+
+// PortToExpose is a tuple of container/port names that correspond to the ports that will be exposed on the VM
+type PortToExpose struct {
+	// ContainerName is the name of the container, it can be omitted if the PodSpec has a single container
+	ContainerName string `json:"containerName,omitempty"`
+	// PortName is the name of the container port
+	PortName string `json:"portName"`
+}
+
+// BuildMetadataItem is a metadata item for a GameServerBuild
+type BuildMetadataItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GameServerBuild{}, &GameServerBuildList{})
+}
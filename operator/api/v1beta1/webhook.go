@@ -0,0 +1,21 @@
+package v1beta1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// SetupWebhookWithManager registers the conversion webhook of the GameServerBuild with the manager
+// the v1beta1 GameServerBuilds are validated and defaulted by the v1alpha1 webhooks, after they are converted
+func (gsb *GameServerBuild) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(gsb).
+		Complete()
+}
+
+// SetupWebhookWithManager registers the conversion webhook of the GameServer with the manager
+// the v1beta1 GameServers are validated by the v1alpha1 webhook, after they are converted
+func (gs *GameServer) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(gs).
+		Complete()
+}
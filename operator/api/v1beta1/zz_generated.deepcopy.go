@@ -0,0 +1,389 @@
+// +build !ignore_autogenerated
+
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationHistory) DeepCopyInto(out *AllocationHistory) {
+	*out = *in
+	in.WindowStart.DeepCopyInto(&out.WindowStart)
+	if in.Allocations != nil {
+		in, out := &in.Allocations, &out.Allocations
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationHistory.
+func (in *AllocationHistory) DeepCopy() *AllocationHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BuildMetadataItem) DeepCopyInto(out *BuildMetadataItem) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BuildMetadataItem.
+func (in *BuildMetadataItem) DeepCopy() *BuildMetadataItem {
+	if in == nil {
+		return nil
+	}
+	out := new(BuildMetadataItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServer) DeepCopyInto(out *GameServer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServer.
+func (in *GameServer) DeepCopy() *GameServer {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GameServer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerBuild) DeepCopyInto(out *GameServerBuild) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerBuild.
+func (in *GameServerBuild) DeepCopy() *GameServerBuild {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerBuild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GameServerBuild) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerBuildList) DeepCopyInto(out *GameServerBuildList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GameServerBuild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerBuildList.
+func (in *GameServerBuildList) DeepCopy() *GameServerBuildList {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerBuildList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GameServerBuildList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerBuildSpec) DeepCopyInto(out *GameServerBuildSpec) {
+	*out = *in
+	in.PodSpec.DeepCopyInto(&out.PodSpec)
+	if in.PortsToExpose != nil {
+		in, out := &in.PortsToExpose, &out.PortsToExpose
+		*out = make([]PortToExpose, len(*in))
+		copy(*out, *in)
+	}
+	if in.BuildMetadata != nil {
+		in, out := &in.BuildMetadata, &out.BuildMetadata
+		*out = make([]BuildMetadataItem, len(*in))
+		copy(*out, *in)
+	}
+	if in.StandingByPrediction != nil {
+		in, out := &in.StandingByPrediction, &out.StandingByPrediction
+		*out = new(StandingByPrediction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DrainTimeout != nil {
+		in, out := &in.DrainTimeout, &out.DrainTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.StandingByTTL != nil {
+		in, out := &in.StandingByTTL, &out.StandingByTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxActiveDuration != nil {
+		in, out := &in.MaxActiveDuration, &out.MaxActiveDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerBuildSpec.
+func (in *GameServerBuildSpec) DeepCopy() *GameServerBuildSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerBuildSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerBuildStatus) DeepCopyInto(out *GameServerBuildStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllocationHistory != nil {
+		in, out := &in.AllocationHistory, &out.AllocationHistory
+		*out = new(AllocationHistory)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerBuildStatus.
+func (in *GameServerBuildStatus) DeepCopy() *GameServerBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerList) DeepCopyInto(out *GameServerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GameServer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerList.
+func (in *GameServerList) DeepCopy() *GameServerList {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GameServerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerPort) DeepCopyInto(out *GameServerPort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerPort.
+func (in *GameServerPort) DeepCopy() *GameServerPort {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerSpec) DeepCopyInto(out *GameServerSpec) {
+	*out = *in
+	in.PodSpec.DeepCopyInto(&out.PodSpec)
+	if in.PortsToExpose != nil {
+		in, out := &in.PortsToExpose, &out.PortsToExpose
+		*out = make([]PortToExpose, len(*in))
+		copy(*out, *in)
+	}
+	if in.BuildMetadata != nil {
+		in, out := &in.BuildMetadata, &out.BuildMetadata
+		*out = make([]BuildMetadataItem, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerSpec.
+func (in *GameServerSpec) DeepCopy() *GameServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerStatus) DeepCopyInto(out *GameServerStatus) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]GameServerPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.InitialPlayers != nil {
+		in, out := &in.InitialPlayers, &out.InitialPlayers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConnectedPlayers != nil {
+		in, out := &in.ConnectedPlayers, &out.ConnectedPlayers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PlayerHistory != nil {
+		in, out := &in.PlayerHistory, &out.PlayerHistory
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StandingByTime != nil {
+		in, out := &in.StandingByTime, &out.StandingByTime
+		*out = (*in).DeepCopy()
+	}
+	if in.AllocatedTime != nil {
+		in, out := &in.AllocatedTime, &out.AllocatedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduledMaintenance != nil {
+		in, out := &in.NextScheduledMaintenance, &out.NextScheduledMaintenance
+		*out = (*in).DeepCopy()
+	}
+	if in.TerminationRequestedTime != nil {
+		in, out := &in.TerminationRequestedTime, &out.TerminationRequestedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerStatus.
+func (in *GameServerStatus) DeepCopy() *GameServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortToExpose) DeepCopyInto(out *PortToExpose) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortToExpose.
+func (in *PortToExpose) DeepCopy() *PortToExpose {
+	if in == nil {
+		return nil
+	}
+	out := new(PortToExpose)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StandingByPrediction) DeepCopyInto(out *StandingByPrediction) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Seasonality != nil {
+		in, out := &in.Seasonality, &out.Seasonality
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StandingByPrediction.
+func (in *StandingByPrediction) DeepCopy() *StandingByPrediction {
+	if in == nil {
+		return nil
+	}
+	out := new(StandingByPrediction)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+)
+
+// retainCrashedGameServer labels a newly Crashed GameServer as retained, so that it and its pod are kept for debugging
+// the label also marks its crash as counted, so that the next reconciles don't count it again
+func (r *GameServerBuildReconciler) retainCrashedGameServer(ctx context.Context, gs *mpsv1alpha1.GameServer) error {
+	labels := gs.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[mpsv1alpha1.CrashRetainedLabel] = "true"
+	gs.SetLabels(labels)
+	return r.Update(ctx, gs)
+}
+
+// deleteOldRetainedGameServers keeps the RetainCrashed most recently crashed of the retained GameServers and deletes the older ones
+func (r *GameServerBuildReconciler) deleteOldRetainedGameServers(ctx context.Context, gsb *mpsv1alpha1.GameServerBuild, retained []mpsv1alpha1.GameServer) error {
+	if len(retained) <= gsb.Spec.RetainCrashed {
+		return nil
+	}
+
+	sort.SliceStable(retained, func(i, j int) bool {
+		return getCrashTime(&retained[i]).After(getCrashTime(&retained[j]))
+	})
+
+	for i := gsb.Spec.RetainCrashed; i < len(retained); i++ {
+		gs := retained[i]
+		if !gs.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Delete(ctx, &gs); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		addGameServerToUnderDeletionMap(gsb.Name, gs.Name)
+		r.Recorder.Eventf(gsb, corev1.EventTypeNormal, "RetainedDeleted", "Crashed GameServer %s is deleted, only the %d most recent Crashed GameServers are retained", gs.Name, gsb.Spec.RetainCrashed)
+	}
+	return nil
+}
+
+// getCrashTime returns when the process of the Crashed GameServer exited, or its creation time if that's not set
+func getCrashTime(gs *mpsv1alpha1.GameServer) time.Time {
+	if gs.Status.CompletionTime != nil {
+		return gs.Status.CompletionTime.Time
+	}
+	return gs.CreationTimestamp.Time
+}
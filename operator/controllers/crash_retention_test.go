@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Crashed GameServer retention tests", func() {
+	now := time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)
+	newBuild := func(retainCrashed int) *mpsv1alpha1.GameServerBuild {
+		return &mpsv1alpha1.GameServerBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "build1", Namespace: "default"},
+			Spec:       mpsv1alpha1.GameServerBuildSpec{RetainCrashed: retainCrashed},
+		}
+	}
+
+	It("should label a retained Crashed game server", func() {
+		gs := newTestGameServer("crashed", mpsv1alpha1.GameServerStateCrashed, now, time.Minute)
+		c := newTestFakeClient(&gs)
+		Expect(gs.IsCrashRetained()).To(BeFalse())
+
+		r := &GameServerBuildReconciler{Client: c, Recorder: record.NewFakeRecorder(10)}
+		Expect(r.retainCrashedGameServer(context.Background(), &gs)).To(Succeed())
+		var updated mpsv1alpha1.GameServer
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "crashed", Namespace: "default"}, &updated)).To(Succeed())
+		Expect(updated.IsCrashRetained()).To(BeTrue())
+	})
+	It("should delete the retained game servers that crashed before the most recent ones", func() {
+		retained := []mpsv1alpha1.GameServer{
+			newTestGameServer("crashed-3h", mpsv1alpha1.GameServerStateCrashed, now, 3*time.Hour),
+			newTestGameServer("crashed-1h", mpsv1alpha1.GameServerStateCrashed, now, time.Hour),
+			newTestGameServer("crashed-2h", mpsv1alpha1.GameServerStateCrashed, now, 2*time.Hour),
+		}
+		c := newTestFakeClient(gameServersAsObjects(retained)...)
+
+		recorder := record.NewFakeRecorder(10)
+		r := &GameServerBuildReconciler{Client: c, Recorder: recorder}
+		Expect(r.deleteOldRetainedGameServers(context.Background(), newBuild(2), retained)).To(Succeed())
+		Expect(<-recorder.Events).To(ContainSubstring("crashed-3h"))
+
+		var gs mpsv1alpha1.GameServer
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "crashed-1h", Namespace: "default"}, &gs)).To(Succeed())
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "crashed-2h", Namespace: "default"}, &gs)).To(Succeed())
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "crashed-3h", Namespace: "default"}, &gs)).ToNot(Succeed())
+
+		// lowering RetainCrashed to zero deletes all of them
+		var list mpsv1alpha1.GameServerList
+		Expect(c.List(context.Background(), &list)).To(Succeed())
+		Expect(r.deleteOldRetainedGameServers(context.Background(), newBuild(0), list.Items)).To(Succeed())
+		Expect(c.List(context.Background(), &list)).To(Succeed())
+		Expect(list.Items).To(BeEmpty())
+		gameServersUnderDeletion.Del("build1")
+	})
+	It("should use the creation time of a crashed game server without a completion time", func() {
+		gs := newTestGameServer("crashed", mpsv1alpha1.GameServerStateCrashed, now, time.Hour)
+		Expect(getCrashTime(&gs)).To(Equal(now.Add(-time.Hour)))
+		gs.Status.CompletionTime = nil
+		Expect(getCrashTime(&gs)).To(Equal(now.Add(-48 * time.Hour)))
+	})
+})
@@ -18,18 +18,26 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
 
@@ -46,6 +54,9 @@ var (
 const safeToEvictPodAttribute string = "cluster-autoscaler.kubernetes.io/safe-to-evict"
 const finalizerName string = "gameservers.mps.playfab.com/finalizer"
 
+// NodeNameIndexField indexes the GameServers by the Node they run on
+const NodeNameIndexField string = "status.nodeName"
+
 // GameServerReconciler reconciles a GameServer object
 type GameServerReconciler struct {
 	client.Client
@@ -56,6 +67,7 @@ type GameServerReconciler struct {
 }
 
 // we request secret RBAC access here so they can be potentially used by the API service (for GameServer allocations)
+// access is limited to the tls-secret Secret in the controller namespace, a separate CA Secret needs to be added to the resourceNames
 
 //+kubebuilder:rbac:groups=mps.playfab.com,resources=gameservers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=mps.playfab.com,resources=gameservers/status,verbs=get;update;patch
@@ -63,7 +75,7 @@ type GameServerReconciler struct {
 //+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",namespace=system,resources=secrets,resourceNames=tls-secret,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -112,6 +124,12 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 	// ----------------------- finalizer logic end ----------------------- //
 
+	// the GameServers whose process exited are deleted by the GameServerBuild controller, or retained for debugging
+	// their pods are kept as they are, and are not recreated if they are missing
+	if gs.Status.State == mpsv1alpha1.GameServerStateCrashed || gs.Status.State == mpsv1alpha1.GameServerStateGameCompleted {
+		return ctrl.Result{}, r.reconcileExitedGameServer(ctx, &gs)
+	}
+
 	// get the pod that is owned by this GameServer
 	var pod corev1.Pod
 	podFoundInCache := true
@@ -157,6 +175,8 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			} else {
 				gs.Status.State = mpsv1alpha1.GameServerStateCrashed
 			}
+			finishedAt := containerStatus.State.Terminated.FinishedAt
+			gs.Status.CompletionTime = &finishedAt
 			// updating GameServer with the new state
 			if err := r.Status().Update(ctx, &gs); err != nil {
 				return ctrl.Result{}, err
@@ -186,8 +206,8 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	pod.SetAnnotations(podAnnotations)
 	r.Update(ctx, &pod)
 
-	// if we don't have a Public IP set, we need to get and set it on the status
-	if gs.Status.PublicIP == "" {
+	// if we don't have a Public IP or Node name set, we need to get and set them on the status
+	if gs.Status.PublicIP == "" || gs.Status.NodeName == "" {
 		if pod.Spec.NodeName == "" {
 			// nodename is empty, maybe the Pod hasn't been scheduled yet?
 			return ctrl.Result{}, nil // will requeue when the Pod is scheduled
@@ -197,6 +217,7 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return ctrl.Result{}, err
 		}
 		gs.Status.PublicIP = publicIP
+		gs.Status.NodeName = pod.Spec.NodeName
 		gs.Status.Ports = getContainerHostPortTuples(&pod)
 		err = r.Status().Update(ctx, &gs)
 		if err != nil {
@@ -208,9 +229,96 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	return r.reconcileNode(ctx, &gs)
+}
+
+// reconcileExitedGameServer marks the pod of a GameServer whose process exited as safe to evict, since there are no players on it
+// the pod of a retained GameServer keeps running the sidecar, so its host ports stay reserved until the GameServer is deleted
+func (r *GameServerReconciler) reconcileExitedGameServer(ctx context.Context, gs *mpsv1alpha1.GameServer) error {
+	var pod corev1.Pod
+	if err := r.Get(ctx, types.NamespacedName{Namespace: gs.Namespace, Name: gs.Name}, &pod); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if pod.GetAnnotations()[safeToEvictPodAttribute] == "true" {
+		return nil
+	}
+	podAnnotations := pod.GetAnnotations()
+	if podAnnotations == nil {
+		podAnnotations = make(map[string]string)
+	}
+	podAnnotations[safeToEvictPodAttribute] = "true"
+	pod.SetAnnotations(podAnnotations)
+	return client.IgnoreNotFound(r.Update(ctx, &pod))
+}
+
+// reconcileNode handles the maintenance of the Node of the GameServer
+// a StandingBy GameServer on a drained Node is deleted, so that the GameServerBuild controller creates a new one on another Node
+// the NextScheduledMaintenance of the Node is set on the status, so that the sidecar can pass it to the game server
+func (r *GameServerReconciler) reconcileNode(ctx context.Context, gs *mpsv1alpha1.GameServer) (ctrl.Result, error) {
+	if gs.Status.NodeName == "" {
+		return ctrl.Result{}, nil
+	}
+	var node corev1.Node
+	if err := r.Get(ctx, client.ObjectKey{Name: gs.Status.NodeName}, &node); err != nil {
+		// if the Node was deleted, its Pods are deleted too
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if gs.Status.State == mpsv1alpha1.GameServerStateStandingBy && IsNodeDrained(&node, gs.Spec.PodSpec.Tolerations) {
+		// the precondition fails if the GameServer changed since it was read, e.g. if it was allocated in the meantime
+		// the change triggers a new reconcile, which deletes it if it's still StandingBy
+		if err := r.Delete(ctx, gs, client.Preconditions{ResourceVersion: &gs.ResourceVersion}); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{}, nil
+			}
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		r.Recorder.Eventf(gs, corev1.EventTypeNormal, "NodeDrained", "Deleted StandingBy GameServer %s, since Node %s is cordoned or tainted", gs.Name, node.Name)
+		return ctrl.Result{}, nil
+	}
+
+	nextScheduledMaintenance := getNextScheduledMaintenance(&node)
+	if !equality.Semantic.DeepEqual(gs.Status.NextScheduledMaintenance, nextScheduledMaintenance) {
+		gs.Status.NextScheduledMaintenance = nextScheduledMaintenance
+		if err := r.Status().Update(ctx, gs); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		if nextScheduledMaintenance != nil {
+			r.Recorder.Eventf(gs, corev1.EventTypeNormal, "NodeMaintenanceScheduled", "Node %s has a scheduled maintenance at %s", node.Name, nextScheduledMaintenance.Format(time.RFC3339))
+		}
+	}
 	return ctrl.Result{}, nil
 }
 
+// getGameServersOnNode returns a reconcile request for every GameServer on the Node
+func (r *GameServerReconciler) getGameServersOnNode(obj client.Object) []reconcile.Request {
+	var gameServers mpsv1alpha1.GameServerList
+	if err := r.List(context.Background(), &gameServers, client.MatchingFields{NodeNameIndexField: obj.GetName()}); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(gameServers.Items))
+	for _, gs := range gameServers.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: gs.Namespace, Name: gs.Name}})
+	}
+	return requests
+}
+
+// nodeMaintenanceChanged returns true if a Node was cordoned or uncordoned, its taints changed or its NextScheduledMaintenanceAnnotation changed
+// the other updates of the Nodes, e.g. of their status, don't affect the GameServers
+func nodeMaintenanceChanged(e event.UpdateEvent) bool {
+	oldNode, okOld := e.ObjectOld.(*corev1.Node)
+	newNode, okNew := e.ObjectNew.(*corev1.Node)
+	if !okOld || !okNew {
+		return false
+	}
+	return oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable ||
+		!equality.Semantic.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints) ||
+		oldNode.Annotations[mpsv1alpha1.NextScheduledMaintenanceAnnotation] != newNode.Annotations[mpsv1alpha1.NextScheduledMaintenanceAnnotation]
+}
+
 // unassignPorts will remove any ports that are used by this GameServer from the port registry
 func (r *GameServerReconciler) unassignPorts(gs *mpsv1alpha1.GameServer) {
 	hostPorts := make([]int32, 0)
@@ -245,9 +353,25 @@ func (r *GameServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	// used to find the GameServers on a Node when it's drained or its maintenance is scheduled, and by the allocation strategies
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &mpsv1alpha1.GameServer{}, NodeNameIndexField, func(rawObj client.Object) []string {
+		gs := rawObj.(*mpsv1alpha1.GameServer)
+		return []string{gs.Status.NodeName}
+	}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&mpsv1alpha1.GameServer{}).
 		Owns(&corev1.Pod{}).
+		Watches(&source.Kind{Type: &corev1.Node{}},
+			handler.EnqueueRequestsFromMapFunc(r.getGameServersOnNode),
+			builder.WithPredicates(predicate.Funcs{
+				CreateFunc:  func(event.CreateEvent) bool { return false },
+				DeleteFunc:  func(event.DeleteEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+				UpdateFunc:  nodeMaintenanceChanged,
+			})).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
 		Complete(r)
 }
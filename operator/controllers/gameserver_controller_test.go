@@ -2,13 +2,18 @@ package controllers
 
 import (
 	"context"
+	"time"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -52,4 +57,52 @@ var _ = Describe("GameServer controller tests", func() {
 
 		})
 	})
+	Context("testing a game server whose process exited", func() {
+		now := time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)
+		newExitedGameServer := func(name string, state mpsv1alpha1.GameServerState, hostPort int32) mpsv1alpha1.GameServer {
+			gs := newTestGameServer(name, state, now, time.Minute)
+			gs.Finalizers = []string{finalizerName}
+			gs.Spec.PortsToExpose = []mpsv1alpha1.PortToExpose{{ContainerName: "testcontainer", PortName: "gameport"}}
+			gs.Spec.PodSpec.Containers = []corev1.Container{{
+				Name:  "testcontainer",
+				Ports: []corev1.ContainerPort{{Name: "gameport", ContainerPort: 80, HostPort: hostPort}},
+			}}
+			return gs
+		}
+		newPod := func(name string) *corev1.Pod {
+			return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		}
+
+		It("should mark the pod as safe to evict and keep the ports of a retained game server until it's deleted", func() {
+			retained := newExitedGameServer("retained", mpsv1alpha1.GameServerStateCrashed, 20000)
+			retained.Labels = map[string]string{mpsv1alpha1.CrashRetainedLabel: "true"}
+			completed := newExitedGameServer("completed", mpsv1alpha1.GameServerStateGameCompleted, 20001)
+			c := newTestFakeClient(&retained, &completed, newPod("retained"), newPod("completed"))
+			portRegistry, err := NewPortRegistry(mpsv1alpha1.GameServerList{Items: []mpsv1alpha1.GameServer{retained, completed}}, 20000, 20010, logr.FromContext(context.Background()))
+			Expect(err).ToNot(HaveOccurred())
+			defer portRegistry.Stop()
+			r := &GameServerReconciler{Client: c, Recorder: record.NewFakeRecorder(10), PortRegistry: portRegistry}
+
+			for _, name := range []string{"retained", "completed"} {
+				_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}})
+				Expect(err).ToNot(HaveOccurred())
+				var pod corev1.Pod
+				Expect(c.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, &pod)).To(Succeed())
+				Expect(pod.Annotations[safeToEvictPodAttribute]).To(Equal("true"))
+			}
+
+			// the pod of the retained game server still holds its host ports, they are released once the game server is deleted
+			Expect(portRegistry.HostPorts[20000]).To(BeTrue())
+			Expect(portRegistry.HostPorts[20001]).To(BeTrue())
+			// the fake client does not support finalizers, so the deletion is simulated
+			var gs mpsv1alpha1.GameServer
+			Expect(c.Get(context.Background(), types.NamespacedName{Name: "retained", Namespace: "default"}, &gs)).To(Succeed())
+			deleted := metav1.NewTime(now)
+			gs.DeletionTimestamp = &deleted
+			Expect(c.Update(context.Background(), &gs)).To(Succeed())
+			_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "retained", Namespace: "default"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(portRegistry.HostPorts[20000]).To(BeFalse())
+		})
+	})
 })
@@ -19,17 +19,22 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
@@ -69,7 +74,7 @@ type GameServerBuildReconciler struct {
 //+kubebuilder:rbac:groups=mps.playfab.com,resources=gameserverbuilds/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=mps.playfab.com,resources=gameserverbuilds/finalizers,verbs=update
 //+kubebuilder:rbac:groups=mps.playfab.com,resources=gameservers,verbs=get;list;watch
-//+kubebuilder:rbac:groups=mps.playfab.com,resources=gameservers/status,verbs=get
+//+kubebuilder:rbac:groups=mps.playfab.com,resources=gameservers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -88,6 +93,21 @@ func (r *GameServerBuildReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		log.Error(err, "unable to fetch gameServerBuild")
 		return ctrl.Result{}, err
 	}
+	// the StandingBy prediction changes the status before updateStatus, which compares the new status with the fetched one
+	fetchedStatus := gsb.Status.DeepCopy()
+
+	// the finalizer keeps the deleted GameServerBuild until its Active GameServers are drained
+	// no GameServers are created for it anymore, even if it is unhealthy
+	if !gsb.DeletionTimestamp.IsZero() {
+		return r.drainGameServerBuild(ctx, &gsb)
+	}
+	if !containsString(gsb.GetFinalizers(), buildFinalizerName) {
+		controllerutil.AddFinalizer(&gsb, buildFinalizerName)
+		if err := r.Update(ctx, &gsb); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
 
 	// if GameServerBuild is unhealthy, do nothing more
 	if gsb.Status.Health == mpsv1alpha1.BuildUnhealthy {
@@ -119,8 +139,20 @@ func (r *GameServerBuildReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
+	// the GameServers that exceeded their lifetime are recycled, the deleted ones are not counted so that they are replaced
+	var expiryRequeueAfter time.Duration
+	if !gsb.Spec.Paused {
+		gameServers.Items, expiryRequeueAfter, err = r.recycleExpiredGameServers(ctx, &gsb, gameServers.Items, time.Now())
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// calculate counts by state so we can update .status accordingly
+	// the Crashed and GameCompleted GameServers of a paused GameServerBuild are kept, they are counted and deleted once it's resumed
+	// the retained Crashed GameServers are not counted, their crashes were counted when they were retained
 	var activeCount, standingByCount, crashesCount, initializingCount int
+	var retainedCrashed []mpsv1alpha1.GameServer
 	for i := 0; i < len(gameServers.Items); i++ {
 		gs := gameServers.Items[i]
 
@@ -130,7 +162,21 @@ func (r *GameServerBuildReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			standingByCount++
 		} else if gs.Status.State == mpsv1alpha1.GameServerStateActive {
 			activeCount++
-		} else if gs.Status.State == mpsv1alpha1.GameServerStateCrashed {
+		} else if gs.Status.State == mpsv1alpha1.GameServerStateCrashed && !gsb.Spec.Paused {
+			if gs.IsCrashRetained() {
+				retainedCrashed = append(retainedCrashed, gs)
+				continue
+			}
+			if gsb.Spec.RetainCrashed > 0 {
+				if err := r.retainCrashedGameServer(ctx, &gs); err != nil {
+					return ctrl.Result{}, err
+				}
+				crashesCount++
+				retainedCrashed = append(retainedCrashed, gs)
+				GameServersSessionEndedCounter.WithLabelValues(gsb.Name).Inc()
+				r.Recorder.Eventf(&gsb, corev1.EventTypeNormal, "Crashed", "GameServer %s crashed, it's retained for debugging", gs.Name)
+				continue
+			}
 			crashesCount++
 			if err := r.Delete(ctx, &gs); err != nil {
 				return ctrl.Result{}, err
@@ -138,7 +184,7 @@ func (r *GameServerBuildReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			GameServersSessionEndedCounter.WithLabelValues(gsb.Name).Inc()
 			addGameServerToUnderDeletionMap(gsb.Name, gs.Name)
 			r.Recorder.Eventf(&gsb, corev1.EventTypeNormal, "Crashed", "GameServer %s crashed", gs.Name)
-		} else if gs.Status.State == mpsv1alpha1.GameServerStateGameCompleted {
+		} else if gs.Status.State == mpsv1alpha1.GameServerStateGameCompleted && !gsb.Spec.Paused {
 			if err := r.Delete(ctx, &gs); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -148,61 +194,60 @@ func (r *GameServerBuildReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		}
 	}
 
+	// only the RetainCrashed most recent Crashed GameServers are kept, this also removes them once RetainCrashed is lowered
+	if err := r.deleteOldRetainedGameServers(ctx, &gsb, retainedCrashed); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the StandingBy GameServers to maintain, it's raised ahead of the forecast allocations when the StandingByPrediction is set
+	standingBy := r.predictStandingBy(&gsb, gameServers.Items, time.Now())
+
+	// a paused GameServerBuild keeps its GameServers as they are, only its status is updated
+	if gsb.Spec.Paused {
+		return r.updateStatus(ctx, &gsb, fetchedStatus, initializingCount, standingByCount, activeCount, crashesCount, expiryRequeueAfter)
+	}
+
 	// if at least one gameServer doesn't have a State, this means that it's initializing
 	// update the gameServerBuild status and exit the reconcile loop
 	// once this gameServer gets a State, the reconcile loop will be re-triggered again
 	if initializingCount > 0 {
-		return r.updateStatus(ctx, &gsb, initializingCount, standingByCount, activeCount, crashesCount)
+		return r.updateStatus(ctx, &gsb, fetchedStatus, initializingCount, standingByCount, activeCount, crashesCount, expiryRequeueAfter)
 	}
 
-	// user has decreased standingBy numbers
-	if standingByCount > gsb.Spec.StandingBy {
-		deletedCount := 0
-		for i := 0; i < standingByCount-gsb.Spec.StandingBy; i++ {
-			gs := gameServers.Items[i]
-			// we're deleting only standingBy servers
-			if gs.Status.State == "" || gs.Status.State == mpsv1alpha1.GameServerStateStandingBy {
-				if err := r.Delete(ctx, &gs); err != nil {
-					return ctrl.Result{}, err
-				}
-				GameServersDeletedCounter.WithLabelValues(gsb.Name).Inc()
-				addGameServerToUnderDeletionMap(gsb.Name, gs.Name)
-				deletedCount++
-				r.Recorder.Eventf(&gsb, corev1.EventTypeNormal, "GameServer deleted", "GameServer %s deleted", gs.Name)
-			}
-		}
-		if deletedCount != standingByCount-gsb.Spec.StandingBy {
-			log.Info("User modified .Spec.StandingBy - No standingBy servers left to delete")
-			r.Recorder.Eventf(&gsb, corev1.EventTypeNormal, "User modified .Spec.StandingBy - No standingBy servers left to delete", "Tried to delete %d GameServers but deleted only %d", standingByCount-gsb.Spec.StandingBy, deletedCount)
-		}
+	// the user has decreased spec.StandingBy, e.g. with kubectl scale or a HorizontalPodAutoscaler, or has decreased spec.Max
+	// both are handled in a single pass with the up-to-date counts, so that frequent scale changes don't delete more GameServers than needed
+	toDeleteCount := standingByCount - standingBy
+	if overMaxCount := standingByCount + activeCount - gsb.Spec.Max; overMaxCount > toDeleteCount {
+		toDeleteCount = overMaxCount
 	}
-
-	// we need to check if we are above the max
-	// this will happen if the user modifies the spec.Max during the GameServerBuild's lifetime
-	if standingByCount+activeCount > gsb.Spec.Max {
-		// we have more servers than we should
-		deletedCount := 0
-		for i := 0; i <= standingByCount+activeCount-gsb.Spec.Max; i++ {
-			gs := gameServers.Items[i]
-			// we're deleting only standingBy or initializing servers
-			if gs.Status.State == "" || gs.Status.State == mpsv1alpha1.GameServerStateStandingBy {
-				if err := r.Delete(ctx, &gs); err != nil {
-					return ctrl.Result{}, err
-				}
-				GameServersDeletedCounter.WithLabelValues(gsb.Name).Inc()
-				addGameServerToUnderDeletionMap(gsb.Name, gs.Name)
-				deletedCount++
-			}
+	if toDeleteCount > 0 {
+		deletedCount, err := r.deleteStandingByGameServers(ctx, &gsb, gameServers.Items, toDeleteCount)
+		if err != nil {
+			return ctrl.Result{}, err
 		}
-		if deletedCount != standingByCount+activeCount-gsb.Spec.Max {
+		standingByCount -= deletedCount
+		// the Active GameServers are not deleted, so we are still above Max until their game sessions end
+		if deletedCount != toDeleteCount {
 			log.Info("User modified .Spec.Max - No standingBy servers left to delete")
-			r.Recorder.Eventf(&gsb, corev1.EventTypeNormal, "User modified .Spec.Max - No standingBy servers left to delete. Will requeue", "Tried to delete %d GameServers but deleted only %d", standingByCount+activeCount-gsb.Spec.Max, deletedCount)
+			r.Recorder.Eventf(&gsb, corev1.EventTypeNormal, "User modified .Spec.Max - No standingBy servers left to delete. Will requeue", "Tried to delete %d GameServers but deleted only %d", toDeleteCount, deletedCount)
+			result, err := r.updateStatus(ctx, &gsb, fetchedStatus, initializingCount, standingByCount, activeCount, crashesCount, expiryRequeueAfter)
+			if err != nil || result.Requeue {
+				return result, err
+			}
 			return ctrl.Result{RequeueAfter: time.Duration(5) * time.Second}, nil
 		}
 	}
 
+	// a cordoned GameServerBuild does not get new GameServers
+	if gsb.IsCordoned() {
+		if standingByCount < standingBy {
+			log.Info("GameServerBuild is cordoned, not creating GameServers")
+		}
+		return r.updateStatus(ctx, &gsb, fetchedStatus, initializingCount, standingByCount, activeCount, crashesCount, expiryRequeueAfter)
+	}
+
 	// we are in need of standingBy servers, so we're creating them here
-	for i := 0; i < gsb.Spec.StandingBy-standingByCount && i+standingByCount+activeCount < gsb.Spec.Max; i++ {
+	for i := 0; i < standingBy-standingByCount && i+standingByCount+activeCount < gsb.Spec.Max; i++ {
 		newgs, err := NewGameServerForGameServerBuild(&gsb, r.PortRegistry)
 		if err != nil {
 			return ctrl.Result{}, err
@@ -216,31 +261,33 @@ func (r *GameServerBuildReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		r.Recorder.Eventf(&gsb, corev1.EventTypeNormal, "Creating", "Creating GameServer %s", newgs.Name)
 	}
 
-	return r.updateStatus(ctx, &gsb, initializingCount, standingByCount, activeCount, crashesCount)
+	return r.updateStatus(ctx, &gsb, fetchedStatus, initializingCount, standingByCount, activeCount, crashesCount, expiryRequeueAfter)
 }
 
-func (r *GameServerBuildReconciler) updateStatus(ctx context.Context, gsb *mpsv1alpha1.GameServerBuild, initializingCount, standingByCount, activeCount, crashesCount int) (ctrl.Result, error) {
-	// update GameServerBuild status only if one of the fields has changed
-	if gsb.Status.CurrentInitializing != initializingCount ||
-		gsb.Status.CurrentActive != activeCount ||
-		gsb.Status.CurrentStandingBy != standingByCount ||
-		crashesCount > 0 {
-
-		gsb.Status.CurrentInitializing = initializingCount
-		gsb.Status.CurrentActive = activeCount
-		gsb.Status.CurrentStandingBy = standingByCount
-		gsb.Status.CrashesCount = gsb.Status.CrashesCount + crashesCount
-		gsb.Status.CurrentStandingByReadyDesired = fmt.Sprintf("%d/%d", standingByCount, gsb.Spec.StandingBy)
-
-		var health mpsv1alpha1.GameServerBuildHealth
-		if gsb.Status.CrashesCount >= gsb.Spec.CrashesToMarkUnhealthy {
-			health = mpsv1alpha1.BuildUnhealthy
-		} else {
-			health = mpsv1alpha1.BuildHealthy
-		}
+// updateStatus updates the counts and the conditions of the GameServerBuild status, if it's different from the oldStatus that was fetched
+// the reconcile is requeued after requeueAfter, if it's not zero, or at the start of the next StandingByPrediction window if that's sooner
+func (r *GameServerBuildReconciler) updateStatus(ctx context.Context, gsb *mpsv1alpha1.GameServerBuild, oldStatus *mpsv1alpha1.GameServerBuildStatus, initializingCount, standingByCount, activeCount, crashesCount int, requeueAfter time.Duration) (ctrl.Result, error) {
+	gsb.Status.CurrentInitializing = initializingCount
+	gsb.Status.CurrentActive = activeCount
+	gsb.Status.CurrentStandingBy = standingByCount
+	gsb.Status.CrashesCount = gsb.Status.CrashesCount + crashesCount
+	gsb.Status.CurrentStandingByReadyDesired = fmt.Sprintf("%d/%d", standingByCount, getDesiredStandingBy(gsb))
+
+	var health mpsv1alpha1.GameServerBuildHealth
+	if gsb.Status.CrashesCount >= gsb.Spec.CrashesToMarkUnhealthy {
+		health = mpsv1alpha1.BuildUnhealthy
+	} else {
+		health = mpsv1alpha1.BuildHealthy
+	}
 
-		gsb.Status.Health = health
+	gsb.Status.Health = health
+	gsb.Status.ObservedGeneration = gsb.Generation
+	gsb.Status.Selector = labels.SelectorFromSet(labels.Set{LabelBuildName: gsb.Name}).String()
+	setGameServerBuildConditions(gsb)
 
+	// update GameServerBuild status only if one of the fields has changed
+	// the LastTransitionTime of a condition changes only when its status changes, so an unchanged status is not updated
+	if !equality.Semantic.DeepEqual(oldStatus, &gsb.Status) {
 		if err := r.Status().Update(ctx, gsb); err != nil {
 			if apierrors.IsConflict(err) {
 				return ctrl.Result{Requeue: true}, nil
@@ -254,7 +301,170 @@ func (r *GameServerBuildReconciler) updateStatus(ctx context.Context, gsb *mpsv1
 	StandingByGameServersGauge.WithLabelValues(gsb.Name).Set(float64(standingByCount))
 	ActiveGameServersGauge.WithLabelValues(gsb.Name).Set(float64(activeCount))
 
-	return ctrl.Result{}, nil
+	// the allocations are recorded and forecast in every window, even if no GameServers change
+	if gsb.Spec.StandingByPrediction != nil {
+		requeueAfter = minRequeueAfter(requeueAfter, getNextPredictionWindow(gsb.Spec.StandingByPrediction, time.Now()))
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// setGameServerBuildConditions sets the conditions of the GameServerBuild from its spec and the current counts of its status
+// the StandingBy target is the desired StandingBy, limited by the GameServers that can still be created without exceeding spec.Max
+func setGameServerBuildConditions(gsb *mpsv1alpha1.GameServerBuild) {
+	status := &gsb.Status
+	standingBy := getDesiredStandingBy(gsb)
+	target := gsb.Spec.Max - status.CurrentActive
+	if target < 0 {
+		target = 0
+	}
+	if target > standingBy {
+		target = standingBy
+	}
+	unhealthy := status.Health == mpsv1alpha1.BuildUnhealthy
+	cordoned := gsb.IsCordoned()
+	paused := gsb.Spec.Paused
+
+	unhealthyCondition := metav1.Condition{
+		Type:               mpsv1alpha1.BuildConditionUnhealthy,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: gsb.Generation,
+		Reason:             "Healthy",
+		Message:            fmt.Sprintf("%d of %d GameServers crashed", status.CrashesCount, gsb.Spec.CrashesToMarkUnhealthy),
+	}
+	if unhealthy {
+		unhealthyCondition.Status = metav1.ConditionTrue
+		unhealthyCondition.Reason = "TooManyCrashes"
+		unhealthyCondition.Message += ", no GameServers are created or deleted"
+	}
+	meta.SetStatusCondition(&status.Conditions, unhealthyCondition)
+
+	scalingLimitedCondition := metav1.Condition{
+		Type:               mpsv1alpha1.BuildConditionScalingLimited,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: gsb.Generation,
+		Reason:             "NotLimited",
+		Message:            fmt.Sprintf("%d StandingBy GameServers can be created", target),
+	}
+	if paused && status.CurrentStandingBy+status.CurrentInitializing != target {
+		scalingLimitedCondition.Status = metav1.ConditionTrue
+		scalingLimitedCondition.Reason = "Paused"
+		scalingLimitedCondition.Message = "the GameServerBuild is paused, no GameServers are created or deleted"
+	} else if target < standingBy {
+		scalingLimitedCondition.Status = metav1.ConditionTrue
+		scalingLimitedCondition.Reason = "MaxReached"
+		scalingLimitedCondition.Message = fmt.Sprintf("%d Active GameServers of Max %d, only %d of %d StandingBy GameServers can be created",
+			status.CurrentActive, gsb.Spec.Max, target, standingBy)
+	} else if cordoned && status.CurrentStandingBy+status.CurrentInitializing < target {
+		scalingLimitedCondition.Status = metav1.ConditionTrue
+		scalingLimitedCondition.Reason = "Cordoned"
+		scalingLimitedCondition.Message = "the GameServerBuild is cordoned, no GameServers are created"
+	}
+	meta.SetStatusCondition(&status.Conditions, scalingLimitedCondition)
+
+	progressingCondition := metav1.Condition{
+		Type:               mpsv1alpha1.BuildConditionProgressing,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: gsb.Generation,
+		Reason:             "ScalingComplete",
+		Message:            fmt.Sprintf("%d of %d StandingBy GameServers", status.CurrentStandingBy, target),
+	}
+	if status.CurrentInitializing > 0 ||
+		(status.CurrentStandingBy > target && !paused) ||
+		(status.CurrentStandingBy < target && !cordoned && !unhealthy && !paused) {
+		progressingCondition.Status = metav1.ConditionTrue
+		progressingCondition.Reason = "Scaling"
+		progressingCondition.Message = fmt.Sprintf("%d of %d StandingBy GameServers, %d initializing",
+			status.CurrentStandingBy, target, status.CurrentInitializing)
+	}
+	meta.SetStatusCondition(&status.Conditions, progressingCondition)
+
+	readyCondition := metav1.Condition{
+		Type:               mpsv1alpha1.BuildConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: gsb.Generation,
+		Reason:             "StandingByNotReady",
+		Message:            fmt.Sprintf("%d of %d StandingBy GameServers are ready, %d initializing", status.CurrentStandingBy, target, status.CurrentInitializing),
+	}
+	if unhealthy {
+		readyCondition.Reason = "Unhealthy"
+		readyCondition.Message = "the GameServerBuild is unhealthy"
+	} else if status.CurrentInitializing == 0 && status.CurrentStandingBy >= target {
+		readyCondition.Status = metav1.ConditionTrue
+		readyCondition.Reason = "StandingByReady"
+		readyCondition.Message = fmt.Sprintf("%d of %d StandingBy GameServers are ready", status.CurrentStandingBy, target)
+	}
+	meta.SetStatusCondition(&status.Conditions, readyCondition)
+}
+
+// deleteStandingByGameServers deletes up to count StandingBy GameServers of the GameServerBuild and returns the number of deleted GameServers
+// the GameServers are deleted in the order of the ScaleDownPolicy of the GameServerBuild, initializing and Active GameServers are never deleted
+func (r *GameServerBuildReconciler) deleteStandingByGameServers(ctx context.Context, gsb *mpsv1alpha1.GameServerBuild, gameServers []mpsv1alpha1.GameServer, count int) (int, error) {
+	var standingByGameServers []mpsv1alpha1.GameServer
+	for _, gs := range gameServers {
+		if gs.Status.State == mpsv1alpha1.GameServerStateStandingBy {
+			standingByGameServers = append(standingByGameServers, gs)
+		}
+	}
+
+	var gameServersPerNode map[string]int
+	if gsb.Spec.ScaleDownPolicy == "" || gsb.Spec.ScaleDownPolicy == mpsv1alpha1.ScaleDownLeastUtilizedNode {
+		var err error
+		if gameServersPerNode, err = r.getGameServersPerNode(ctx); err != nil {
+			return 0, err
+		}
+	}
+	sortGameServersForDeletion(standingByGameServers, gsb.Spec.ScaleDownPolicy, gameServersPerNode)
+
+	deletedCount := 0
+	for i := 0; i < len(standingByGameServers) && deletedCount < count; i++ {
+		gs := standingByGameServers[i]
+		if err := r.Delete(ctx, &gs); err != nil {
+			return deletedCount, err
+		}
+		GameServersDeletedCounter.WithLabelValues(gsb.Name).Inc()
+		addGameServerToUnderDeletionMap(gsb.Name, gs.Name)
+		deletedCount++
+		r.Recorder.Eventf(gsb, corev1.EventTypeNormal, "GameServer deleted", "GameServer %s deleted", gs.Name)
+	}
+	return deletedCount, nil
+}
+
+// getGameServersPerNode returns the number of GameServers of all the GameServerBuilds on each Node
+func (r *GameServerBuildReconciler) getGameServersPerNode(ctx context.Context) (map[string]int, error) {
+	var gameServers mpsv1alpha1.GameServerList
+	if err := r.List(ctx, &gameServers); err != nil {
+		return nil, err
+	}
+	gameServersPerNode := make(map[string]int)
+	for _, gs := range gameServers.Items {
+		if gs.Status.NodeName != "" {
+			gameServersPerNode[gs.Status.NodeName]++
+		}
+	}
+	return gameServersPerNode, nil
+}
+
+// sortGameServersForDeletion sorts the GameServers in the order they should be deleted, according to the ScaleDownPolicy
+// Unhealthy GameServers are always first, the GameServers on the least utilized Nodes are first by default
+// the newest GameServers are first among the ones on equally utilized Nodes, since their Nodes are the most likely to be emptied soon
+func sortGameServersForDeletion(gameServers []mpsv1alpha1.GameServer, policy mpsv1alpha1.ScaleDownPolicy, gameServersPerNode map[string]int) {
+	sort.SliceStable(gameServers, func(i, j int) bool {
+		gsi, gsj := &gameServers[i], &gameServers[j]
+		if unhealthyi, unhealthyj := gsi.Status.Health == mpsv1alpha1.Unhealthy, gsj.Status.Health == mpsv1alpha1.Unhealthy; unhealthyi != unhealthyj {
+			return unhealthyi
+		}
+		switch policy {
+		case mpsv1alpha1.ScaleDownOldest:
+			return gsi.CreationTimestamp.Before(&gsj.CreationTimestamp)
+		case mpsv1alpha1.ScaleDownNewest:
+			return gsj.CreationTimestamp.Before(&gsi.CreationTimestamp)
+		default:
+			if nodei, nodej := gameServersPerNode[gsi.Status.NodeName], gameServersPerNode[gsj.Status.NodeName]; nodei != nodej {
+				return nodei < nodej
+			}
+			return gsj.CreationTimestamp.Before(&gsi.CreationTimestamp)
+		}
+	})
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -2,11 +2,14 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
@@ -110,6 +113,99 @@ var _ = Describe("GameServerBuild controller tests", func() {
 			updateInitializingGameServersToStandingBy(ctx, buildID)
 			verifyStandingByActiveByCount(ctx, buildID, 4, 2)
 		})
+		// scaling down, e.g. with kubectl scale, should delete only StandingBy GameServers
+		It("should scale down only standingBy game servers", func() {
+			buildName, buildID := getNewBuildNameAndID()
+			gsb := createTestGameServerBuild(buildName, buildID, 4, 6)
+			Expect(k8sClient.Create(ctx, &gsb)).Should(Succeed())
+			verifyTotalGameServerCount(ctx, buildID, 4)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 4, 0)
+
+			allocateGameServer(ctx, buildID)
+			allocateGameServer(ctx, buildID)
+			verifyTotalGameServerCount(ctx, buildID, 6)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 4, 2)
+
+			// decrease standingBy and max at the same time, the GameServers should not be deleted twice
+			updateGameServerBuild(ctx, 1, 4, buildName)
+			verifyTotalGameServerCount(ctx, buildID, 3)
+			verifyStandingByActiveByCount(ctx, buildID, 1, 2)
+		})
+
+		It("should not create game servers for a cordoned build", func() {
+			buildName, buildID := getNewBuildNameAndID()
+			gsb := createTestGameServerBuild(buildName, buildID, 2, 4)
+			Expect(k8sClient.Create(ctx, &gsb)).Should(Succeed())
+			verifyTotalGameServerCount(ctx, buildID, 2)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 2, 0)
+
+			setGameServerBuildCordoned(ctx, buildName, true)
+			allocateGameServer(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 1, 1)
+			Consistently(func() int {
+				var gameServers mpsv1alpha1.GameServerList
+				Expect(k8sClient.List(ctx, &gameServers, client.InNamespace(testnamespace), client.MatchingLabels{LabelBuildID: buildID})).To(Succeed())
+				return len(gameServers.Items)
+			}, "2s", interval).Should(Equal(2))
+
+			setGameServerBuildCordoned(ctx, buildName, false)
+			verifyTotalGameServerCount(ctx, buildID, 3)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 2, 1)
+		})
+
+		It("should not create or delete game servers for a paused build", func() {
+			buildName, buildID := getNewBuildNameAndID()
+			gsb := createTestGameServerBuild(buildName, buildID, 2, 4)
+			Expect(k8sClient.Create(ctx, &gsb)).Should(Succeed())
+			verifyTotalGameServerCount(ctx, buildID, 2)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 2, 0)
+
+			setGameServerBuildPaused(ctx, buildName, true)
+			allocateGameServer(ctx, buildID)
+			terminateActiveSession(ctx, buildID, true)
+			Consistently(func() int {
+				var gameServers mpsv1alpha1.GameServerList
+				Expect(k8sClient.List(ctx, &gameServers, client.InNamespace(testnamespace), client.MatchingLabels{LabelBuildID: buildID})).To(Succeed())
+				return len(gameServers.Items)
+			}, "2s", interval).Should(Equal(2))
+			verifyStandingByActiveByCount(ctx, buildID, 1, 0)
+
+			setGameServerBuildPaused(ctx, buildName, false)
+			verifyTotalGameServerCount(ctx, buildID, 2)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 2, 0)
+		})
+
+		It("should wait for the active game servers before deleting a build", func() {
+			buildName, buildID := getNewBuildNameAndID()
+			gsb := createTestGameServerBuild(buildName, buildID, 2, 4)
+			Expect(k8sClient.Create(ctx, &gsb)).Should(Succeed())
+			verifyTotalGameServerCount(ctx, buildID, 2)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			allocateGameServer(ctx, buildID)
+			verifyTotalGameServerCount(ctx, buildID, 3)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 2, 1)
+
+			Expect(k8sClient.Delete(ctx, &gsb)).Should(Succeed())
+			verifyTotalGameServerCount(ctx, buildID, 1)
+			verifyStandingByActiveByCount(ctx, buildID, 0, 1)
+			Eventually(func() bool {
+				gsb := getGameServerBuild(ctx, buildName)
+				return meta.IsStatusConditionTrue(gsb.Status.Conditions, mpsv1alpha1.BuildConditionDraining)
+			}, timeout, interval).Should(BeTrue())
+
+			terminateActiveSession(ctx, buildID, true)
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: buildName, Namespace: testnamespace}, &mpsv1alpha1.GameServerBuild{})
+				return apierrors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+		})
 
 		It("should create new game servers if game sessions end", func() {
 			buildName, buildID := getNewBuildNameAndID()
@@ -170,9 +266,156 @@ var _ = Describe("GameServerBuild controller tests", func() {
 			}
 			verifyThatBuildIsUnhealthy(ctx, buildName)
 		})
+
+		It("should retain the most recently crashed game servers", func() {
+			// Max is reached once both game servers are allocated
+			buildName, buildID := getNewBuildNameAndID()
+			gsb := createTestGameServerBuild(buildName, buildID, 2, 2)
+			gsb.Spec.RetainCrashed = 1
+			Expect(k8sClient.Create(ctx, &gsb)).Should(Succeed())
+			verifyTotalGameServerCount(ctx, buildID, 2)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			allocateGameServer(ctx, buildID)
+			allocateGameServer(ctx, buildID)
+			verifyStandingByActiveByCount(ctx, buildID, 0, 2)
+
+			// the retained game server is not counted as Active, so a new one is created to replace it
+			first := crashActiveGameServer(ctx, buildID, time.Now().Add(-time.Minute))
+			waitTillCountGameServersAreInitializing(ctx, buildID, 1)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyRetainedGameServer(ctx, buildID, first)
+
+			// only the most recent crash is retained
+			second := crashActiveGameServer(ctx, buildID, time.Now())
+			waitTillCountGameServersAreInitializing(ctx, buildID, 1)
+			updateInitializingGameServersToStandingBy(ctx, buildID)
+			verifyRetainedGameServer(ctx, buildID, second)
+			verifyTotalGameServerCount(ctx, buildID, 3)
+			verifyStandingByActiveByCount(ctx, buildID, 2, 0)
+			Eventually(func() bool {
+				gsb := getGameServerBuild(ctx, buildName)
+				return gsb.Status.CurrentStandingBy == 2 && gsb.Status.CurrentActive == 0 && gsb.Status.CrashesCount == 2
+			}, timeout, interval).Should(BeTrue())
+
+			// the pod of the retained game server is kept, and can be evicted
+			Eventually(func() string {
+				var pod corev1.Pod
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: second, Namespace: testnamespace}, &pod); err != nil {
+					return ""
+				}
+				return pod.Annotations[safeToEvictPodAttribute]
+			}, timeout, interval).Should(Equal("true"))
+		})
+	})
+	Context("testing the conditions of a gameserverbuild", func() {
+		newBuildWithStatus := func(standingBy, max int, status mpsv1alpha1.GameServerBuildStatus) *mpsv1alpha1.GameServerBuild {
+			return &mpsv1alpha1.GameServerBuild{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       mpsv1alpha1.GameServerBuildSpec{StandingBy: standingBy, Max: max, CrashesToMarkUnhealthy: 5},
+				Status:     status,
+			}
+		}
+		It("should be Ready when the StandingBy GameServers are ready", func() {
+			gsb := newBuildWithStatus(2, 4, mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 2, CurrentActive: 1, Health: mpsv1alpha1.BuildHealthy})
+			setGameServerBuildConditions(gsb)
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionReady, metav1.ConditionTrue, "StandingByReady")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionProgressing, metav1.ConditionFalse, "ScalingComplete")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionScalingLimited, metav1.ConditionFalse, "NotLimited")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionUnhealthy, metav1.ConditionFalse, "Healthy")
+			Expect(meta.FindStatusCondition(gsb.Status.Conditions, mpsv1alpha1.BuildConditionReady).ObservedGeneration).To(Equal(int64(2)))
+		})
+		It("should be Progressing while GameServers are initializing", func() {
+			gsb := newBuildWithStatus(4, 4, mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 2, CurrentInitializing: 2, Health: mpsv1alpha1.BuildHealthy})
+			setGameServerBuildConditions(gsb)
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionReady, metav1.ConditionFalse, "StandingByNotReady")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionProgressing, metav1.ConditionTrue, "Scaling")
+		})
+		It("should be ScalingLimited and Ready when Max is reached", func() {
+			gsb := newBuildWithStatus(2, 4, mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 1, CurrentActive: 3, Health: mpsv1alpha1.BuildHealthy})
+			setGameServerBuildConditions(gsb)
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionScalingLimited, metav1.ConditionTrue, "MaxReached")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionReady, metav1.ConditionTrue, "StandingByReady")
+		})
+		It("should be ScalingLimited when the build is cordoned", func() {
+			gsb := newBuildWithStatus(2, 4, mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 1, Health: mpsv1alpha1.BuildHealthy})
+			gsb.Annotations = map[string]string{mpsv1alpha1.CordonedAnnotation: "true"}
+			setGameServerBuildConditions(gsb)
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionScalingLimited, metav1.ConditionTrue, "Cordoned")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionProgressing, metav1.ConditionFalse, "ScalingComplete")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionReady, metav1.ConditionFalse, "StandingByNotReady")
+		})
+		It("should be ScalingLimited and not Progressing when the build is paused", func() {
+			gsb := newBuildWithStatus(2, 4, mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 3, Health: mpsv1alpha1.BuildHealthy})
+			gsb.Spec.Paused = true
+			setGameServerBuildConditions(gsb)
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionScalingLimited, metav1.ConditionTrue, "Paused")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionProgressing, metav1.ConditionFalse, "ScalingComplete")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionReady, metav1.ConditionTrue, "StandingByReady")
+		})
+		It("should not be Ready when the build is unhealthy", func() {
+			gsb := newBuildWithStatus(2, 4, mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 2, CrashesCount: 5, Health: mpsv1alpha1.BuildUnhealthy})
+			setGameServerBuildConditions(gsb)
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionUnhealthy, metav1.ConditionTrue, "TooManyCrashes")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionReady, metav1.ConditionFalse, "Unhealthy")
+		})
+		It("should be Draining and not Ready when the build is deleted", func() {
+			gsb := newBuildWithStatus(2, 4, mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 2, CurrentActive: 1, Health: mpsv1alpha1.BuildHealthy})
+			setGameServerBuildConditions(gsb)
+			setGameServerBuildDrainingStatus(gsb, 1, time.Date(2021, 8, 1, 11, 0, 0, 0, time.UTC))
+			Expect(gsb.Status.CurrentStandingBy).To(BeZero())
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionDraining, metav1.ConditionTrue, "WaitingForActiveGameServers")
+			verifyCondition(gsb, mpsv1alpha1.BuildConditionReady, metav1.ConditionFalse, "Draining")
+			Expect(meta.FindStatusCondition(gsb.Status.Conditions, mpsv1alpha1.BuildConditionDraining).Message).To(ContainSubstring("1 Active GameServers until 2021-08-01T11:00:00Z"))
+		})
+	})
+	Context("testing the order of the game servers deleted on scale down", func() {
+		now := time.Now()
+		newGameServers := func() []mpsv1alpha1.GameServer {
+			newGameServer := func(name, nodeName string, age time.Duration, health mpsv1alpha1.GameServerHealth) mpsv1alpha1.GameServer {
+				return mpsv1alpha1.GameServer{
+					ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+					Status:     mpsv1alpha1.GameServerStatus{NodeName: nodeName, Health: health, State: mpsv1alpha1.GameServerStateStandingBy},
+				}
+			}
+			return []mpsv1alpha1.GameServer{
+				newGameServer("gs1", "node1", 3*time.Minute, mpsv1alpha1.Healthy),
+				newGameServer("gs2", "node2", 2*time.Minute, mpsv1alpha1.Healthy),
+				newGameServer("gs3", "node1", time.Minute, mpsv1alpha1.Healthy),
+				newGameServer("gs4", "node1", 4*time.Minute, mpsv1alpha1.Unhealthy),
+				newGameServer("gs5", "node2", 5*time.Minute, mpsv1alpha1.Healthy),
+			}
+		}
+		gameServerNames := func(gameServers []mpsv1alpha1.GameServer) []string {
+			var names []string
+			for _, gs := range gameServers {
+				names = append(names, gs.Name)
+			}
+			return names
+		}
+
+		It("should delete the game servers on the least utilized nodes first", func() {
+			gameServers := newGameServers()
+			sortGameServersForDeletion(gameServers, mpsv1alpha1.ScaleDownLeastUtilizedNode, map[string]int{"node1": 5, "node2": 2})
+			Expect(gameServerNames(gameServers)).To(Equal([]string{"gs4", "gs2", "gs5", "gs3", "gs1"}))
+		})
+		It("should delete the newest or the oldest game servers first", func() {
+			gameServers := newGameServers()
+			sortGameServersForDeletion(gameServers, mpsv1alpha1.ScaleDownNewest, nil)
+			Expect(gameServerNames(gameServers)).To(Equal([]string{"gs4", "gs3", "gs2", "gs1", "gs5"}))
+			sortGameServersForDeletion(gameServers, mpsv1alpha1.ScaleDownOldest, nil)
+			Expect(gameServerNames(gameServers)).To(Equal([]string{"gs4", "gs5", "gs1", "gs2", "gs3"}))
+		})
 	})
 })
 
+// verifyCondition verifies the status and the reason of a condition of the GameServerBuild
+func verifyCondition(gsb *mpsv1alpha1.GameServerBuild, conditionType string, status metav1.ConditionStatus, reason string) {
+	condition := meta.FindStatusCondition(gsb.Status.Conditions, conditionType)
+	Expect(condition).ToNot(BeNil(), conditionType)
+	Expect(condition.Status).To(Equal(status), conditionType)
+	Expect(condition.Reason).To(Equal(reason), conditionType)
+}
+
 // getNewBuildNameAndID returns a new build name and ID
 func getNewBuildNameAndID() (string, string) {
 	buildName := randString(5)
@@ -186,7 +429,8 @@ func verifyThatBuildIsUnhealthy(ctx context.Context, buildName string) {
 		var gameServerBuild mpsv1alpha1.GameServerBuild
 		err := k8sClient.Get(ctx, types.NamespacedName{Name: buildName, Namespace: testnamespace}, &gameServerBuild)
 		Expect(err).ShouldNot(HaveOccurred())
-		return gameServerBuild.Status.Health == mpsv1alpha1.BuildUnhealthy
+		return gameServerBuild.Status.Health == mpsv1alpha1.BuildUnhealthy &&
+			meta.IsStatusConditionTrue(gameServerBuild.Status.Conditions, mpsv1alpha1.BuildConditionUnhealthy)
 	})
 }
 
@@ -226,6 +470,42 @@ func terminateActiveSession(ctx context.Context, buildID string, gracefulTermina
 	Expect(true).To(BeFalse()) // should never get here
 }
 
+// crashActiveGameServer sets the state of an Active GameServer to Crashed and returns its name
+func crashActiveGameServer(ctx context.Context, buildID string, completionTime time.Time) string {
+	var gameServers mpsv1alpha1.GameServerList
+	err := k8sClient.List(ctx, &gameServers, client.InNamespace(testnamespace), client.MatchingLabels{LabelBuildID: buildID})
+	Expect(err).ToNot(HaveOccurred())
+	for i := 0; i < len(gameServers.Items); i++ {
+		gs := gameServers.Items[i]
+		if gs.Status.State == mpsv1alpha1.GameServerStateActive {
+			gs.Status.State = mpsv1alpha1.GameServerStateCrashed
+			completed := metav1.NewTime(completionTime)
+			gs.Status.CompletionTime = &completed
+			err = k8sClient.Status().Update(ctx, &gs)
+			Expect(err).ToNot(HaveOccurred())
+			return gs.Name
+		}
+	}
+	Expect(true).To(BeFalse()) // should never get here
+	return ""
+}
+
+// verifyRetainedGameServer verifies that the GameServer with the given name is the only retained one of the build
+func verifyRetainedGameServer(ctx context.Context, buildID, gameServerName string) {
+	Eventually(func() []string {
+		var gameServers mpsv1alpha1.GameServerList
+		err := k8sClient.List(ctx, &gameServers, client.InNamespace(testnamespace), client.MatchingLabels{LabelBuildID: buildID, mpsv1alpha1.CrashRetainedLabel: "true"})
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, gs := range gameServers.Items {
+			if gs.DeletionTimestamp.IsZero() {
+				names = append(names, gs.Name)
+			}
+		}
+		return names
+	}, timeout, interval).Should(Equal([]string{gameServerName}))
+}
+
 // allocateGameServer converts the state of a GameServer to Active
 func allocateGameServer(ctx context.Context, buildID string) {
 	var gameServers mpsv1alpha1.GameServerList
@@ -253,6 +533,31 @@ func updateGameServerBuild(ctx context.Context, standingBy, max int, buildName s
 	}, timeout, interval).Should(Succeed())
 }
 
+// setGameServerBuildCordoned sets or removes the cordoned annotation of the GameServerBuild
+func setGameServerBuildCordoned(ctx context.Context, buildName string, cordoned bool) {
+	Eventually(func() error {
+		gsb := getGameServerBuild(ctx, buildName)
+		if gsb.Annotations == nil {
+			gsb.Annotations = map[string]string{}
+		}
+		if cordoned {
+			gsb.Annotations[mpsv1alpha1.CordonedAnnotation] = "true"
+		} else {
+			delete(gsb.Annotations, mpsv1alpha1.CordonedAnnotation)
+		}
+		return k8sClient.Update(ctx, &gsb)
+	}, timeout, interval).Should(Succeed())
+}
+
+// setGameServerBuildPaused pauses or resumes the GameServerBuild
+func setGameServerBuildPaused(ctx context.Context, buildName string, paused bool) {
+	Eventually(func() error {
+		gsb := getGameServerBuild(ctx, buildName)
+		gsb.Spec.Paused = paused
+		return k8sClient.Update(ctx, &gsb)
+	}, timeout, interval).Should(Succeed())
+}
+
 // verifyTotalGameServerCount verifies the total number of game servers
 func verifyTotalGameServerCount(ctx context.Context, buildID string, total int) {
 	Eventually(func() bool {
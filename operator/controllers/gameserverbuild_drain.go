@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+)
+
+// buildFinalizerName is the finalizer that keeps a deleted GameServerBuild, and so its GameServers, until its Active GameServers are drained
+const buildFinalizerName string = "gameserverbuilds.mps.playfab.com/finalizer"
+
+// drainGameServerBuild drains the GameServers of a deleted GameServerBuild and removes its finalizer once no Active GameServers remain
+// the non Active GameServers are deleted right away, the Active ones only when the GameServerBuild is force deleted or its DrainTimeout expires
+func (r *GameServerBuildReconciler) drainGameServerBuild(ctx context.Context, gsb *mpsv1alpha1.GameServerBuild) (ctrl.Result, error) {
+	if !containsString(gsb.GetFinalizers(), buildFinalizerName) {
+		return ctrl.Result{}, nil
+	}
+
+	var gameServers mpsv1alpha1.GameServerList
+	if err := r.List(ctx, &gameServers, client.InNamespace(gsb.Namespace), client.MatchingFields{ownerKey: gsb.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	deadline := gsb.DeletionTimestamp.Add(gsb.GetDrainTimeout())
+	deleteActive := gsb.IsForceDeleted() || !now.Before(deadline)
+
+	var activeCount, conflictCount int
+	for i := 0; i < len(gameServers.Items); i++ {
+		gs := gameServers.Items[i]
+		active := gs.Status.State == mpsv1alpha1.GameServerStateActive
+		if active && !deleteActive {
+			activeCount++
+			continue
+		}
+		if !gs.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// the precondition fails if the GameServer changed since it was listed, e.g. if it was allocated in the meantime
+		if err := r.Delete(ctx, &gs, client.Preconditions{ResourceVersion: &gs.ResourceVersion}); err != nil {
+			if apierrors.IsConflict(err) {
+				conflictCount++
+				continue
+			}
+			if !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+		if active {
+			r.Recorder.Eventf(gsb, corev1.EventTypeWarning, "ActiveDeleted", "GameServer %s was deleted during its game session", gs.Name)
+		}
+	}
+
+	// the GameServers that changed are checked again, since they might be Active now
+	if activeCount == 0 && conflictCount > 0 {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if activeCount == 0 {
+		controllerutil.RemoveFinalizer(gsb, buildFinalizerName)
+		if err := r.Update(ctx, gsb); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		gameServersUnderCreation.Del(gsb.Name)
+		gameServersUnderDeletion.Del(gsb.Name)
+		r.Recorder.Event(gsb, corev1.EventTypeNormal, "Drained", "no Active GameServers remain, the GameServerBuild is deleted")
+		return ctrl.Result{}, nil
+	}
+
+	// the finalizer keeps the GameServerBuild until then, so this shows why its deletion is not done yet
+	log.FromContext(ctx).Info("Waiting for the Active GameServers of the deleted GameServerBuild", "activeCount", activeCount, "deadline", deadline.UTC().Format(time.RFC3339))
+	if err := r.updateDrainingStatus(ctx, gsb, activeCount, deadline); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	ActiveGameServersGauge.WithLabelValues(gsb.Name).Set(float64(activeCount))
+
+	// the GameServers that finish their game sessions trigger a reconcile, the deadline does not
+	return ctrl.Result{RequeueAfter: deadline.Sub(now)}, nil
+}
+
+// updateDrainingStatus reports the Active GameServers that the deleted GameServerBuild waits for in its status
+func (r *GameServerBuildReconciler) updateDrainingStatus(ctx context.Context, gsb *mpsv1alpha1.GameServerBuild, activeCount int, deadline time.Time) error {
+	oldStatus := gsb.Status.DeepCopy()
+	setGameServerBuildDrainingStatus(gsb, activeCount, deadline)
+	if equality.Semantic.DeepEqual(oldStatus, &gsb.Status) {
+		return nil
+	}
+	if !meta.IsStatusConditionTrue(oldStatus.Conditions, mpsv1alpha1.BuildConditionDraining) {
+		r.Recorder.Eventf(gsb, corev1.EventTypeNormal, "Draining", "waiting for %d Active GameServers until %s", activeCount, deadline.UTC().Format(time.RFC3339))
+	}
+	return r.Status().Update(ctx, gsb)
+}
+
+// setGameServerBuildDrainingStatus sets the counts and the conditions of a deleted GameServerBuild that waits for activeCount Active GameServers
+func setGameServerBuildDrainingStatus(gsb *mpsv1alpha1.GameServerBuild, activeCount int, deadline time.Time) {
+	status := &gsb.Status
+	status.CurrentInitializing = 0
+	status.CurrentStandingBy = 0
+	status.CurrentActive = activeCount
+	status.CurrentStandingByReadyDesired = "0/0"
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               mpsv1alpha1.BuildConditionDraining,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: gsb.Generation,
+		Reason:             "WaitingForActiveGameServers",
+		Message: fmt.Sprintf("the GameServerBuild is deleted, waiting for %d Active GameServers until %s",
+			activeCount, deadline.UTC().Format(time.RFC3339)),
+	})
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               mpsv1alpha1.BuildConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: gsb.Generation,
+		Reason:             "Draining",
+		Message:            "the GameServerBuild is deleted, no GameServers are created",
+	})
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+)
+
+// ActiveTerminationGracePeriod is how long a GameServer that exceeded the MaxActiveDuration of its GameServerBuild
+// has to end its game session after it was asked to terminate, before it's deleted
+const ActiveTerminationGracePeriod = 2 * time.Minute
+
+// StandingByRecycleBatchPercent is the percentage of the StandingBy GameServers of a GameServerBuild, at least one, that are recycled together
+// the next batch is only recycled once the replacements are no longer initializing, so the StandingBy GameServers that
+// were created together and expire together are not all deleted at once, which would leave no GameServers to allocate
+const StandingByRecycleBatchPercent = 10
+
+// standingByRecycleRetryPeriod is how soon the expired StandingBy GameServers that wait for the next batch are checked again
+const standingByRecycleRetryPeriod = 10 * time.Second
+
+// recycleExpiredGameServers deletes the StandingBy GameServers that exceeded the StandingByTTL of the GameServerBuild, in batches,
+// and terminates the Active GameServers that exceeded its MaxActiveDuration, first through the GSDK and after ActiveTerminationGracePeriod by deleting them
+// the GameServers are deleted only if they did not change since they were listed, so a GameServer that was just allocated is not deleted
+// it returns the GameServers that were not deleted, and the time until the next GameServer expires or zero if none will
+func (r *GameServerBuildReconciler) recycleExpiredGameServers(ctx context.Context, gsb *mpsv1alpha1.GameServerBuild, gameServers []mpsv1alpha1.GameServer, now time.Time) ([]mpsv1alpha1.GameServer, time.Duration, error) {
+	if gsb.Spec.StandingByTTL == nil && gsb.Spec.MaxActiveDuration == nil {
+		return gameServers, 0, nil
+	}
+
+	toRecycle := getStandingByGameServersToRecycle(gsb, gameServers, now)
+	remaining := make([]mpsv1alpha1.GameServer, 0, len(gameServers))
+	var nextExpiry time.Duration
+	for i := 0; i < len(gameServers); i++ {
+		gs := gameServers[i]
+		expiry, ok := getGameServerExpiry(gsb, &gs)
+		if !ok {
+			remaining = append(remaining, gs)
+			continue
+		}
+		if now.Before(expiry) {
+			nextExpiry = minRequeueAfter(nextExpiry, expiry.Sub(now))
+			remaining = append(remaining, gs)
+			continue
+		}
+
+		// an expired StandingBy GameServer waits for its batch
+		if gs.Status.State == mpsv1alpha1.GameServerStateStandingBy {
+			if _, ok := toRecycle[gs.Name]; !ok {
+				nextExpiry = minRequeueAfter(nextExpiry, standingByRecycleRetryPeriod)
+				remaining = append(remaining, gs)
+				continue
+			}
+		}
+
+		// an expired Active GameServer is asked to terminate first, the sidecar passes the request to the GSDK
+		if gs.Status.State == mpsv1alpha1.GameServerStateActive && gs.Status.TerminationRequestedTime == nil {
+			requested := metav1.NewTime(now)
+			gs.Status.TerminationRequestedTime = &requested
+			if err := r.Status().Update(ctx, &gs); client.IgnoreNotFound(err) != nil {
+				return nil, 0, err
+			}
+			GameServersTerminationRequestedCounter.WithLabelValues(gsb.Name).Inc()
+			r.Recorder.Eventf(gsb, corev1.EventTypeNormal, "TerminationRequested", "GameServer %s exceeded the MaxActiveDuration %s, its game session is asked to terminate",
+				gs.Name, gsb.Spec.MaxActiveDuration.Duration)
+			nextExpiry = minRequeueAfter(nextExpiry, ActiveTerminationGracePeriod)
+			remaining = append(remaining, gs)
+			continue
+		}
+
+		// the precondition fails if the GameServer changed since it was listed, e.g. if it was allocated in the meantime
+		if err := r.Delete(ctx, &gs, client.Preconditions{ResourceVersion: &gs.ResourceVersion}); err != nil {
+			if apierrors.IsConflict(err) {
+				remaining = append(remaining, gs)
+				continue
+			}
+			if !apierrors.IsNotFound(err) {
+				return nil, 0, err
+			}
+		}
+		addGameServerToUnderDeletionMap(gsb.Name, gs.Name)
+		if gs.Status.State == mpsv1alpha1.GameServerStateStandingBy {
+			GameServersRecycledCounter.WithLabelValues(gsb.Name, "StandingByTTL").Inc()
+			r.Recorder.Eventf(gsb, corev1.EventTypeNormal, "StandingByExpired", "GameServer %s exceeded the StandingByTTL %s, it's deleted and replaced",
+				gs.Name, gsb.Spec.StandingByTTL.Duration)
+		} else {
+			GameServersRecycledCounter.WithLabelValues(gsb.Name, "MaxActiveDuration").Inc()
+			r.Recorder.Eventf(gsb, corev1.EventTypeWarning, "MaxActiveDurationExceeded", "GameServer %s did not terminate its game session %s after it was asked to, it's deleted",
+				gs.Name, ActiveTerminationGracePeriod)
+		}
+	}
+	return remaining, nextExpiry, nil
+}
+
+// getStandingByGameServersToRecycle returns the names of the expired StandingBy GameServers of the current batch, the ones that expired first
+// there is no batch while GameServers are initializing, since they are the replacements of the previous batch
+func getStandingByGameServersToRecycle(gsb *mpsv1alpha1.GameServerBuild, gameServers []mpsv1alpha1.GameServer, now time.Time) map[string]struct{} {
+	type expiredGameServer struct {
+		name   string
+		expiry time.Time
+	}
+	var expired []expiredGameServer
+	for i := 0; i < len(gameServers); i++ {
+		gs := &gameServers[i]
+		if gs.Status.State == "" {
+			return nil
+		}
+		if gs.Status.State != mpsv1alpha1.GameServerStateStandingBy {
+			continue
+		}
+		if expiry, ok := getGameServerExpiry(gsb, gs); ok && !now.Before(expiry) {
+			expired = append(expired, expiredGameServer{name: gs.Name, expiry: expiry})
+		}
+	}
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].expiry.Before(expired[j].expiry)
+	})
+
+	batchSize := gsb.Spec.StandingBy * StandingByRecycleBatchPercent / 100
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	toRecycle := make(map[string]struct{}, batchSize)
+	for i := 0; i < len(expired) && i < batchSize; i++ {
+		toRecycle[expired[i].name] = struct{}{}
+	}
+	return toRecycle
+}
+
+// getGameServerExpiry returns when the GameServer exceeds the lifetime that the GameServerBuild allows in its state
+// a StandingBy GameServer expires StandingByTTL after it became StandingBy, an Active GameServer MaxActiveDuration after its allocation,
+// and ActiveTerminationGracePeriod after it was asked to terminate. It returns false if the GameServer does not expire
+func getGameServerExpiry(gsb *mpsv1alpha1.GameServerBuild, gs *mpsv1alpha1.GameServer) (time.Time, bool) {
+	switch gs.Status.State {
+	case mpsv1alpha1.GameServerStateStandingBy:
+		if gsb.Spec.StandingByTTL == nil {
+			return time.Time{}, false
+		}
+		// the StandingByTime is set by the sidecar, the GameServers of older versions only have their creation time
+		standingBySince := gs.CreationTimestamp.Time
+		if gs.Status.StandingByTime != nil {
+			standingBySince = gs.Status.StandingByTime.Time
+		}
+		return standingBySince.Add(gsb.Spec.StandingByTTL.Duration), true
+	case mpsv1alpha1.GameServerStateActive:
+		if gs.Status.TerminationRequestedTime != nil {
+			return gs.Status.TerminationRequestedTime.Add(ActiveTerminationGracePeriod), true
+		}
+		if gsb.Spec.MaxActiveDuration == nil || gs.Status.AllocatedTime == nil {
+			return time.Time{}, false
+		}
+		return gs.Status.AllocatedTime.Add(gsb.Spec.MaxActiveDuration.Duration), true
+	}
+	return time.Time{}, false
+}
+
+// minRequeueAfter returns the shorter of the two requeue durations, zero means that no requeue is needed
+func minRequeueAfter(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
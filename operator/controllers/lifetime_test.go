@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GameServer lifetime tests", func() {
+	now := time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)
+	newBuild := func() *mpsv1alpha1.GameServerBuild {
+		return &mpsv1alpha1.GameServerBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "build1", Namespace: "default"},
+			Spec: mpsv1alpha1.GameServerBuildSpec{
+				StandingByTTL:     &metav1.Duration{Duration: 24 * time.Hour},
+				MaxActiveDuration: &metav1.Duration{Duration: time.Hour},
+			},
+		}
+	}
+
+	It("should compute when a game server expires in its state", func() {
+		gsb := newBuild()
+		gs := newTestGameServer("gs1", mpsv1alpha1.GameServerStateStandingBy, now, time.Hour)
+		expiry, ok := getGameServerExpiry(gsb, &gs)
+		Expect(ok).To(BeTrue())
+		Expect(expiry).To(Equal(now.Add(23 * time.Hour)))
+
+		// the creation time is used if the sidecar did not set the StandingByTime
+		gs.Status.StandingByTime = nil
+		expiry, _ = getGameServerExpiry(gsb, &gs)
+		Expect(expiry).To(Equal(now.Add(-24 * time.Hour)))
+
+		gs = newTestGameServer("gs2", mpsv1alpha1.GameServerStateActive, now, 10*time.Minute)
+		expiry, _ = getGameServerExpiry(gsb, &gs)
+		Expect(expiry).To(Equal(now.Add(50 * time.Minute)))
+		requested := metav1.NewTime(now)
+		gs.Status.TerminationRequestedTime = &requested
+		expiry, _ = getGameServerExpiry(gsb, &gs)
+		Expect(expiry).To(Equal(now.Add(ActiveTerminationGracePeriod)))
+
+		gsb.Spec.StandingByTTL = nil
+		gs = newTestGameServer("gs3", mpsv1alpha1.GameServerStateStandingBy, now, 48*time.Hour)
+		_, ok = getGameServerExpiry(gsb, &gs)
+		Expect(ok).To(BeFalse())
+	})
+	It("should recycle the expired game servers", func() {
+		gameServers := []mpsv1alpha1.GameServer{
+			newTestGameServer("standingby-expired", mpsv1alpha1.GameServerStateStandingBy, now, 25*time.Hour),
+			newTestGameServer("standingby", mpsv1alpha1.GameServerStateStandingBy, now, time.Hour),
+			newTestGameServer("active-expired", mpsv1alpha1.GameServerStateActive, now, 2*time.Hour),
+			newTestGameServer("active", mpsv1alpha1.GameServerStateActive, now, 50*time.Minute),
+		}
+		terminating := newTestGameServer("active-terminating", mpsv1alpha1.GameServerStateActive, now, 2*time.Hour)
+		requested := metav1.NewTime(now.Add(-ActiveTerminationGracePeriod))
+		terminating.Status.TerminationRequestedTime = &requested
+		gameServers = append(gameServers, terminating)
+		c := newTestFakeClient(gameServersAsObjects(gameServers)...)
+
+		recorder := record.NewFakeRecorder(10)
+		r := &GameServerBuildReconciler{Client: c, Recorder: recorder}
+		remaining, requeueAfter, err := r.recycleExpiredGameServers(context.Background(), newBuild(), gameServers, now)
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, gs := range remaining {
+			names = append(names, gs.Name)
+		}
+		Expect(names).To(Equal([]string{"standingby", "active-expired", "active"}))
+		Expect(requeueAfter).To(Equal(ActiveTerminationGracePeriod))
+		Expect(<-recorder.Events).To(ContainSubstring("StandingByExpired"))
+		Expect(<-recorder.Events).To(ContainSubstring("TerminationRequested"))
+		Expect(<-recorder.Events).To(ContainSubstring("MaxActiveDurationExceeded"))
+
+		var gs mpsv1alpha1.GameServer
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "active-expired", Namespace: "default"}, &gs)).To(Succeed())
+		Expect(gs.Status.TerminationRequestedTime.Time).To(BeTemporally("==", now))
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "standingby-expired", Namespace: "default"}, &gs)).ToNot(Succeed())
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "active-terminating", Namespace: "default"}, &gs)).ToNot(Succeed())
+		gameServersUnderDeletion.Del("build1")
+	})
+	It("should recycle the expired StandingBy game servers in batches", func() {
+		gameServers := []mpsv1alpha1.GameServer{
+			newTestGameServer("standingby-25h", mpsv1alpha1.GameServerStateStandingBy, now, 25*time.Hour),
+			newTestGameServer("standingby-27h", mpsv1alpha1.GameServerStateStandingBy, now, 27*time.Hour),
+			newTestGameServer("standingby-26h", mpsv1alpha1.GameServerStateStandingBy, now, 26*time.Hour),
+			newTestGameServer("initializing", "", now, 0),
+		}
+		c := newTestFakeClient(gameServersAsObjects(gameServers)...)
+		gsb := newBuild()
+		gsb.Spec.StandingBy = 10
+		r := &GameServerBuildReconciler{Client: c, Recorder: record.NewFakeRecorder(10)}
+
+		// nothing is recycled while the replacements of the previous batch are initializing
+		remaining, requeueAfter, err := r.recycleExpiredGameServers(context.Background(), gsb, gameServers, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remaining).To(HaveLen(4))
+		Expect(requeueAfter).To(Equal(standingByRecycleRetryPeriod))
+
+		// the batch of 10% of the StandingBy servers contains the one that expired first
+		remaining, requeueAfter, err = r.recycleExpiredGameServers(context.Background(), gsb, gameServers[:3], now)
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, gs := range remaining {
+			names = append(names, gs.Name)
+		}
+		Expect(names).To(Equal([]string{"standingby-25h", "standingby-26h"}))
+		Expect(requeueAfter).To(Equal(standingByRecycleRetryPeriod))
+		var gs mpsv1alpha1.GameServer
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "standingby-27h", Namespace: "default"}, &gs)).ToNot(Succeed())
+
+		gameServersUnderDeletion.Del("build1")
+	})
+	It("should return the shorter requeue duration", func() {
+		Expect(minRequeueAfter(0, time.Minute)).To(Equal(time.Minute))
+		Expect(minRequeueAfter(time.Minute, 0)).To(Equal(time.Minute))
+		Expect(minRequeueAfter(time.Hour, time.Minute)).To(Equal(time.Minute))
+	})
+})
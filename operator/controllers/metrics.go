@@ -34,6 +34,20 @@ var (
 		},
 		[]string{"BuildName"},
 	)
+	GameServersRecycledCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gameservers_recycled_total",
+			Help: "Number of GameServers deleted because they exceeded the StandingByTTL or the MaxActiveDuration of their GameServerBuild",
+		},
+		[]string{"BuildName", "Reason"},
+	)
+	GameServersTerminationRequestedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gameservers_termination_requested_total",
+			Help: "Number of Active GameServers asked to terminate because they exceeded the MaxActiveDuration of their GameServerBuild",
+		},
+		[]string{"BuildName"},
+	)
 	InitializingGameServersGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gameservers_initializing_total",
@@ -62,6 +76,27 @@ var (
 		},
 		[]string{"BuildName"},
 	)
+	AllocationsUnfulfilledCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "allocations_unfulfilled_total",
+			Help: "Number of GameServer allocations rejected because there were no StandingBy GameServers",
+		},
+		[]string{"BuildName"},
+	)
+	AllocationsThrottledCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "allocations_throttled_total",
+			Help: "Number of GameServer allocations rejected because of the allocation rate limits or the Active session quotas",
+		},
+		[]string{"BuildName", "TitleID", "Reason"},
+	)
+	ApiServerCertificateExpiryGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "apiserver_certificate_expiry_timestamp_seconds",
+			Help: "Expiry time of the currently loaded API server TLS certificate, in seconds since the Unix epoch",
+		},
+		[]string{"SecretName"},
+	)
 )
 
 func addMetricsToRegistry() {
@@ -70,8 +105,13 @@ func addMetricsToRegistry() {
 		GameServersCrashedCounter,
 		GameServersDeletedCounter,
 		GameServersSessionEndedCounter,
+		GameServersRecycledCounter,
+		GameServersTerminationRequestedCounter,
 		InitializingGameServersGauge,
 		StandingByGameServersGauge,
 		ActiveGameServersGauge,
-		AllocationsCounter)
+		AllocationsCounter,
+		AllocationsThrottledCounter,
+		AllocationsUnfulfilledCounter,
+		ApiServerCertificateExpiryGauge)
 }
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+)
+
+// predictionMovingAverageWindows is the number of complete windows of the moving average of the allocations
+const predictionMovingAverageWindows = 3
+
+// allocationForecast is the forecast of the allocations of the next window
+type allocationForecast struct {
+	// movingAverage is the average of the allocations of the last complete windows
+	movingAverage float64
+	// seasonal is the number of allocations of the window one Seasonality before the next window, -1 if it's not recorded yet
+	seasonal int
+}
+
+// allocations returns the forecast number of allocations of the next window, the larger of the moving average and the seasonal value
+func (f allocationForecast) allocations() int {
+	allocations := int(math.Ceil(f.movingAverage))
+	if f.seasonal > allocations {
+		allocations = f.seasonal
+	}
+	return allocations
+}
+
+// predictStandingBy records the allocations of the GameServerBuild and returns the StandingBy that the controller should maintain
+// without a StandingByPrediction it's spec.StandingBy, otherwise it's the forecast allocations of the next window,
+// between spec.StandingBy and MaxStandingBy
+// the AllocationHistory and the EffectiveStandingBy are set on the status, which is updated later in the reconcile loop
+func (r *GameServerBuildReconciler) predictStandingBy(gsb *mpsv1alpha1.GameServerBuild, gameServers []mpsv1alpha1.GameServer, now time.Time) int {
+	prediction := gsb.Spec.StandingByPrediction
+	if prediction == nil {
+		gsb.Status.AllocationHistory = nil
+		gsb.Status.EffectiveStandingBy = 0
+		return gsb.Spec.StandingBy
+	}
+	window, seasonality := prediction.GetWindow(), prediction.GetSeasonality()
+	gsb.Status.AllocationHistory = recordAllocations(gsb.Status.AllocationHistory, gameServers, now, window, int(seasonality/window))
+	forecast := forecastAllocations(gsb.Status.AllocationHistory, int(seasonality/window))
+
+	effectiveStandingBy := forecast.allocations()
+	if effectiveStandingBy > prediction.MaxStandingBy {
+		effectiveStandingBy = prediction.MaxStandingBy
+	}
+	if effectiveStandingBy < gsb.Spec.StandingBy {
+		effectiveStandingBy = gsb.Spec.StandingBy
+	}
+	if effectiveStandingBy != gsb.Status.EffectiveStandingBy {
+		r.Recorder.Eventf(gsb, corev1.EventTypeNormal, "PredictedStandingBy",
+			"Effective StandingBy changed from %d to %d, %d allocations forecast in the next %s (moving average %.1f, %s one seasonality ago), bounds %d-%d",
+			gsb.Status.EffectiveStandingBy, effectiveStandingBy, forecast.allocations(), window, forecast.movingAverage,
+			seasonalDescription(forecast.seasonal), gsb.Spec.StandingBy, prediction.MaxStandingBy)
+	}
+	gsb.Status.EffectiveStandingBy = effectiveStandingBy
+	return effectiveStandingBy
+}
+
+// getDesiredStandingBy returns the StandingBy that the controller maintains, the EffectiveStandingBy when the prediction is enabled
+func getDesiredStandingBy(gsb *mpsv1alpha1.GameServerBuild) int {
+	if gsb.Spec.StandingByPrediction != nil && gsb.Status.EffectiveStandingBy > gsb.Spec.StandingBy {
+		return gsb.Status.EffectiveStandingBy
+	}
+	return gsb.Spec.StandingBy
+}
+
+// getNextPredictionWindow returns the duration until the next window of the StandingByPrediction starts
+func getNextPredictionWindow(prediction *mpsv1alpha1.StandingByPrediction, now time.Time) time.Duration {
+	window := prediction.GetWindow()
+	return now.Truncate(window).Add(window).Sub(now)
+}
+
+// recordAllocations returns the AllocationHistory with the allocations of the GameServers counted in the current and the previous window
+// the GameServers are deleted when their game sessions end, so the count of a window can only increase
+// the previous window is recounted, since its allocations might have been reconciled after it ended
+// the history keeps the windows of one Seasonality, or the ones of the moving average if there are more, and is reset if the window changes
+func recordAllocations(history *mpsv1alpha1.AllocationHistory, gameServers []mpsv1alpha1.GameServer, now time.Time, window time.Duration, seasonWindows int) *mpsv1alpha1.AllocationHistory {
+	historyWindows := seasonWindows
+	if historyWindows < predictionMovingAverageWindows+1 {
+		historyWindows = predictionMovingAverageWindows + 1
+	}
+	windowStart := now.Truncate(window)
+	if history == nil || len(history.Allocations) == 0 || history.WindowStart.Time.After(windowStart) ||
+		windowStart.Sub(history.WindowStart.Time)%window != 0 {
+		history = &mpsv1alpha1.AllocationHistory{Allocations: []int{0}}
+	} else {
+		history = history.DeepCopy()
+		elapsedWindows := int(windowStart.Sub(history.WindowStart.Time) / window)
+		if elapsedWindows >= historyWindows {
+			history.Allocations = []int{0}
+		} else {
+			for i := 0; i < elapsedWindows; i++ {
+				history.Allocations = append(history.Allocations, 0)
+			}
+		}
+	}
+	history.WindowStart = metav1.NewTime(windowStart)
+
+	last := len(history.Allocations) - 1
+	for i := 0; i < 2 && i <= last; i++ {
+		start := windowStart.Add(-time.Duration(i) * window)
+		count := countAllocations(gameServers, start, start.Add(window))
+		if count > history.Allocations[last-i] {
+			history.Allocations[last-i] = count
+		}
+	}
+	if len(history.Allocations) > historyWindows {
+		history.Allocations = history.Allocations[len(history.Allocations)-historyWindows:]
+	}
+	return history
+}
+
+// countAllocations returns the number of GameServers that were allocated in [start, end)
+func countAllocations(gameServers []mpsv1alpha1.GameServer, start, end time.Time) int {
+	count := 0
+	for _, gs := range gameServers {
+		if t := gs.Status.AllocatedTime; t != nil && !t.Time.Before(start) && t.Time.Before(end) {
+			count++
+		}
+	}
+	return count
+}
+
+// forecastAllocations forecasts the allocations of the next window from the AllocationHistory
+// the last window is the current one, which is not complete, so it's not part of the moving average
+func forecastAllocations(history *mpsv1alpha1.AllocationHistory, seasonWindows int) allocationForecast {
+	forecast := allocationForecast{seasonal: -1}
+	completeWindows := len(history.Allocations) - 1
+	count := predictionMovingAverageWindows
+	if completeWindows < count {
+		count = completeWindows
+	}
+	if count > 0 {
+		sum := 0
+		for _, allocations := range history.Allocations[completeWindows-count : completeWindows] {
+			sum += allocations
+		}
+		forecast.movingAverage = float64(sum) / float64(count)
+	}
+	// the next window starts one window after the current one, so the window one Seasonality before it is seasonWindows-1 windows before the current one
+	if i := len(history.Allocations) - seasonWindows; seasonWindows > 1 && i >= 0 {
+		forecast.seasonal = history.Allocations[i]
+	}
+	return forecast
+}
+
+// seasonalDescription describes the seasonal value of a forecast for the Events
+func seasonalDescription(seasonal int) string {
+	if seasonal < 0 {
+		return "nothing recorded"
+	}
+	return fmt.Sprintf("%d", seasonal)
+}
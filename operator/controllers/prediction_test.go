@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StandingBy prediction tests", func() {
+	window := 5 * time.Minute
+	windowStart := time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)
+	allocatedGameServer := func(allocatedTime time.Time) mpsv1alpha1.GameServer {
+		t := metav1.NewTime(allocatedTime)
+		return mpsv1alpha1.GameServer{Status: mpsv1alpha1.GameServerStatus{State: mpsv1alpha1.GameServerStateActive, AllocatedTime: &t}}
+	}
+
+	It("should record the allocations of the current and the previous window", func() {
+		gameServers := []mpsv1alpha1.GameServer{
+			allocatedGameServer(windowStart.Add(-time.Minute)),
+			allocatedGameServer(windowStart.Add(time.Minute)),
+			allocatedGameServer(windowStart.Add(2 * time.Minute)),
+			{},
+		}
+		history := &mpsv1alpha1.AllocationHistory{WindowStart: metav1.NewTime(windowStart.Add(-window)), Allocations: []int{4, 2}}
+		history = recordAllocations(history, gameServers, windowStart.Add(3*time.Minute), window, 12)
+		Expect(history.WindowStart.Time).To(Equal(windowStart))
+		// the previous window keeps its larger count, since its other GameServers were deleted
+		Expect(history.Allocations).To(Equal([]int{4, 2, 2}))
+
+		history.Allocations[1] = 0
+		history = recordAllocations(history, gameServers, windowStart.Add(3*time.Minute), window, 12)
+		Expect(history.Allocations).To(Equal([]int{4, 1, 2}))
+	})
+	It("should keep the windows of one seasonality", func() {
+		history := &mpsv1alpha1.AllocationHistory{WindowStart: metav1.NewTime(windowStart), Allocations: []int{1, 2, 3, 4, 5, 6}}
+		history = recordAllocations(history, nil, windowStart.Add(2*window), window, 6)
+		Expect(history.Allocations).To(Equal([]int{3, 4, 5, 6, 0, 0}))
+
+		history = recordAllocations(history, nil, windowStart.Add(20*window), window, 6)
+		Expect(history.Allocations).To(Equal([]int{0}))
+	})
+	It("should reset the history when the window changes", func() {
+		history := &mpsv1alpha1.AllocationHistory{WindowStart: metav1.NewTime(windowStart.Add(-time.Minute)), Allocations: []int{1, 2}}
+		history = recordAllocations(history, nil, windowStart, window, 12)
+		Expect(history.WindowStart.Time).To(Equal(windowStart))
+		Expect(history.Allocations).To(Equal([]int{0}))
+	})
+	It("should forecast the allocations from the moving average and the seasonality", func() {
+		history := &mpsv1alpha1.AllocationHistory{Allocations: []int{1, 2, 3, 2, 5}}
+		forecast := forecastAllocations(history, 12)
+		Expect(forecast.movingAverage).To(BeNumerically("~", 7.0/3))
+		Expect(forecast.seasonal).To(Equal(-1))
+		Expect(forecast.allocations()).To(Equal(3))
+
+		// the window one seasonality before the next window is the second one
+		forecast = forecastAllocations(history, 4)
+		Expect(forecast.seasonal).To(Equal(2))
+		history.Allocations[1] = 8
+		Expect(forecastAllocations(history, 4).allocations()).To(Equal(8))
+	})
+	It("should raise the effective StandingBy within its bounds and explain it in an Event", func() {
+		recorder := record.NewFakeRecorder(10)
+		r := &GameServerBuildReconciler{Recorder: recorder}
+		gsb := &mpsv1alpha1.GameServerBuild{
+			Spec: mpsv1alpha1.GameServerBuildSpec{
+				StandingBy:           2,
+				Max:                  20,
+				StandingByPrediction: &mpsv1alpha1.StandingByPrediction{MaxStandingBy: 6},
+			},
+			Status: mpsv1alpha1.GameServerBuildStatus{
+				EffectiveStandingBy: 2,
+				AllocationHistory:   &mpsv1alpha1.AllocationHistory{WindowStart: metav1.NewTime(windowStart), Allocations: []int{5, 4, 3, 0}},
+			},
+		}
+		Expect(r.predictStandingBy(gsb, nil, windowStart.Add(time.Minute))).To(Equal(4))
+		Expect(gsb.Status.EffectiveStandingBy).To(Equal(4))
+		Expect(getDesiredStandingBy(gsb)).To(Equal(4))
+		Expect(<-recorder.Events).To(ContainSubstring("Effective StandingBy changed from 2 to 4"))
+
+		gsb.Status.AllocationHistory.Allocations = []int{9, 9, 9, 0}
+		Expect(r.predictStandingBy(gsb, nil, windowStart.Add(time.Minute))).To(Equal(6))
+
+		gsb.Status.AllocationHistory.Allocations = []int{0, 0, 0, 0}
+		Expect(r.predictStandingBy(gsb, nil, windowStart.Add(time.Minute))).To(Equal(2))
+
+		gsb.Spec.StandingByPrediction = nil
+		Expect(r.predictStandingBy(gsb, nil, windowStart.Add(time.Minute))).To(Equal(2))
+		Expect(gsb.Status.AllocationHistory).To(BeNil())
+		Expect(gsb.Status.EffectiveStandingBy).To(BeZero())
+	})
+	It("should requeue at the start of the next window", func() {
+		prediction := &mpsv1alpha1.StandingByPrediction{MaxStandingBy: 1}
+		Expect(getNextPredictionWindow(prediction, windowStart.Add(time.Minute))).To(Equal(4 * time.Minute))
+	})
+	It("should update the status when only the prediction changed", func() {
+		gsb := &mpsv1alpha1.GameServerBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: "build1", Namespace: "default"},
+			Spec: mpsv1alpha1.GameServerBuildSpec{StandingBy: 2, Max: 10, CrashesToMarkUnhealthy: 5,
+				StandingByPrediction: &mpsv1alpha1.StandingByPrediction{MaxStandingBy: 6}},
+		}
+		c := newTestFakeClient(gsb)
+		r := &GameServerBuildReconciler{Client: c, Recorder: record.NewFakeRecorder(10)}
+		_, err := r.updateStatus(context.Background(), gsb, gsb.Status.DeepCopy(), 0, 2, 0, 0, 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		// like in Reconcile, the prediction changes the status before updateStatus, with the same counts
+		fetchedStatus := gsb.Status.DeepCopy()
+		r.predictStandingBy(gsb, []mpsv1alpha1.GameServer{allocatedGameServer(windowStart)}, windowStart.Add(time.Minute))
+		_, err = r.updateStatus(context.Background(), gsb, fetchedStatus, 0, 2, 0, 0, 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		var updated mpsv1alpha1.GameServerBuild
+		Expect(c.Get(context.Background(), types.NamespacedName{Name: "build1", Namespace: "default"}, &updated)).To(Succeed())
+		Expect(updated.Status.AllocationHistory).ToNot(BeNil())
+		Expect(updated.Status.AllocationHistory.Allocations).To(Equal([]int{1}))
+	})
+})
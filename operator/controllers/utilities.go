@@ -109,6 +109,46 @@ func GetPublicIPForNode(ctx context.Context, r client.Reader, nodeName string) (
 	return "", fmt.Errorf("node %s does not have a Public or Internal IP", nodeName)
 }
 
+// IsNodeDrained returns true if no new game servers should run on the Node, since it's cordoned
+// or it has a NoSchedule or NoExecute taint that the Pods of the game servers do not tolerate
+func IsNodeDrained(node *corev1.Node, tolerations []corev1.Toleration) bool {
+	if node.Spec.Unschedulable {
+		return true
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return true
+		}
+	}
+	return false
+}
+
+// getNextScheduledMaintenance returns the time of the NextScheduledMaintenanceAnnotation of the Node
+// it returns nil if the annotation is not set or is not a valid RFC3339 time
+func getNextScheduledMaintenance(node *corev1.Node) *metav1.Time {
+	value, ok := node.Annotations[mpsv1alpha1.NextScheduledMaintenanceAnnotation]
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	maintenance := metav1.NewTime(t.UTC())
+	return &maintenance
+}
+
 // NewGameServerForGameServerBuild creates a GameServer for a GameServerBuild
 func NewGameServerForGameServerBuild(gsb *mpsv1alpha1.GameServerBuild, portRegistry *PortRegistry) (*mpsv1alpha1.GameServer, error) {
 	gs := &mpsv1alpha1.GameServer{
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -224,6 +229,28 @@ var _ = Describe("Utilities tests", func() {
 			Expect(s).To(HavePrefix(prefix))
 			Expect(len(s)).To(BeNumerically(">", len(prefix)))
 		})
+		It("should consider cordoned nodes and nodes with taints that are not tolerated drained", func() {
+			node := &corev1.Node{}
+			Expect(IsNodeDrained(node, nil)).To(BeFalse())
+			node.Spec.Unschedulable = true
+			Expect(IsNodeDrained(node, nil)).To(BeTrue())
+
+			node.Spec.Unschedulable = false
+			node.Spec.Taints = []corev1.Taint{{Key: "maintenance", Effect: corev1.TaintEffectPreferNoSchedule}}
+			Expect(IsNodeDrained(node, nil)).To(BeFalse())
+			node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{Key: "dedicated", Value: "gameservers", Effect: corev1.TaintEffectNoSchedule})
+			Expect(IsNodeDrained(node, nil)).To(BeTrue())
+			tolerations := []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gameservers", Effect: corev1.TaintEffectNoSchedule}}
+			Expect(IsNodeDrained(node, tolerations)).To(BeFalse())
+		})
+		It("should get the next scheduled maintenance of a node", func() {
+			node := &corev1.Node{}
+			Expect(getNextScheduledMaintenance(node)).To(BeNil())
+			node.Annotations = map[string]string{mpsv1alpha1.NextScheduledMaintenanceAnnotation: "tomorrow"}
+			Expect(getNextScheduledMaintenance(node)).To(BeNil())
+			node.Annotations[mpsv1alpha1.NextScheduledMaintenanceAnnotation] = "2021-08-01T12:00:00+02:00"
+			Expect(getNextScheduledMaintenance(node).Time).To(Equal(time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC)))
+		})
 	})
 })
 
@@ -235,3 +262,42 @@ func checkEnvTestHelper(envs []corev1.EnvVar, env corev1.EnvVar) bool {
 	}
 	return false
 }
+
+// newTestGameServer returns a GameServer in the given state, that was created two days before now
+// since is how long before now it became StandingBy or Active, or its process exited
+func newTestGameServer(name string, state mpsv1alpha1.GameServerState, now time.Time, since time.Duration) mpsv1alpha1.GameServer {
+	t := metav1.NewTime(now.Add(-since))
+	gs := mpsv1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour))},
+		Status:     mpsv1alpha1.GameServerStatus{State: state},
+	}
+	switch state {
+	case mpsv1alpha1.GameServerStateStandingBy:
+		gs.Status.StandingByTime = &t
+	case mpsv1alpha1.GameServerStateActive:
+		gs.Status.AllocatedTime = &t
+	case mpsv1alpha1.GameServerStateCrashed, mpsv1alpha1.GameServerStateGameCompleted:
+		gs.Status.CompletionTime = &t
+	}
+	return gs
+}
+
+// newTestFakeClient returns a fake client that contains the given objects
+// they are created in place, so that they get the ResourceVersion that the fake client requires for updates
+func newTestFakeClient(objects ...client.Object) client.Client {
+	Expect(mpsv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+	c := fake.NewClientBuilder().Build()
+	for _, o := range objects {
+		Expect(c.Create(context.Background(), o)).To(Succeed())
+	}
+	return c
+}
+
+// gameServersAsObjects returns pointers to the game servers of the slice, so that they can be passed to newTestFakeClient
+func gameServersAsObjects(gameServers []mpsv1alpha1.GameServer) []client.Object {
+	objects := make([]client.Object, 0, len(gameServers))
+	for i := range gameServers {
+		objects = append(objects, &gameServers[i])
+	}
+	return objects
+}
@@ -1,16 +1,17 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"math/rand"
-
 	"fmt"
 	"net/http"
+	"time"
 
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
 	"github.com/playfab/thundernetes/operator/controllers"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,10 +20,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// maxBatchAllocateSize is the maximum number of allocations in a single batch allocation call
+const maxBatchAllocateSize = 100
+
 type allocateHandler struct {
-	client client.Client
-	config *rest.Config
-	scheme *runtime.Scheme
+	client  client.Client
+	config  *rest.Config
+	scheme  *runtime.Scheme
+	limiter *allocationLimiter
 }
 
 func (h *allocateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -34,6 +39,7 @@ func (h *allocateHandler) handle(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodPost && r.Method != http.MethodPatch {
 		badRequestError(ctx, w, errors.New("invalid method"), "Only POST and PATCH are accepted")
+		return
 	}
 
 	// Parse args.
@@ -44,99 +50,168 @@ func (h *allocateHandler) handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// validate args
-	isValid := validateAllocateArgs(&args)
-	if !isValid {
-		badRequestError(ctx, w, errors.New("invalid sessionID or buildID"), "invalid arguments")
+	rs, err := allocate(ctx, h.client, h.limiter, &args)
+	if err != nil {
+		writeError(ctx, w, err)
 		return
 	}
 
+	writeJSON(ctx, w, http.StatusOK, rs)
+}
+
+// allocate allocates a StandingBy GameServer of the requested build for the requested session
+// if the session is already allocated, the GameServer that hosts it is returned
+// if limiter is not nil, new allocations are subject to the rate limits and the Active session quotas of the TitleID and the BuildID
+// it is used by both the REST and the gRPC API
+func allocate(ctx context.Context, c client.Client, limiter *allocationLimiter, args *AllocateArgs) (*RequestMultiplayerServerResponse, error) {
+	// validate args
+	if details := validateAllocateArgs(args); len(details) > 0 {
+		return nil, newValidationError(details)
+	}
+
 	// check if this build exists
 	var gameServerBuilds mpsv1alpha1.GameServerBuildList
-	err = h.client.List(ctx, &gameServerBuilds, client.MatchingFields{"spec.buildID": args.BuildID})
+	err := c.List(ctx, &gameServerBuilds, client.MatchingFields{"spec.buildID": args.BuildID})
 	if err != nil {
 		if kerrors.IsNotFound(err) {
-			notFoundError(ctx, w, err, "not found")
-			return
-		} else {
-			internalServerError(ctx, w, err, "error listing")
-			return
+			return nil, newApiError(http.StatusNotFound, err, "not found")
 		}
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
 	}
 	if len(gameServerBuilds.Items) == 0 {
-		notFoundError(ctx, w, errors.New("build not found"), fmt.Sprintf("Build with ID %s not found", args.BuildID))
-		return
+		return nil, newApiError(http.StatusNotFound, errors.New("build not found"), fmt.Sprintf("Build with ID %s not found", args.BuildID))
+	}
+	if err := authorize(ctx, gameServerBuilds.Items[0].Spec.TitleID, args.BuildID); err != nil {
+		return nil, err
 	}
 
 	// check if this server is already allocated
 	var gameserversForSessionID mpsv1alpha1.GameServerList
-	err = h.client.List(r.Context(), &gameserversForSessionID, &client.ListOptions{
+	err = c.List(ctx, &gameserversForSessionID, &client.ListOptions{
 		FieldSelector: fields.SelectorFromSet(fields.Set{"status.sessionID": args.SessionID}),
 		LabelSelector: labels.SelectorFromSet(labels.Set{controllers.LabelBuildID: args.BuildID}),
 	})
 	if err != nil {
-		internalServerError(ctx, w, err, "error listing")
-		return
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
 	}
 
 	// this should never happen, but just in case
 	if len(gameserversForSessionID.Items) > 1 {
-		internalServerError(ctx, w, errors.New("multiple servers found"), fmt.Sprintf("Multiple servers found for sessionID %s", args.SessionID))
-		return
+		return nil, newApiError(http.StatusInternalServerError, errors.New("multiple servers found"), fmt.Sprintf("Multiple servers found for sessionID %s", args.SessionID))
 	}
 
 	if len(gameserversForSessionID.Items) == 1 {
 		// return it
 		gs := gameserversForSessionID.Items[0]
-		rs := RequestMultiplayerServerResponse{
+		return &RequestMultiplayerServerResponse{
 			IPV4Address: gs.Status.PublicIP,
 			Ports:       gs.Status.Ports,
 			SessionID:   args.SessionID,
+		}, nil
+	}
+
+	// a paused build keeps its StandingBy servers, but they are not allocated for new sessions
+	if gameServerBuilds.Items[0].Spec.Paused {
+		return nil, newApiError(http.StatusConflict, errors.New("build is paused"), fmt.Sprintf("Build with ID %s is paused", args.BuildID))
+	}
+
+	// a deleted build waits for its Active servers to be drained, new allocations would keep it from being deleted
+	if !gameServerBuilds.Items[0].DeletionTimestamp.IsZero() {
+		return nil, newApiError(http.StatusConflict, errors.New("build is being deleted"), fmt.Sprintf("Build with ID %s is being deleted", args.BuildID))
+	}
+
+	// retries of an allocation that already succeeded are not limited
+	// the tokens are returned if no GameServer is allocated
+	var reservation *allocationReservation
+	if limiter != nil {
+		if reservation, err = limiter.allow(ctx, c, &gameServerBuilds.Items[0]); err != nil {
+			return nil, err
 		}
-		json.NewEncoder(w).Encode(rs)
-		return
 	}
+	allocated := false
+	defer func() {
+		if !allocated {
+			reservation.cancel()
+		}
+	}()
 
 	// get the standingBy GameServers for this BuildID
 	var gameserversStandingBy mpsv1alpha1.GameServerList
-	err = h.client.List(r.Context(), &gameserversStandingBy, &client.ListOptions{
+	err = c.List(ctx, &gameserversStandingBy, &client.ListOptions{
 		FieldSelector: fields.SelectorFromSet(fields.Set{"status.state": "StandingBy"}),
 		LabelSelector: labels.SelectorFromSet(labels.Set{controllers.LabelBuildID: args.BuildID}),
 	})
 	if err != nil {
-		internalServerError(ctx, w, err, "error listing")
-		return
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
 	}
 
-	if len(gameserversStandingBy.Items) == 0 {
-		tooManyRequestsError(ctx, w, fmt.Errorf("not enough standingBy"), "there are not enough standingBy servers")
-		return
+	// the GameServers on cordoned or tainted Nodes are not allocated, since these Nodes are about to be drained
+	standingBy, err := filterGameServersOnDrainedNodes(ctx, c, gameserversStandingBy.Items)
+	if err != nil {
+		return nil, newApiError(http.StatusInternalServerError, err, "error getting nodes")
+	}
+
+	if len(standingBy) == 0 {
+		// the controller creates new StandingBy servers to replace the allocated ones
+		controllers.AllocationsUnfulfilledCounter.WithLabelValues(gameServerBuilds.Items[0].Name).Inc()
+		ae := newApiError(http.StatusTooManyRequests, fmt.Errorf("not enough standingBy"), "there are not enough standingBy servers")
+		ae.retryAfter = time.Second
+		return nil, ae
 	}
 
-	// pick a random one
-	gs := gameserversStandingBy.Items[rand.Intn(len(gameserversStandingBy.Items))]
+	selected, err := selectGameServer(ctx, c, gameServerBuilds.Items[0].Spec.AllocationStrategy, standingBy)
+	if err != nil {
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
+	}
+	gs := *selected
 
 	// set the relevant status fields
 	gs.Status.State = mpsv1alpha1.GameServerStateActive
 	gs.Status.SessionID = args.SessionID
 	gs.Status.SessionCookie = args.SessionCookie
 	gs.Status.InitialPlayers = args.InitialPlayers
+	gs.Status.PlayerHistory = args.InitialPlayers
+	allocatedTime := metav1.Now()
+	gs.Status.AllocatedTime = &allocatedTime
 
-	err = h.client.Status().Update(r.Context(), &gs)
+	err = c.Status().Update(ctx, &gs)
 	if err != nil {
-		internalServerError(ctx, w, err, "cannot update game server")
-		return
+		return nil, newApiError(http.StatusInternalServerError, err, "cannot update game server")
 	}
 
-	rs := RequestMultiplayerServerResponse{
+	allocated = true
+	controllers.AllocationsCounter.WithLabelValues(gs.Labels[controllers.LabelBuildName]).Inc()
+
+	return &RequestMultiplayerServerResponse{
 		IPV4Address: gs.Status.PublicIP,
 		Ports:       gs.Status.Ports,
 		SessionID:   args.SessionID,
+	}, nil
+}
+
+// allocateBatch allocates a GameServer for each of the args
+// the allocations are independent of each other, so for every one of the args
+// either the response or the error is set at the same index of the returned slices
+// the returned error is set only if the batch itself is invalid
+func allocateBatch(ctx context.Context, c client.Client, limiter *allocationLimiter, args []AllocateArgs) ([]*RequestMultiplayerServerResponse, []error, error) {
+	if len(args) == 0 || len(args) > maxBatchAllocateSize {
+		return nil, nil, newApiError(http.StatusBadRequest, fmt.Errorf("batch contains %d allocations", len(args)), fmt.Sprintf("a batch must contain between 1 and %d allocations", maxBatchAllocateSize))
 	}
-	err = json.NewEncoder(w).Encode(rs)
-	if err != nil {
-		internalServerError(ctx, w, err, "encode json response")
-		return
+
+	// the cache might not contain an allocation that was just made,
+	// so the same session in a batch could be allocated twice
+	sessionIDs := make(map[string]struct{}, len(args))
+	for _, a := range args {
+		if _, ok := sessionIDs[a.SessionID]; ok {
+			return nil, nil, newApiError(http.StatusBadRequest, fmt.Errorf("duplicate sessionID %s", a.SessionID), "invalid arguments")
+		}
+		sessionIDs[a.SessionID] = struct{}{}
 	}
-	controllers.AllocationsCounter.WithLabelValues(gs.Labels[controllers.LabelBuildName]).Inc()
+
+	responses := make([]*RequestMultiplayerServerResponse, len(args))
+	errs := make([]error, len(args))
+	for i := 0; i < len(args); i++ {
+		responses[i], errs[i] = allocate(ctx, c, limiter, &args[i])
+	}
+	return responses, errs, nil
 }
@@ -26,6 +26,7 @@ var (
 	buildName1 string = "testBuild"
 	buildID1   string = "acb84898-cf73-46e2-8057-314ac557d85d"
 	sessionID1 string = "d5f075a4-517b-4bf4-8123-dfa0021aa169"
+	sessionID2 string = "4a9d4ea3-6c10-4f1e-9b1f-2f0f4c1c6a57"
 	gsName     string = "testgs"
 )
 
@@ -192,6 +193,9 @@ func createTestGameServerAndBuild(client client.Client, gameServerName, buildNam
 				controllers.LabelBuildName: buildName,
 			},
 		},
+		Spec: mpsv1alpha1.GameServerSpec{
+			BuildID: buildID,
+		},
 		Status: mpsv1alpha1.GameServerStatus{
 			SessionID: sessionID,
 			State:     state,
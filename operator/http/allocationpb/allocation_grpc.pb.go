@@ -0,0 +1,221 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package allocationpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AllocationServiceClient is the client API for AllocationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AllocationServiceClient interface {
+	// Allocate allocates a StandingBy GameServer of the given build for the session.
+	// If the session is already allocated, the existing GameServer is returned.
+	Allocate(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (*AllocateResponse, error)
+	// BatchAllocate allocates a GameServer for each of the requests.
+	// Every allocation succeeds or fails on its own, the results are returned in the order of the requests.
+	BatchAllocate(ctx context.Context, in *BatchAllocateRequest, opts ...grpc.CallOption) (*BatchAllocateResponse, error)
+	// GetSession returns the details of the GameServer that hosts the session.
+	GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*GameServerDetails, error)
+	// Terminate deletes the GameServer that hosts the session.
+	Terminate(ctx context.Context, in *TerminateRequest, opts ...grpc.CallOption) (*TerminateResponse, error)
+}
+
+type allocationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAllocationServiceClient(cc grpc.ClientConnInterface) AllocationServiceClient {
+	return &allocationServiceClient{cc}
+}
+
+func (c *allocationServiceClient) Allocate(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (*AllocateResponse, error) {
+	out := new(AllocateResponse)
+	err := c.cc.Invoke(ctx, "/thundernetes.allocation.v1.AllocationService/Allocate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *allocationServiceClient) BatchAllocate(ctx context.Context, in *BatchAllocateRequest, opts ...grpc.CallOption) (*BatchAllocateResponse, error) {
+	out := new(BatchAllocateResponse)
+	err := c.cc.Invoke(ctx, "/thundernetes.allocation.v1.AllocationService/BatchAllocate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *allocationServiceClient) GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*GameServerDetails, error) {
+	out := new(GameServerDetails)
+	err := c.cc.Invoke(ctx, "/thundernetes.allocation.v1.AllocationService/GetSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *allocationServiceClient) Terminate(ctx context.Context, in *TerminateRequest, opts ...grpc.CallOption) (*TerminateResponse, error) {
+	out := new(TerminateResponse)
+	err := c.cc.Invoke(ctx, "/thundernetes.allocation.v1.AllocationService/Terminate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AllocationServiceServer is the server API for AllocationService service.
+// All implementations must embed UnimplementedAllocationServiceServer
+// for forward compatibility
+type AllocationServiceServer interface {
+	// Allocate allocates a StandingBy GameServer of the given build for the session.
+	// If the session is already allocated, the existing GameServer is returned.
+	Allocate(context.Context, *AllocateRequest) (*AllocateResponse, error)
+	// BatchAllocate allocates a GameServer for each of the requests.
+	// Every allocation succeeds or fails on its own, the results are returned in the order of the requests.
+	BatchAllocate(context.Context, *BatchAllocateRequest) (*BatchAllocateResponse, error)
+	// GetSession returns the details of the GameServer that hosts the session.
+	GetSession(context.Context, *GetSessionRequest) (*GameServerDetails, error)
+	// Terminate deletes the GameServer that hosts the session.
+	Terminate(context.Context, *TerminateRequest) (*TerminateResponse, error)
+	mustEmbedUnimplementedAllocationServiceServer()
+}
+
+// UnimplementedAllocationServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAllocationServiceServer struct {
+}
+
+func (UnimplementedAllocationServiceServer) Allocate(context.Context, *AllocateRequest) (*AllocateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Allocate not implemented")
+}
+func (UnimplementedAllocationServiceServer) BatchAllocate(context.Context, *BatchAllocateRequest) (*BatchAllocateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchAllocate not implemented")
+}
+func (UnimplementedAllocationServiceServer) GetSession(context.Context, *GetSessionRequest) (*GameServerDetails, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSession not implemented")
+}
+func (UnimplementedAllocationServiceServer) Terminate(context.Context, *TerminateRequest) (*TerminateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Terminate not implemented")
+}
+func (UnimplementedAllocationServiceServer) mustEmbedUnimplementedAllocationServiceServer() {}
+
+// UnsafeAllocationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AllocationServiceServer will
+// result in compilation errors.
+type UnsafeAllocationServiceServer interface {
+	mustEmbedUnimplementedAllocationServiceServer()
+}
+
+func RegisterAllocationServiceServer(s grpc.ServiceRegistrar, srv AllocationServiceServer) {
+	s.RegisterService(&AllocationService_ServiceDesc, srv)
+}
+
+func _AllocationService_Allocate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AllocationServiceServer).Allocate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/thundernetes.allocation.v1.AllocationService/Allocate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AllocationServiceServer).Allocate(ctx, req.(*AllocateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AllocationService_BatchAllocate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchAllocateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AllocationServiceServer).BatchAllocate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/thundernetes.allocation.v1.AllocationService/BatchAllocate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AllocationServiceServer).BatchAllocate(ctx, req.(*BatchAllocateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AllocationService_GetSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AllocationServiceServer).GetSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/thundernetes.allocation.v1.AllocationService/GetSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AllocationServiceServer).GetSession(ctx, req.(*GetSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AllocationService_Terminate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TerminateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AllocationServiceServer).Terminate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/thundernetes.allocation.v1.AllocationService/Terminate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AllocationServiceServer).Terminate(ctx, req.(*TerminateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AllocationService_ServiceDesc is the grpc.ServiceDesc for AllocationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AllocationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "thundernetes.allocation.v1.AllocationService",
+	HandlerType: (*AllocationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Allocate",
+			Handler:    _AllocationService_Allocate_Handler,
+		},
+		{
+			MethodName: "BatchAllocate",
+			Handler:    _AllocationService_BatchAllocate_Handler,
+		},
+		{
+			MethodName: "GetSession",
+			Handler:    _AllocationService_GetSession_Handler,
+		},
+		{
+			MethodName: "Terminate",
+			Handler:    _AllocationService_Terminate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "http/allocationpb/allocation.proto",
+}
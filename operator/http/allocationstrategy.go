@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"math/rand"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/playfab/thundernetes/operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// selectGameServer returns the StandingBy GameServer to allocate, according to the allocation strategy of the GameServerBuild
+// Random is the default strategy
+func selectGameServer(ctx context.Context, c client.Client, strategy mpsv1alpha1.AllocationStrategy, gameServers []mpsv1alpha1.GameServer) (*mpsv1alpha1.GameServer, error) {
+	if strategy != mpsv1alpha1.AllocationPacked && strategy != mpsv1alpha1.AllocationDistributed {
+		return &gameServers[rand.Intn(len(gameServers))], nil
+	}
+	activePerNode, err := getActiveGameServersPerNode(ctx, c, gameServers)
+	if err != nil {
+		return nil, err
+	}
+	return selectGameServerByNode(gameServers, activePerNode, strategy == mpsv1alpha1.AllocationPacked), nil
+}
+
+// filterGameServersOnDrainedNodes returns the GameServers that are not on cordoned or tainted Nodes
+// the GameServer controller deletes the StandingBy GameServers on these Nodes, but the cache might not be updated yet
+func filterGameServersOnDrainedNodes(ctx context.Context, c client.Client, gameServers []mpsv1alpha1.GameServer) ([]mpsv1alpha1.GameServer, error) {
+	nodes := make(map[string]*corev1.Node)
+	filtered := make([]mpsv1alpha1.GameServer, 0, len(gameServers))
+	for _, gs := range gameServers {
+		nodeName := gs.Status.NodeName
+		node, ok := nodes[nodeName]
+		if !ok && nodeName != "" {
+			node = &corev1.Node{}
+			if err := c.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return nil, err
+				}
+				node = nil
+			}
+			nodes[nodeName] = node
+		}
+		if node != nil && controllers.IsNodeDrained(node, gs.Spec.PodSpec.Tolerations) {
+			continue
+		}
+		filtered = append(filtered, gs)
+	}
+	return filtered, nil
+}
+
+// getActiveGameServersPerNode returns the number of Active GameServers of all the GameServerBuilds on the Nodes of the GameServers
+// the GameServers are indexed by their Node by the GameServer controller
+func getActiveGameServersPerNode(ctx context.Context, c client.Client, gameServers []mpsv1alpha1.GameServer) (map[string]int, error) {
+	activePerNode := make(map[string]int)
+	for _, gs := range gameServers {
+		nodeName := gs.Status.NodeName
+		if _, ok := activePerNode[nodeName]; ok || nodeName == "" {
+			continue
+		}
+		var gameServersOnNode mpsv1alpha1.GameServerList
+		if err := c.List(ctx, &gameServersOnNode, client.MatchingFields{controllers.NodeNameIndexField: nodeName}); err != nil {
+			return nil, err
+		}
+		activePerNode[nodeName] = 0
+		for _, gsOnNode := range gameServersOnNode.Items {
+			if gsOnNode.Status.NodeName == nodeName && gsOnNode.Status.State == mpsv1alpha1.GameServerStateActive {
+				activePerNode[nodeName]++
+			}
+		}
+	}
+	return activePerNode, nil
+}
+
+// selectGameServerByNode returns a random GameServer among the ones on the Nodes with the most Active GameServers if packed is true,
+// or on the Nodes with the fewest Active GameServers otherwise
+func selectGameServerByNode(gameServers []mpsv1alpha1.GameServer, activePerNode map[string]int, packed bool) *mpsv1alpha1.GameServer {
+	var candidates []int
+	bestCount := 0
+	for i, gs := range gameServers {
+		count := activePerNode[gs.Status.NodeName]
+		if len(candidates) == 0 || (packed && count > bestCount) || (!packed && count < bestCount) {
+			candidates = []int{i}
+			bestCount = count
+		} else if count == bestCount {
+			candidates = append(candidates, i)
+		}
+	}
+	return &gameServers[candidates[rand.Intn(len(candidates))]]
+}
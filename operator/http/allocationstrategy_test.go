@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("allocation strategy tests", func() {
+	newGameServer := func(name, nodeName string, state mpsv1alpha1.GameServerState) mpsv1alpha1.GameServer {
+		return mpsv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     mpsv1alpha1.GameServerStatus{NodeName: nodeName, State: state},
+		}
+	}
+	standingBy := func() []mpsv1alpha1.GameServer {
+		return []mpsv1alpha1.GameServer{
+			newGameServer("gs1", "node1", mpsv1alpha1.GameServerStateStandingBy),
+			newGameServer("gs2", "node2", mpsv1alpha1.GameServerStateStandingBy),
+			newGameServer("gs3", "node3", mpsv1alpha1.GameServerStateStandingBy),
+		}
+	}
+	newTestClientWithActiveGameServers := func() client.Client {
+		c := newTestSimpleK8s()
+		for _, gs := range append(standingBy(),
+			newGameServer("active1", "node1", mpsv1alpha1.GameServerStateActive),
+			newGameServer("active2", "node2", mpsv1alpha1.GameServerStateActive),
+			newGameServer("active3", "node2", mpsv1alpha1.GameServerStateActive),
+			newGameServer("active4", "node4", mpsv1alpha1.GameServerStateActive),
+		) {
+			gs := gs
+			Expect(c.Create(context.Background(), &gs)).To(Succeed())
+		}
+		return c
+	}
+
+	It("should count the Active game servers on the nodes of the StandingBy game servers", func() {
+		activePerNode, err := getActiveGameServersPerNode(context.Background(), newTestClientWithActiveGameServers(), standingBy())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(activePerNode).To(Equal(map[string]int{"node1": 1, "node2": 2, "node3": 0}))
+	})
+	It("should allocate on the busiest node with the Packed strategy", func() {
+		gs, err := selectGameServer(context.Background(), newTestClientWithActiveGameServers(), mpsv1alpha1.AllocationPacked, standingBy())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gs.Name).To(Equal("gs2"))
+	})
+	It("should allocate on the least busy node with the Distributed strategy", func() {
+		gs, err := selectGameServer(context.Background(), newTestClientWithActiveGameServers(), mpsv1alpha1.AllocationDistributed, standingBy())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gs.Name).To(Equal("gs3"))
+	})
+	It("should not allocate the game servers on drained nodes", func() {
+		c := newTestSimpleK8s()
+		Expect(c.Create(context.Background(), &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Spec: corev1.NodeSpec{Unschedulable: true}})).To(Succeed())
+		Expect(c.Create(context.Background(), &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}})).To(Succeed())
+		// node3 is not found, its game servers can still be allocated
+		gameServers, err := filterGameServersOnDrainedNodes(context.Background(), c, standingBy())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gameServers).To(HaveLen(2))
+		Expect(gameServers[0].Name).To(Equal("gs2"))
+		Expect(gameServers[1].Name).To(Equal("gs3"))
+	})
+	It("should allocate any of the game servers on equally busy nodes", func() {
+		gameServers := standingBy()
+		activePerNode := map[string]int{"node1": 2, "node2": 2, "node3": 1}
+		selected := map[string]bool{}
+		for i := 0; i < 100; i++ {
+			selected[selectGameServerByNode(gameServers, activePerNode, true).Name] = true
+		}
+		Expect(selected).To(Equal(map[string]bool{"gs1": true, "gs2": true}))
+	})
+})
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// apiKeyLabel is the label of the Secrets that contain the API keys
+	apiKeyLabel            = "mps.playfab.com/apikey"
+	apiKeyFileName         = "apiKey"
+	apiKeyTitleIDsFileName = "titleIDs"
+	apiKeyBuildIDsFileName = "buildIDs"
+)
+
+// apiKey is the hash of an API key and the caller it belongs to
+type apiKey struct {
+	hash   [sha256.Size]byte
+	caller *caller
+}
+
+// apiKeyStore holds the API keys of the Secrets with the apiKeyLabel, by Secret name
+// the name of the Secret is used as the name of the caller
+type apiKeyStore struct {
+	mux  sync.RWMutex
+	keys map[string]apiKey
+}
+
+// newApiKeyStore creates a new, empty apiKeyStore
+func newApiKeyStore() *apiKeyStore {
+	return &apiKeyStore{keys: make(map[string]apiKey)}
+}
+
+// setSecret adds or updates the API key of a Secret
+// Secrets without an API key are ignored
+func (s *apiKeyStore) setSecret(secret *corev1.Secret) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	key := secret.Data[apiKeyFileName]
+	if len(key) == 0 {
+		delete(s.keys, secret.Name)
+		return
+	}
+	s.keys[secret.Name] = apiKey{
+		hash: sha256.Sum256(key),
+		caller: &caller{
+			name:     secret.Name,
+			titleIDs: splitIDs(string(secret.Data[apiKeyTitleIDsFileName])),
+			buildIDs: splitIDs(string(secret.Data[apiKeyBuildIDsFileName])),
+		},
+	}
+}
+
+// deleteSecret removes the API key of a Secret
+func (s *apiKeyStore) deleteSecret(name string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.keys, name)
+}
+
+// getCaller returns the caller that the token belongs to, or nil if it's not a valid API key
+func (s *apiKeyStore) getCaller(token string) *caller {
+	hash := sha256.Sum256([]byte(token))
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	// compare the hashes in constant time, so the keys cannot be guessed by timing the responses
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash[:]) == 1 {
+			return k.caller
+		}
+	}
+	return nil
+}
+
+// watch watches the API key Secrets in the namespace until the context is done
+// it returns after the current Secrets have been loaded
+func (s *apiKeyStore) watch(ctx context.Context, config *rest.Config, namespace string) error {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	labelSelector := labels.SelectorFromSet(labels.Set{apiKeyLabel: "true"}).String()
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = labelSelector
+		}))
+	informer := factory.Core().V1().Secrets().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			s.setSecret(obj.(*corev1.Secret))
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			s.setSecret(newObj.(*corev1.Secret))
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if secret, ok := obj.(*corev1.Secret); ok {
+				s.deleteSecret(secret.Name)
+			}
+		},
+	})
+	factory.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return errors.New("unable to sync the API key Secrets")
+	}
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	log.FromContext(ctx).Info("loaded API keys", "count", len(s.keys))
+	return nil
+}
+
+// splitIDs returns the non-empty, trimmed values of a comma separated list of IDs
+func splitIDs(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// TokenAuthOptions configures the authentication of the API server callers with bearer tokens
+// if neither API keys nor a JWKS file are configured, token authentication is disabled
+type TokenAuthOptions struct {
+	// Namespace is the namespace of the API key Secrets
+	Namespace string
+	// APIKeys enables authentication with the static API keys that are stored in Secrets
+	// with the mps.playfab.com/apikey=true label
+	APIKeys bool
+	// JWKSFile is the path to a JSON Web Key Set file
+	// if it's set, JWTs that are signed with one of its keys are accepted
+	JWKSFile string
+	// Issuer is the required "iss" claim of the JWTs, it's not checked if it's empty
+	Issuer string
+	// Audience is the required "aud" claim of the JWTs, it's not checked if it's empty
+	Audience string
+}
+
+// caller is an authenticated caller of the API
+// it's allowed to use the GameServerBuilds whose TitleID and BuildID are in its lists, an empty list allows all values
+type caller struct {
+	name     string
+	titleIDs []string
+	buildIDs []string
+}
+
+type callerContextKey struct{}
+
+// isAllowed returns true if the caller is allowed to use the GameServerBuild with the given TitleID and BuildID
+func (c *caller) isAllowed(titleID, buildID string) bool {
+	return containsOrEmpty(c.titleIDs, titleID) && containsOrEmpty(c.buildIDs, buildID)
+}
+
+// containsOrEmpty returns true if the list is empty or contains the value
+func containsOrEmpty(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// callerFromContext returns the authenticated caller of the request, or nil if token authentication is disabled
+func callerFromContext(ctx context.Context) *caller {
+	c, _ := ctx.Value(callerContextKey{}).(*caller)
+	return c
+}
+
+// isAllowedForContext returns true if the caller of the request is allowed to use the GameServerBuild with the given TitleID and BuildID
+// all GameServerBuilds are allowed if token authentication is disabled
+func isAllowedForContext(ctx context.Context, titleID, buildID string) bool {
+	c := callerFromContext(ctx)
+	return c == nil || c.isAllowed(titleID, buildID)
+}
+
+// authorize returns an error if the caller of the request is not allowed to use the GameServerBuild with the given TitleID and BuildID
+func authorize(ctx context.Context, titleID, buildID string) error {
+	if isAllowedForContext(ctx, titleID, buildID) {
+		return nil
+	}
+	return newApiError(http.StatusForbidden, fmt.Errorf("caller %s is not allowed to use TitleID %s and BuildID %s", callerFromContext(ctx).name, titleID, buildID), "forbidden")
+}
+
+// tokenAuthenticator authenticates the API callers with bearer tokens
+// a token is either a static API key that is stored in a Secret or a JWT that is signed with one of the keys of the JWKS
+type tokenAuthenticator struct {
+	apiKeys *apiKeyStore
+	jwt     *jwtVerifier
+}
+
+// authenticate returns the caller that the token belongs to
+func (a *tokenAuthenticator) authenticate(token string) (*caller, error) {
+	if token == "" {
+		return nil, errors.New("no bearer token")
+	}
+	if a.apiKeys != nil {
+		if c := a.apiKeys.getCaller(token); c != nil {
+			return c, nil
+		}
+	}
+	// JWTs consist of three dot separated parts, whereas API keys can be anything
+	if a.jwt != nil && strings.Count(token, ".") == 2 {
+		return a.jwt.verify(token)
+	}
+	return nil, errors.New("invalid token")
+}
+
+// middleware authenticates every request and adds the caller to the request context
+// requests without a valid bearer token are rejected with 401
+func (a *tokenAuthenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		c, err := a.authenticate(getBearerToken(r.Header.Get("Authorization")))
+		if err != nil {
+			if strings.HasPrefix(r.URL.Path, apiV2Path) {
+				writeErrorV2(ctx, w, newApiError(http.StatusUnauthorized, err, "unauthorized"))
+				return
+			}
+			unauthorizedError(ctx, w, err, "unauthorized")
+			return
+		}
+		log.FromContext(ctx).V(1).Info("authenticated API caller", "caller", c.name)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, callerContextKey{}, c)))
+	})
+}
+
+// unaryInterceptor authenticates every gRPC call and adds the caller to the call context
+// calls without a valid bearer token in the "authorization" metadata are rejected with Unauthenticated
+func (a *tokenAuthenticator) unaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			token = getBearerToken(values[0])
+		}
+	}
+	c, err := a.authenticate(token)
+	if err != nil {
+		return nil, grpcError(ctx, newApiError(http.StatusUnauthorized, err, "unauthorized"))
+	}
+	return handler(context.WithValue(ctx, callerContextKey{}, c), req)
+}
+
+// getBearerToken returns the token of a "Bearer <token>" authorization header value
+func getBearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
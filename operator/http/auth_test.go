@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/playfab/thundernetes/operator/http/allocationpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testApiKey = "test-api-key"
+
+var _ = Describe("token authentication tests", func() {
+	var authenticator *tokenAuthenticator
+
+	BeforeEach(func() {
+		authenticator = &tokenAuthenticator{apiKeys: newApiKeyStore()}
+		authenticator.apiKeys.setSecret(newTestApiKeySecret("allowed", testApiKey, "", buildID1))
+		authenticator.apiKeys.setSecret(newTestApiKeySecret("other", "other-api-key", "otherTitleID", ""))
+	})
+
+	It("should return Unauthorized without a bearer token", func() {
+		res := serveWithToken(authenticator, &sessionHandler{client: newTestSimpleK8s()}, "")
+		Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(res.Header.Get("WWW-Authenticate")).To(Equal("Bearer"))
+	})
+	It("should return Unauthorized with an unknown API key", func() {
+		res := serveWithToken(authenticator, &sessionHandler{client: newTestSimpleK8s()}, "unknown")
+		Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+	It("should return Unauthorized after the API key Secret is deleted", func() {
+		authenticator.apiKeys.deleteSecret("allowed")
+		res := serveWithToken(authenticator, &sessionHandler{client: newTestSimpleK8s()}, testApiKey)
+		Expect(res.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+	It("should return the session for a caller that is allowed to use the build", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		res := serveWithToken(authenticator, &sessionHandler{client: client}, testApiKey)
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("should return Forbidden for a caller that is not allowed to use the build", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		res := serveWithToken(authenticator, &sessionHandler{client: client}, "other-api-key")
+		Expect(res.StatusCode).To(Equal(http.StatusForbidden))
+	})
+	It("should return Forbidden when allocating from a build the caller is not allowed to use", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, "", mpsv1alpha1.GameServerStateStandingBy)
+		Expect(err).ToNot(HaveOccurred())
+		b, _ := json.Marshal(AllocateArgs{SessionID: sessionID1, BuildID: buildID1})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/allocate", bytes.NewReader(b))
+		req.Header.Set("Authorization", "Bearer other-api-key")
+		w := httptest.NewRecorder()
+		authenticator.middleware(&allocateHandler{client: client}).ServeHTTP(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusForbidden))
+	})
+	It("should only list the builds the caller is allowed to use", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		for token, total := range map[string]int{testApiKey: 1, "other-api-key": 0} {
+			req := httptest.NewRequest(http.MethodGet, buildsPath, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			authenticator.middleware(&buildsHandler{client: client}).ServeHTTP(w, req)
+			res := w.Result()
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+			var rs ListBuildsResponse
+			err = json.NewDecoder(res.Body).Decode(&rs)
+			res.Body.Close()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rs.Total).To(Equal(total))
+		}
+	})
+	It("should parse bearer tokens", func() {
+		Expect(getBearerToken("Bearer abc")).To(Equal("abc"))
+		Expect(getBearerToken("bearer abc ")).To(Equal("abc"))
+		Expect(getBearerToken("Basic abc")).To(BeEmpty())
+		Expect(getBearerToken("")).To(BeEmpty())
+	})
+	It("should authenticate gRPC calls", func() {
+		ln := bufconn.Listen(1024 * 1024)
+		srv := grpc.NewServer(grpc.UnaryInterceptor(authenticator.unaryInterceptor))
+		allocationpb.RegisterAllocationServiceServer(srv, &allocationServer{client: newTestSimpleK8s()})
+		go srv.Serve(ln)
+		defer srv.Stop()
+
+		conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.Dial()
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		c := allocationpb.NewAllocationServiceClient(conn)
+
+		_, err = c.GetSession(context.Background(), &allocationpb.GetSessionRequest{SessionId: sessionID1})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testApiKey)
+		_, err = c.GetSession(ctx, &allocationpb.GetSessionRequest{SessionId: sessionID1})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+})
+
+// serveWithToken serves a GET request for sessionID1 through the authentication middleware
+func serveWithToken(authenticator *tokenAuthenticator, h http.Handler, token string) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, sessionsPath+sessionID1, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	authenticator.middleware(h).ServeHTTP(w, req)
+	res := w.Result()
+	res.Body.Close()
+	return res
+}
+
+func newTestApiKeySecret(name, key, titleIDs, buildIDs string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{apiKeyLabel: "true"},
+		},
+		Data: map[string][]byte{
+			apiKeyFileName:         []byte(key),
+			apiKeyTitleIDsFileName: []byte(titleIDs),
+			apiKeyBuildIDsFileName: []byte(buildIDs),
+		},
+	}
+}
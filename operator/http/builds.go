@@ -0,0 +1,223 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/playfab/thundernetes/operator/controllers"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	buildsPath = "/api/v1/builds"
+
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+type buildsHandler struct {
+	client client.Client
+}
+
+func (h *buildsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r)
+}
+
+// handle serves GET /api/v1/builds and GET /api/v1/builds/{buildID}/gameservers
+func (h *buildsHandler) handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet {
+		badRequestError(ctx, w, errors.New("invalid method"), "Only GET is accepted")
+		return
+	}
+
+	offset, limit, err := getPagingArgs(r)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, buildsPath), "/")
+	if path == "" {
+		rs, err := listBuilds(ctx, h.client, r.URL.Query().Get("health"), offset, limit)
+		if err != nil {
+			writeError(ctx, w, err)
+			return
+		}
+		writeJSON(ctx, w, http.StatusOK, rs)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[1] != "gameservers" {
+		notFoundError(ctx, w, errors.New("path not found"), fmt.Sprintf("Path %s not found", r.URL.Path))
+		return
+	}
+	rs, err := listGameServersForBuild(ctx, h.client, parts[0], r.URL.Query().Get("state"), offset, limit)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, rs)
+}
+
+// listBuilds returns a page of GameServerBuilds, optionally filtered by their health
+// it is used by both the v1 and the v2 REST API
+func listBuilds(ctx context.Context, c client.Client, health string, offset, limit int) (*ListBuildsResponse, error) {
+	if health != "" && health != string(mpsv1alpha1.BuildHealthy) && health != string(mpsv1alpha1.BuildUnhealthy) {
+		return nil, newValidationError([]FieldError{{Field: "health", Message: fmt.Sprintf("must be %s or %s", mpsv1alpha1.BuildHealthy, mpsv1alpha1.BuildUnhealthy)}})
+	}
+
+	var gameServerBuilds mpsv1alpha1.GameServerBuildList
+	if err := c.List(ctx, &gameServerBuilds); err != nil {
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
+	}
+
+	builds := make([]BuildDetails, 0, len(gameServerBuilds.Items))
+	for _, gsb := range gameServerBuilds.Items {
+		if health != "" && string(gsb.Status.Health) != health {
+			continue
+		}
+		// callers only see the builds they are allowed to use
+		if !isAllowedForContext(ctx, gsb.Spec.TitleID, gsb.Spec.BuildID) {
+			continue
+		}
+		builds = append(builds, BuildDetails{
+			Name:                gsb.Name,
+			Namespace:           gsb.Namespace,
+			BuildID:             gsb.Spec.BuildID,
+			TitleID:             gsb.Spec.TitleID,
+			StandingBy:          gsb.Spec.StandingBy,
+			Max:                 gsb.Spec.Max,
+			CurrentInitializing: gsb.Status.CurrentInitializing,
+			CurrentStandingBy:   gsb.Status.CurrentStandingBy,
+			CurrentActive:       gsb.Status.CurrentActive,
+			CrashesCount:        gsb.Status.CrashesCount,
+			Health:              string(gsb.Status.Health),
+		})
+	}
+	// cache does not guarantee any ordering, so we sort to get consistent pages
+	sort.Slice(builds, func(i, j int) bool {
+		if builds[i].Namespace != builds[j].Namespace {
+			return builds[i].Namespace < builds[j].Namespace
+		}
+		return builds[i].Name < builds[j].Name
+	})
+
+	start, end, next := getPageBounds(len(builds), offset, limit)
+	return &ListBuildsResponse{
+		Builds:     builds[start:end],
+		Total:      len(builds),
+		NextOffset: next,
+	}, nil
+}
+
+// listGameServersForBuild returns a page of GameServers for the given BuildID, optionally filtered by their state
+// it is used by both the v1 and the v2 REST API
+func listGameServersForBuild(ctx context.Context, c client.Client, buildID, state string, offset, limit int) (*ListGameServersResponse, error) {
+	var details []FieldError
+	if !isValidUUID(buildID) {
+		details = append(details, FieldError{Field: "buildID", Message: "must be a UUID"})
+	}
+	if state != "" && !isValidGameServerState(state) {
+		details = append(details, FieldError{Field: "state", Message: "must be StandingBy, Active, Crashed or GameCompleted"})
+	}
+	if len(details) > 0 {
+		return nil, newValidationError(details)
+	}
+
+	// check if this build exists
+	var gameServerBuilds mpsv1alpha1.GameServerBuildList
+	if err := c.List(ctx, &gameServerBuilds, client.MatchingFields{"spec.buildID": buildID}); err != nil {
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
+	}
+	if len(gameServerBuilds.Items) == 0 {
+		return nil, newApiError(http.StatusNotFound, errors.New("build not found"), fmt.Sprintf("Build with ID %s not found", buildID))
+	}
+	if err := authorize(ctx, gameServerBuilds.Items[0].Spec.TitleID, buildID); err != nil {
+		return nil, err
+	}
+
+	listOptions := &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{controllers.LabelBuildID: buildID}),
+	}
+	if state != "" {
+		listOptions.FieldSelector = fields.SelectorFromSet(fields.Set{"status.state": state})
+	}
+	var gameServers mpsv1alpha1.GameServerList
+	if err := c.List(ctx, &gameServers, listOptions); err != nil {
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
+	}
+
+	sort.Slice(gameServers.Items, func(i, j int) bool {
+		return gameServers.Items[i].Name < gameServers.Items[j].Name
+	})
+
+	start, end, next := getPageBounds(len(gameServers.Items), offset, limit)
+	gameServerDetails := make([]GameServerDetails, 0, end-start)
+	for i := start; i < end; i++ {
+		gameServerDetails = append(gameServerDetails, newGameServerDetails(&gameServers.Items[i]))
+	}
+	return &ListGameServersResponse{
+		GameServers: gameServerDetails,
+		Total:       len(gameServers.Items),
+		NextOffset:  next,
+	}, nil
+}
+
+// getPagingArgs parses the offset and limit query parameters
+func getPagingArgs(r *http.Request) (int, int, error) {
+	offset, limit := 0, defaultPageLimit
+	var details []FieldError
+	var err error
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			details = append(details, FieldError{Field: "offset", Message: "must be a non-negative integer"})
+		}
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			details = append(details, FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", maxPageLimit)})
+		}
+	}
+	if len(details) > 0 {
+		return 0, 0, newValidationError(details)
+	}
+	return offset, limit, nil
+}
+
+// getPageBounds returns the start and end indexes of the requested page
+// as well as the offset of the next page (zero if there are no more items)
+func getPageBounds(total, offset, limit int) (int, int, int) {
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end >= total {
+		return offset, total, 0
+	}
+	return offset, end, end
+}
+
+// isValidGameServerState returns true if the string is a valid GameServer state
+func isValidGameServerState(state string) bool {
+	switch mpsv1alpha1.GameServerState(state) {
+	case mpsv1alpha1.GameServerStateStandingBy,
+		mpsv1alpha1.GameServerStateActive,
+		mpsv1alpha1.GameServerStateCrashed,
+		mpsv1alpha1.GameServerStateGameCompleted:
+		return true
+	}
+	return false
+}
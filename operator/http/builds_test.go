@@ -0,0 +1,105 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+)
+
+var _ = Describe("build listing tests", func() {
+	It("POST method should return error", func() {
+		req := httptest.NewRequest(http.MethodPost, buildsPath, nil)
+		w := httptest.NewRecorder()
+		h := &buildsHandler{}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+	It("invalid limit should return error", func() {
+		req := httptest.NewRequest(http.MethodGet, buildsPath+"?limit=0", nil)
+		w := httptest.NewRecorder()
+		h := &buildsHandler{}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+	It("invalid state should return error", func() {
+		req := httptest.NewRequest(http.MethodGet, buildsPath+"/"+buildID1+"/gameservers?state=Foo", nil)
+		w := httptest.NewRecorder()
+		h := &buildsHandler{}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+	It("should return NotFound for an unknown build", func() {
+		req := httptest.NewRequest(http.MethodGet, buildsPath+"/"+buildID1+"/gameservers", nil)
+		w := httptest.NewRecorder()
+		h := &buildsHandler{
+			client: newTestSimpleK8s(),
+		}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+	})
+	It("should list builds", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, "", mpsv1alpha1.GameServerStateStandingBy)
+		Expect(err).ToNot(HaveOccurred())
+		req := httptest.NewRequest(http.MethodGet, buildsPath, nil)
+		w := httptest.NewRecorder()
+		h := &buildsHandler{
+			client: client,
+		}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		var rs ListBuildsResponse
+		err = json.Unmarshal(body, &rs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rs.Total).To(Equal(1))
+		Expect(rs.NextOffset).To(Equal(0))
+		Expect(rs.Builds[0].Name).To(Equal(buildName1))
+		Expect(rs.Builds[0].BuildID).To(Equal(buildID1))
+	})
+	It("should list game servers for a build", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, "", mpsv1alpha1.GameServerStateStandingBy)
+		Expect(err).ToNot(HaveOccurred())
+		req := httptest.NewRequest(http.MethodGet, buildsPath+"/"+buildID1+"/gameservers", nil)
+		w := httptest.NewRecorder()
+		h := &buildsHandler{
+			client: client,
+		}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		var rs ListGameServersResponse
+		err = json.Unmarshal(body, &rs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rs.Total).To(Equal(1))
+		Expect(rs.GameServers[0].Name).To(Equal(gsName))
+	})
+	It("should calculate page bounds", func() {
+		start, end, next := getPageBounds(5, 0, 2)
+		Expect([]int{start, end, next}).To(Equal([]int{0, 2, 2}))
+		start, end, next = getPageBounds(5, 4, 2)
+		Expect([]int{start, end, next}).To(Equal([]int{4, 5, 0}))
+		start, end, next = getPageBounds(5, 10, 2)
+		Expect([]int{start, end, next}).To(Equal([]int{5, 5, 0}))
+	})
+})
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playfab/thundernetes/operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	certificateFileName = "tls.crt"
+	privateKeyFileName  = "tls.key"
+	caBundleFileName    = "ca.crt"
+	// certificateExpiryWarningPeriod is the time before the expiry of the certificate when we start emitting Warning Events
+	certificateExpiryWarningPeriod = 30 * 24 * time.Hour
+)
+
+// certificateReloader loads the certificates of the API server from the TLS Secrets into the certificateStore
+// it watches the Secrets and reloads the certificates when they change, so they can be rotated without restarting the API server
+type certificateReloader struct {
+	options  *TLSOptions
+	store    *certificateStore
+	recorder record.EventRecorder
+	mux      sync.Mutex
+	secrets  map[string]*corev1.Secret
+}
+
+// newCertificateReloader creates a new certificateReloader for the given options
+func newCertificateReloader(options *TLSOptions, recorder record.EventRecorder) *certificateReloader {
+	return &certificateReloader{
+		options:  options,
+		store:    &certificateStore{},
+		recorder: recorder,
+		secrets:  make(map[string]*corev1.Secret),
+	}
+}
+
+// load gets the Secrets and loads the certificates
+// it's used on startup, before the Secrets are watched
+func (r *certificateReloader) load(ctx context.Context, reader client.Reader) error {
+	for _, name := range r.secretNames() {
+		var secret corev1.Secret
+		if err := reader.Get(ctx, types.NamespacedName{Name: name, Namespace: r.options.Namespace}, &secret); err != nil {
+			return err
+		}
+		r.secrets[name] = &secret
+	}
+	return r.reload(ctx)
+}
+
+// secretChanged updates the Secret and reloads the certificates
+// if the new certificates are invalid, the current ones keep being used
+func (r *certificateReloader) secretChanged(ctx context.Context, secret *corev1.Secret) {
+	log := log.FromContext(ctx)
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if old, ok := r.secrets[secret.Name]; ok && old.ResourceVersion == secret.ResourceVersion {
+		return
+	}
+	r.secrets[secret.Name] = secret
+	if err := r.reload(ctx); err != nil {
+		log.Error(err, "unable to reload the TLS certificates, the current ones will be used", "secret", secret.Name)
+		r.recorder.Eventf(secret, corev1.EventTypeWarning, "CertificateReloadFailed", "Unable to reload the API server TLS certificates: %s", err.Error())
+	}
+}
+
+// reload loads the certificates from the current Secrets into the store
+// it updates the certificate expiry metric and emits an Event with the expiry of the loaded certificate
+func (r *certificateReloader) reload(ctx context.Context) error {
+	log := log.FromContext(ctx)
+
+	secret, ok := r.secrets[r.options.SecretName]
+	if !ok {
+		return fmt.Errorf("secret %s has not been loaded", r.options.SecretName)
+	}
+	caSecret, ok := r.secrets[r.options.CASecretName]
+	if !ok {
+		return fmt.Errorf("secret %s has not been loaded", r.options.CASecretName)
+	}
+
+	// a self-signed server certificate is its own CA, so it can also be used as the client certificate
+	// a CA signed one is not used, since the client certificates that are signed by the same CA would not be verified against it
+	caBundle := caSecret.Data[caBundleFileName]
+	if len(caBundle) == 0 && r.options.CASecretName == r.options.SecretName && isSelfSigned(secret.Data[certificateFileName]) {
+		caBundle = secret.Data[certificateFileName]
+	}
+
+	// without a CA bundle the client certificates are not verified, which is only allowed if the options explicitly allow it
+	// otherwise the API server does not start, and a reload keeps the current CA bundle
+	if len(caBundle) == 0 && !r.options.allowsUnverifiedClients() {
+		return fmt.Errorf("secret %s does not contain a CA bundle in the %s key, the client certificates cannot be verified", caSecret.Name, caBundleFileName)
+	}
+
+	cert, err := r.store.update(secret.Data[certificateFileName], secret.Data[privateKeyFileName], caBundle)
+	if err != nil {
+		return err
+	}
+
+	if len(caBundle) == 0 {
+		log.Info("WARNING: no CA bundle found, the client certificates are not verified", "secret", caSecret.Name, "key", caBundleFileName)
+		r.recorder.Eventf(caSecret, corev1.EventTypeWarning, "ClientCertificatesNotVerified", "The %s key is missing, the API server does not verify the client certificates", caBundleFileName)
+	}
+
+	log.Info("loaded API server TLS certificate", "secret", secret.Name, "subject", cert.Subject.String(), "expiry", cert.NotAfter)
+	controllers.ApiServerCertificateExpiryGauge.WithLabelValues(secret.Name).Set(float64(cert.NotAfter.Unix()))
+	r.recorder.Eventf(secret, corev1.EventTypeNormal, "CertificateLoaded", "Loaded API server TLS certificate %s that expires at %s", cert.Subject.String(), cert.NotAfter.UTC().Format(time.RFC3339))
+	if time.Until(cert.NotAfter) < certificateExpiryWarningPeriod {
+		r.recorder.Eventf(secret, corev1.EventTypeWarning, "CertificateExpiring", "API server TLS certificate %s expires at %s", cert.Subject.String(), cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// watch watches the Secrets for changes until the context is done
+func (r *certificateReloader) watch(ctx context.Context, config *rest.Config) error {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range r.secretNames() {
+		fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+		factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+			informers.WithNamespace(r.options.Namespace),
+			informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+				o.FieldSelector = fieldSelector
+			}))
+		factory.Core().V1().Secrets().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				r.secretChanged(ctx, obj.(*corev1.Secret))
+			},
+			UpdateFunc: func(_, newObj interface{}) {
+				r.secretChanged(ctx, newObj.(*corev1.Secret))
+			},
+			DeleteFunc: func(obj interface{}) {
+				log.FromContext(ctx).Info("TLS secret was deleted, the current certificates will be used until it's recreated", "secret", name)
+			},
+		})
+		factory.Start(ctx.Done())
+	}
+	return nil
+}
+
+// secretNames returns the distinct names of the Secrets that contain the certificates
+func (r *certificateReloader) secretNames() []string {
+	if r.options.CASecretName == r.options.SecretName {
+		return []string{r.options.SecretName}
+	}
+	return []string{r.options.SecretName, r.options.CASecretName}
+}
+
+// isSelfSigned returns true if the first certificate of the PEM block is signed by its own key
+func isSelfSigned(certPEMBlock []byte) bool {
+	block, _ := pem.Decode(certPEMBlock)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/playfab/thundernetes/operator/controllers"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+var _ = Describe("TLS certificate reloading tests", func() {
+	const testSecretName = "tls-secret"
+
+	var (
+		recorder *record.FakeRecorder
+		reloader *certificateReloader
+	)
+
+	newTestSecret := func(cert *testCertificate, resourceVersion string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            testSecretName,
+				Namespace:       "default",
+				ResourceVersion: resourceVersion,
+			},
+			Data: map[string][]byte{
+				certificateFileName: cert.certPEM,
+				privateKeyFileName:  cert.keyPEM,
+			},
+		}
+	}
+	withLocalhostIP := func(t *x509.Certificate) {
+		t.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		reloader = newCertificateReloader(&TLSOptions{
+			Namespace:    "default",
+			SecretName:   testSecretName,
+			CASecretName: testSecretName,
+		}, recorder)
+	})
+
+	It("should load the certificate from the Secret and use it as the CA bundle", func() {
+		cert := newTestCertificate("localhost", nil, nil)
+		client := newTestSimpleK8s()
+		err := client.Create(context.Background(), newTestSecret(cert, ""))
+		Expect(err).ToNot(HaveOccurred())
+
+		err = reloader.load(context.Background(), client)
+		Expect(err).ToNot(HaveOccurred())
+		loaded, err := reloader.store.getCertificate(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Leaf.SerialNumber).To(Equal(cert.cert.SerialNumber))
+		Expect(reloader.store.getClientCAs()).ToNot(BeNil())
+
+		Expect(testutil.ToFloat64(controllers.ApiServerCertificateExpiryGauge.WithLabelValues(testSecretName))).To(Equal(float64(cert.cert.NotAfter.Unix())))
+		Expect(<-recorder.Events).To(ContainSubstring("CertificateLoaded"))
+		// the test certificates expire in an hour
+		Expect(<-recorder.Events).To(ContainSubstring("CertificateExpiring"))
+	})
+	It("should not start without a CA bundle unless unverified clients are allowed", func() {
+		// a CA signed server certificate is not used as the CA bundle
+		ca := newTestCertificate("test-ca", nil, nil)
+		client := newTestSimpleK8s()
+		Expect(client.Create(context.Background(), newTestSecret(newTestCertificate("localhost", ca, nil), ""))).To(Succeed())
+		Expect(reloader.load(context.Background(), client)).ToNot(Succeed())
+
+		// allow-lists always require a CA bundle
+		reloader.options.AllowUnverifiedClients = true
+		reloader.options.ClientAuth.AllowedSubjects = []string{"matchmaker"}
+		Expect(reloader.load(context.Background(), client)).ToNot(Succeed())
+
+		reloader.options.ClientAuth.AllowedSubjects = nil
+		Expect(reloader.load(context.Background(), client)).To(Succeed())
+		Expect(reloader.store.getClientCAs()).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("ClientCertificatesNotVerified")))
+	})
+	It("should require the CA bundle of a separate CA Secret and keep it if it's removed", func() {
+		ca := newTestCertificate("test-ca", nil, nil)
+		caSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-secret", Namespace: "default"},
+			Data:       map[string][]byte{},
+		}
+		reloader.options.CASecretName = caSecret.Name
+		reloader.options.AllowUnverifiedClients = true
+		client := newTestSimpleK8s()
+		Expect(client.Create(context.Background(), newTestSecret(newTestCertificate("localhost", ca, nil), ""))).To(Succeed())
+		Expect(client.Create(context.Background(), caSecret.DeepCopy())).To(Succeed())
+		Expect(reloader.load(context.Background(), client)).ToNot(Succeed())
+
+		caSecret.Data[caBundleFileName] = ca.certPEM
+		caSecret.ResourceVersion = "2"
+		reloader.secretChanged(context.Background(), caSecret.DeepCopy())
+		clientCAs := reloader.store.getClientCAs()
+		Expect(clientCAs).ToNot(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("CertificateLoaded")))
+		Expect(recorder.Events).To(Receive(ContainSubstring("CertificateExpiring")))
+
+		delete(caSecret.Data, caBundleFileName)
+		caSecret.ResourceVersion = "3"
+		reloader.secretChanged(context.Background(), caSecret.DeepCopy())
+		Expect(reloader.store.getClientCAs()).To(BeIdenticalTo(clientCAs))
+		Expect(recorder.Events).To(Receive(ContainSubstring("CertificateReloadFailed")))
+	})
+	It("should return an error if the Secret does not exist", func() {
+		err := reloader.load(context.Background(), newTestSimpleK8s())
+		Expect(err).To(HaveOccurred())
+	})
+	It("should serve the new certificate without restarting the listener", func() {
+		oldCert := newTestCertificate("localhost", nil, withLocalhostIP)
+		reloader.secretChanged(context.Background(), newTestSecret(oldCert, "1"))
+
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		srv.TLS = newTLSConfig(reloader.store, &ClientAuthOptions{}, nil)
+		srv.StartTLS()
+		defer srv.Close()
+
+		res, err := newTestTLSClient(oldCert, oldCert).Get(srv.URL)
+		Expect(err).ToNot(HaveOccurred())
+		res.Body.Close()
+		Expect(res.TLS.PeerCertificates[0].SerialNumber).To(Equal(oldCert.cert.SerialNumber))
+
+		newCert := newTestCertificate("localhost", nil, withLocalhostIP)
+		reloader.secretChanged(context.Background(), newTestSecret(newCert, "2"))
+
+		res, err = newTestTLSClient(newCert, newCert).Get(srv.URL)
+		Expect(err).ToNot(HaveOccurred())
+		res.Body.Close()
+		Expect(res.TLS.PeerCertificates[0].SerialNumber).To(Equal(newCert.cert.SerialNumber))
+
+		// the old certificate is no longer trusted as a client certificate, since it's not in the CA bundle anymore
+		_, err = newTestTLSClient(oldCert, newCert).Get(srv.URL)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should keep the current certificate if the new one is invalid", func() {
+		cert := newTestCertificate("localhost", nil, nil)
+		reloader.secretChanged(context.Background(), newTestSecret(cert, "1"))
+
+		invalid := newTestSecret(cert, "2")
+		invalid.Data[privateKeyFileName] = []byte("not a key")
+		reloader.secretChanged(context.Background(), invalid)
+
+		loaded, err := reloader.store.getCertificate(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Leaf.SerialNumber).To(Equal(cert.cert.SerialNumber))
+		Expect(recorder.Events).To(Receive(ContainSubstring("CertificateLoaded")))
+		Expect(recorder.Events).To(Receive(ContainSubstring("CertificateExpiring")))
+		Expect(recorder.Events).To(Receive(ContainSubstring("CertificateReloadFailed")))
+	})
+})
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/playfab/thundernetes/operator/http/allocationpb"
+)
+
+// allocationServer implements the gRPC AllocationService
+// it uses the same logic as the REST API handlers
+type allocationServer struct {
+	allocationpb.UnimplementedAllocationServiceServer
+	client  client.Client
+	limiter *allocationLimiter
+}
+
+// Allocate allocates a StandingBy GameServer for the requested session
+func (s *allocationServer) Allocate(ctx context.Context, req *allocationpb.AllocateRequest) (*allocationpb.AllocateResponse, error) {
+	rs, err := allocate(ctx, s.client, s.limiter, newAllocateArgs(req))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return newAllocateResponse(rs), nil
+}
+
+// BatchAllocate allocates a GameServer for each of the requests
+// a failed allocation is reported in its result and does not fail the whole call
+func (s *allocationServer) BatchAllocate(ctx context.Context, req *allocationpb.BatchAllocateRequest) (*allocationpb.BatchAllocateResponse, error) {
+	args := make([]AllocateArgs, 0, len(req.Requests))
+	for _, r := range req.Requests {
+		args = append(args, *newAllocateArgs(r))
+	}
+
+	responses, errs, err := allocateBatch(ctx, s.client, s.limiter, args)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	rs := &allocationpb.BatchAllocateResponse{
+		Results: make([]*allocationpb.BatchAllocateResult, 0, len(args)),
+	}
+	for i := 0; i < len(args); i++ {
+		if errs[i] != nil {
+			st := status.Convert(grpcError(ctx, errs[i]))
+			rs.Results = append(rs.Results, &allocationpb.BatchAllocateResult{
+				Result: &allocationpb.BatchAllocateResult_Error{
+					Error: &allocationpb.Error{Code: uint32(st.Code()), Message: st.Message()},
+				},
+			})
+			continue
+		}
+		rs.Results = append(rs.Results, &allocationpb.BatchAllocateResult{
+			Result: &allocationpb.BatchAllocateResult_Allocation{
+				Allocation: newAllocateResponse(responses[i]),
+			},
+		})
+	}
+	return rs, nil
+}
+
+// GetSession returns the details of the GameServer that hosts the requested session
+func (s *allocationServer) GetSession(ctx context.Context, req *allocationpb.GetSessionRequest) (*allocationpb.GameServerDetails, error) {
+	gs, err := getGameServerForSession(ctx, s.client, req.SessionId)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	gsd := newGameServerDetails(gs)
+	return &allocationpb.GameServerDetails{
+		Name:             gsd.Name,
+		Namespace:        gsd.Namespace,
+		BuildName:        gsd.BuildName,
+		BuildId:          gsd.BuildID,
+		NodeName:         gsd.NodeName,
+		Ipv4Address:      gsd.IPV4Address,
+		Ports:            gsd.Ports,
+		State:            gsd.State,
+		Health:           gsd.Health,
+		SessionId:        gsd.SessionID,
+		SessionCookie:    gsd.SessionCookie,
+		InitialPlayers:   gsd.InitialPlayers,
+		ConnectedPlayers: gsd.ConnectedPlayers,
+	}, nil
+}
+
+// Terminate deletes the GameServer that hosts the requested session
+func (s *allocationServer) Terminate(ctx context.Context, req *allocationpb.TerminateRequest) (*allocationpb.TerminateResponse, error) {
+	if err := terminateSession(ctx, s.client, req.SessionId); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &allocationpb.TerminateResponse{}, nil
+}
+
+// newAllocateArgs converts a gRPC AllocateRequest to the AllocateArgs used by the REST API
+func newAllocateArgs(req *allocationpb.AllocateRequest) *AllocateArgs {
+	return &AllocateArgs{
+		SessionID:      req.SessionId,
+		BuildID:        req.BuildId,
+		SessionCookie:  req.SessionCookie,
+		InitialPlayers: req.InitialPlayers,
+	}
+}
+
+// newAllocateResponse converts a RequestMultiplayerServerResponse of the REST API to a gRPC AllocateResponse
+func newAllocateResponse(rs *RequestMultiplayerServerResponse) *allocationpb.AllocateResponse {
+	return &allocationpb.AllocateResponse{
+		Ipv4Address: rs.IPV4Address,
+		Ports:       rs.Ports,
+		SessionId:   rs.SessionID,
+	}
+}
+
+// grpcError logs the error and converts it to a gRPC status error
+// the status code corresponds to the HTTP status code that the REST API returns for the same error
+func grpcError(ctx context.Context, err error) error {
+	log := log.FromContext(ctx)
+	var ae *apiError
+	if !errors.As(err, &ae) {
+		log.Error(err, "internal error")
+		return status.Error(codes.Internal, err.Error())
+	}
+	switch ae.statusCode {
+	case http.StatusBadRequest:
+		log.Info(ae.msg)
+		st := status.New(codes.InvalidArgument, ae.Error())
+		if len(ae.details) > 0 {
+			violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(ae.details))
+			for _, d := range ae.details {
+				violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: d.Field, Description: d.Message})
+			}
+			if withDetails, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+				st = withDetails
+			}
+		}
+		return st.Err()
+	case http.StatusUnauthorized:
+		log.Info(ae.msg)
+		return status.Error(codes.Unauthenticated, ae.Error())
+	case http.StatusForbidden:
+		log.Info(ae.msg)
+		return status.Error(codes.PermissionDenied, ae.Error())
+	case http.StatusNotFound:
+		log.Info(ae.msg)
+		return status.Error(codes.NotFound, ae.Error())
+	case http.StatusConflict:
+		log.Info(ae.msg)
+		return status.Error(codes.FailedPrecondition, ae.Error())
+	case http.StatusTooManyRequests:
+		log.Info(ae.msg)
+		st := status.New(codes.ResourceExhausted, ae.Error())
+		if ae.retryAfter > 0 {
+			// the gRPC equivalent of the Retry-After header
+			if withDetails, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(ae.retryAfter)}); err == nil {
+				st = withDetails
+			}
+		}
+		return st.Err()
+	default:
+		log.Error(ae.err, ae.msg)
+		return status.Error(codes.Internal, ae.Error())
+	}
+}
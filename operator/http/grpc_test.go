@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/playfab/thundernetes/operator/http/allocationpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("gRPC allocation service tests", func() {
+	var (
+		k8sClient client.Client
+		srv       *grpc.Server
+		conn      *grpc.ClientConn
+		c         allocationpb.AllocationServiceClient
+	)
+
+	BeforeEach(func() {
+		k8sClient = newTestSimpleK8s()
+		ln := bufconn.Listen(1024 * 1024)
+		srv = grpc.NewServer()
+		allocationpb.RegisterAllocationServiceServer(srv, &allocationServer{client: k8sClient})
+		go srv.Serve(ln)
+
+		var err error
+		conn, err = grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.Dial()
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		c = allocationpb.NewAllocationServiceClient(conn)
+	})
+
+	AfterEach(func() {
+		conn.Close()
+		srv.Stop()
+	})
+
+	It("should return InvalidArgument when buildID is not a GUID", func() {
+		_, err := c.Allocate(context.Background(), &allocationpb.AllocateRequest{SessionId: sessionID1, BuildId: "NOT_A_GUID"})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+	It("should return NotFound when the build does not exist", func() {
+		_, err := c.Allocate(context.Background(), &allocationpb.AllocateRequest{SessionId: sessionID1, BuildId: buildID1})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+	It("should allocate a game server", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, "", mpsv1alpha1.GameServerStateStandingBy)
+		Expect(err).ToNot(HaveOccurred())
+		rs, err := c.Allocate(context.Background(), &allocationpb.AllocateRequest{SessionId: sessionID1, BuildId: buildID1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rs.SessionId).To(Equal(sessionID1))
+	})
+	It("should return FailedPrecondition when the build is paused", func() {
+		Expect(k8sClient.Create(context.Background(), &mpsv1alpha1.GameServerBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: buildName1, Namespace: "default"},
+			Spec:       mpsv1alpha1.GameServerBuildSpec{BuildID: buildID1, Paused: true},
+		})).To(Succeed())
+		_, err := c.Allocate(context.Background(), &allocationpb.AllocateRequest{SessionId: sessionID1, BuildId: buildID1})
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+	})
+	It("should return the game server details for an existing session", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		gsd, err := c.GetSession(context.Background(), &allocationpb.GetSessionRequest{SessionId: sessionID1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gsd.Name).To(Equal(gsName))
+		Expect(gsd.BuildName).To(Equal(buildName1))
+		Expect(gsd.State).To(Equal(string(mpsv1alpha1.GameServerStateActive)))
+	})
+	It("should return a result for every allocation of a batch", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		rs, err := c.BatchAllocate(context.Background(), &allocationpb.BatchAllocateRequest{
+			Requests: []*allocationpb.AllocateRequest{
+				{SessionId: sessionID1, BuildId: buildID1},
+				{SessionId: "9bb3bbb2-5031-42fd-8982-5a3f76ef2c8a", BuildId: "NOT_A_GUID"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rs.Results).To(HaveLen(2))
+		Expect(rs.Results[0].GetAllocation().SessionId).To(Equal(sessionID1))
+		Expect(codes.Code(rs.Results[1].GetError().Code)).To(Equal(codes.InvalidArgument))
+	})
+	It("should reject a batch with duplicate sessionIDs", func() {
+		_, err := c.BatchAllocate(context.Background(), &allocationpb.BatchAllocateRequest{
+			Requests: []*allocationpb.AllocateRequest{
+				{SessionId: sessionID1, BuildId: buildID1},
+				{SessionId: sessionID1, BuildId: buildID1},
+			},
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+	It("should terminate a session", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = c.Terminate(context.Background(), &allocationpb.TerminateRequest{SessionId: sessionID1})
+		Expect(err).ToNot(HaveOccurred())
+
+		var gs mpsv1alpha1.GameServer
+		err = k8sClient.Get(context.Background(), types.NamespacedName{Name: gsName, Namespace: "default"}, &gs)
+		Expect(err).To(HaveOccurred())
+		_, err = c.Terminate(context.Background(), &allocationpb.TerminateRequest{SessionId: sessionID1})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+})
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -15,31 +17,59 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
-)
-
-var (
-	crtBytes []byte
-	keyBytes []byte
+	"github.com/playfab/thundernetes/operator/http/allocationpb"
 )
 
 const (
-	listeningPort = 5000
+	listeningPort     = 5000
+	grpcListeningPort = 5001
 )
 
 // ApiServer is a helper struct that implements manager.Runnable interface
 // so it can be added to our Manager
 type ApiServer struct {
-	client client.Client
-	config *rest.Config
-	scheme *runtime.Scheme
+	client           client.Client
+	config           *rest.Config
+	scheme           *runtime.Scheme
+	tlsOptions       *TLSOptions
+	certificates     *certificateReloader
+	tokenAuthOptions *TokenAuthOptions
+	authenticator    *tokenAuthenticator
+	limiter          *allocationLimiter
 }
 
-// NewApiServer creates a new ApiServer and initializes the crd/key variables (can be nil)
-func NewApiServer(mgr ctrl.Manager, crt, key []byte) error {
-	crtBytes = crt
-	keyBytes = key
+// NewApiServer creates a new ApiServer
+// if tlsOptions is not nil, the certificates are loaded from the TLS Secrets and reloaded whenever the Secrets change
+// if tokenAuthOptions enables API keys or JWTs, all callers need to authenticate with a bearer token
+// if allocationLimits is not nil, the allocations are rate limited and the Active sessions are limited per TitleID and BuildID
+func NewApiServer(mgr ctrl.Manager, tlsOptions *TLSOptions, tokenAuthOptions *TokenAuthOptions, allocationLimits *AllocationLimits) error {
+	server := &ApiServer{client: mgr.GetClient(), config: mgr.GetConfig(), scheme: mgr.GetScheme(), tlsOptions: tlsOptions, tokenAuthOptions: tokenAuthOptions}
 
-	server := &ApiServer{client: mgr.GetClient(), config: mgr.GetConfig(), scheme: mgr.GetScheme()}
+	if tlsOptions != nil {
+		server.certificates = newCertificateReloader(tlsOptions, mgr.GetEventRecorderFor("ApiServer"))
+		// the cache is not started yet, so we use the API reader
+		if err := server.certificates.load(context.Background(), mgr.GetAPIReader()); err != nil {
+			return err
+		}
+	}
+
+	if tokenAuthOptions != nil && (tokenAuthOptions.APIKeys || tokenAuthOptions.JWKSFile != "") {
+		server.authenticator = &tokenAuthenticator{}
+		if tokenAuthOptions.APIKeys {
+			server.authenticator.apiKeys = newApiKeyStore()
+		}
+		if tokenAuthOptions.JWKSFile != "" {
+			jwt, err := newJwtVerifier(tokenAuthOptions.JWKSFile, tokenAuthOptions.Issuer, tokenAuthOptions.Audience)
+			if err != nil {
+				return err
+			}
+			server.authenticator.jwt = jwt
+		}
+	}
+
+	if allocationLimits != nil {
+		server.limiter = newAllocationLimiter(allocationLimits)
+	}
 
 	if err := server.setupIndexers(mgr); err != nil {
 		return err
@@ -63,6 +93,14 @@ func (s *ApiServer) setupIndexers(mgr ctrl.Manager) error {
 		return err
 	}
 
+	// indexes the initial, the past and the currently connected players, so we can find the existing GameServers of a player
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &mpsv1alpha1.GameServer{}, playersIndexField, func(rawObj client.Object) []string {
+		gs := rawObj.(*mpsv1alpha1.GameServer)
+		return getPlayersForGameServer(gs)
+	}); err != nil {
+		return err
+	}
+
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &mpsv1alpha1.GameServerBuild{}, "spec.buildID", func(rawObj client.Object) []string {
 		gsb := rawObj.(*mpsv1alpha1.GameServerBuild)
 		return []string{gsb.Spec.BuildID}
@@ -80,6 +118,7 @@ func (s *ApiServer) NeedLeaderElection() bool {
 
 // Start starts the HTTP(S) API Server
 // if user has provided public/private cert details, it will create a TLS-auth HTTPS server
+// that requires the clients to present a certificate signed by the configured CA
 // otherwise it will create a HTTP server with no auth
 func (s *ApiServer) Start(ctx context.Context) error {
 	log := log.FromContext(ctx)
@@ -90,16 +129,60 @@ func (s *ApiServer) Start(ctx context.Context) error {
 
 	mux := http.NewServeMux()
 	mux.Handle("/api/v1/allocate", &allocateHandler{
+		client:  s.client,
+		config:  s.config,
+		scheme:  s.scheme,
+		limiter: s.limiter,
+	})
+	mux.Handle(sessionsPath, &sessionHandler{
 		client: s.client,
-		config: s.config,
-		scheme: s.scheme,
 	})
+	builds := &buildsHandler{
+		client: s.client,
+	}
+	mux.Handle(buildsPath, builds)
+	mux.Handle(buildsPath+"/", builds)
+	mux.Handle(playersPath, &playerSessionsHandler{
+		client: s.client,
+	})
+	apiV2, err := newApiV2Handler(s.client, s.limiter)
+	if err != nil {
+		return err
+	}
+	mux.Handle(apiV2Path, apiV2)
+
+	if s.certificates != nil {
+		if err := s.certificates.watch(ctx, s.config); err != nil {
+			return err
+		}
+	}
+
+	// authenticate every request before it reaches the handlers
+	var handler http.Handler = mux
+	if s.authenticator != nil {
+		if s.authenticator.apiKeys != nil {
+			if err := s.authenticator.apiKeys.watch(ctx, s.config, s.tokenAuthOptions.Namespace); err != nil {
+				return err
+			}
+		}
+		handler = s.authenticator.middleware(mux)
+	}
+
+	grpcSrv, grpcLn, err := s.newGrpcServer()
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := grpcSrv.Serve(grpcLn); err != nil {
+			log.Error(err, "error serving the gRPC server")
+		}
+	}()
 
 	log.Info("serving API server", "addr", addr, "port", listeningPort)
 
 	srv := &http.Server{
 		Addr:    addr,
-		Handler: mux,
+		Handler: handler,
 	}
 
 	done := make(chan struct{})
@@ -112,12 +195,14 @@ func (s *ApiServer) Start(ctx context.Context) error {
 			// Error from closing listeners, or context timeout
 			log.Error(err, "error shutting down the HTTP server")
 		}
+		grpcSrv.GracefulStop()
 		close(done)
 	}()
 
-	if crtBytes != nil && keyBytes != nil {
+	if s.certificates != nil {
 		log.Info("starting TLS enabled API server")
-		if err := customListenAndServeTLS(srv, crtBytes, keyBytes); err != nil && err != http.ErrServerClosed {
+		tlsConfig := newTLSConfig(s.certificates.store, &s.tlsOptions.ClientAuth, []string{"http/1.1"})
+		if err := customListenAndServeTLS(srv, tlsConfig); err != nil && err != http.ErrServerClosed {
 			return err
 		}
 	} else {
@@ -131,27 +216,40 @@ func (s *ApiServer) Start(ctx context.Context) error {
 	return nil
 }
 
-// customListenAndServeTLS creates a new http server with []byte cert and []byte key
-// Golang's ListenAndServerTLS accepts filenames for cert and key whereas we have []byte
-// https://stackoverflow.com/a/30818656
-func customListenAndServeTLS(srv *http.Server, certPEMBlock, keyPEMBlock []byte) error {
-	addr := srv.Addr
+// newGrpcServer creates the gRPC server for the AllocationService and its listener
+// it uses the same certificates as the HTTP server, if the user has enabled TLS
+func (s *ApiServer) newGrpcServer() (*grpc.Server, net.Listener, error) {
+	addr := os.Getenv("GRPC_LISTEN")
 	if addr == "" {
-		addr = ":https"
+		addr = fmt.Sprintf(":%d", grpcListeningPort)
 	}
-	config := &tls.Config{}
-	if srv.TLSConfig != nil {
-		config = srv.TLSConfig
+
+	var opts []grpc.ServerOption
+	if s.certificates != nil {
+		tlsConfig := newTLSConfig(s.certificates.store, &s.tlsOptions.ClientAuth, []string{"h2"})
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
-	if config.NextProtos == nil {
-		config.NextProtos = []string{"http/1.1"}
+	if s.authenticator != nil {
+		opts = append(opts, grpc.UnaryInterceptor(s.authenticator.unaryInterceptor))
 	}
 
-	var err error
-	config.Certificates = make([]tls.Certificate, 1)
-	config.Certificates[0], err = tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+	ln, err := net.Listen("tcp", addr)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	srv := grpc.NewServer(opts...)
+	allocationpb.RegisterAllocationServiceServer(srv, &allocationServer{client: s.client, limiter: s.limiter})
+	return srv, tcpKeepAliveListener{ln.(*net.TCPListener)}, nil
+}
+
+// customListenAndServeTLS creates a new http server with the given TLS configuration
+// Golang's ListenAndServerTLS accepts filenames for cert and key whereas we get the certificates from the TLS Secret
+// https://stackoverflow.com/a/30818656
+func customListenAndServeTLS(srv *http.Server, config *tls.Config) error {
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":https"
 	}
 
 	ln, err := net.Listen("tcp", addr)
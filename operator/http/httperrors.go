@@ -2,7 +2,14 @@ package http
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -11,30 +18,133 @@ import (
 func internalServerError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
 	log := log.FromContext(ctx)
 	log.Error(err, msg)
-	w.WriteHeader(http.StatusInternalServerError)
-	w.Write([]byte("500 - " + msg + " " + err.Error()))
+	writeTextError(w, http.StatusInternalServerError, err, msg)
 }
 
 // badRequestError is a helper function for returning a bad request error
 func badRequestError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
 	log := log.FromContext(ctx)
 	log.Info(msg)
-	w.WriteHeader(http.StatusBadRequest)
-	w.Write([]byte("400 - " + msg + " " + err.Error()))
+	writeTextError(w, http.StatusBadRequest, err, msg)
 }
 
 // tooManyRequestsError is a helper function for returning a too many requests error
 func tooManyRequestsError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
 	log := log.FromContext(ctx)
 	log.Info(msg)
-	w.WriteHeader(http.StatusTooManyRequests)
-	w.Write([]byte("429 - " + msg + " " + err.Error()))
+	writeTextError(w, http.StatusTooManyRequests, err, msg)
 }
 
 // notFoundError is a helper function for returning a not found error
 func notFoundError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
 	log := log.FromContext(ctx)
 	log.Info(msg)
-	w.WriteHeader(http.StatusNotFound)
-	w.Write([]byte("404 - " + msg + " " + err.Error()))
+	writeTextError(w, http.StatusNotFound, err, msg)
+}
+
+// conflictError is a helper function for returning a conflict error
+func conflictError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
+	log := log.FromContext(ctx)
+	log.Info(msg)
+	writeTextError(w, http.StatusConflict, err, msg)
+}
+
+// unauthorizedError is a helper function for returning an unauthorized error
+func unauthorizedError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
+	log := log.FromContext(ctx)
+	log.Info(msg)
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	writeTextError(w, http.StatusUnauthorized, err, msg)
+}
+
+// forbiddenError is a helper function for returning a forbidden error
+func forbiddenError(ctx context.Context, w http.ResponseWriter, err error, msg string) {
+	log := log.FromContext(ctx)
+	log.Info(msg)
+	writeTextError(w, http.StatusForbidden, err, msg)
+}
+
+// writeTextError writes the plain text error body of the v1 API, e.g. "400 - invalid arguments sessionID: must be a UUID"
+func writeTextError(w http.ResponseWriter, statusCode int, err error, msg string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+	w.Write([]byte(strconv.Itoa(statusCode) + " - " + msg + " " + err.Error()))
+}
+
+// writeJSON writes the value as the JSON body of the response
+func writeJSON(ctx context.Context, w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.FromContext(ctx).Error(err, "encode json response")
+	}
+}
+
+// apiError is an error returned by the logic that is shared between the REST and the gRPC API
+// statusCode is the HTTP status code that corresponds to the error
+// retryAfter is the time after which the caller can retry the request, if it's set
+// details contains the invalid fields of the request, if it's a validation error
+type apiError struct {
+	statusCode int
+	msg        string
+	err        error
+	retryAfter time.Duration
+	details    []FieldError
+}
+
+func (e *apiError) Error() string {
+	return e.msg + " " + e.err.Error()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+// writeError is a helper function for returning an error that was returned by the shared API logic
+func writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	var ae *apiError
+	if !errors.As(err, &ae) {
+		internalServerError(ctx, w, err, "internal error")
+		return
+	}
+	if ae.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(ae.retryAfter)))
+	}
+	switch ae.statusCode {
+	case http.StatusBadRequest:
+		badRequestError(ctx, w, ae.err, ae.msg)
+	case http.StatusUnauthorized:
+		unauthorizedError(ctx, w, ae.err, ae.msg)
+	case http.StatusForbidden:
+		forbiddenError(ctx, w, ae.err, ae.msg)
+	case http.StatusNotFound:
+		notFoundError(ctx, w, ae.err, ae.msg)
+	case http.StatusConflict:
+		conflictError(ctx, w, ae.err, ae.msg)
+	case http.StatusTooManyRequests:
+		tooManyRequestsError(ctx, w, ae.err, ae.msg)
+	default:
+		internalServerError(ctx, w, ae.err, ae.msg)
+	}
+}
+
+// newApiError returns a new apiError with the given HTTP status code
+func newApiError(statusCode int, err error, msg string) *apiError {
+	return &apiError{statusCode: statusCode, msg: msg, err: err}
+}
+
+// newValidationError returns a new bad request apiError for the given invalid fields
+func newValidationError(details []FieldError) *apiError {
+	fields := make([]string, 0, len(details))
+	for _, d := range details {
+		fields = append(fields, fmt.Sprintf("%s: %s", d.Field, d.Message))
+	}
+	ae := newApiError(http.StatusBadRequest, errors.New(strings.Join(fields, ", ")), "invalid arguments")
+	ae.details = details
+	return ae
+}
+
+// retryAfterSeconds returns the duration in whole seconds, rounded up, since Retry-After does not support fractions
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
@@ -0,0 +1,330 @@
+package http
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// jwtLeeway is the allowed clock skew when validating the exp and nbf claims
+	jwtLeeway = time.Minute
+	// jwksReloadInterval is the minimum interval between reloads of the JWKS file
+	// the file is reloaded when a JWT is signed with an unknown key, so keys can be rotated without restarting the API server
+	jwksReloadInterval = time.Minute
+)
+
+// jwtVerifier verifies JWTs that are signed with one of the keys of a local JWKS file
+// supported algorithms are RS256, RS384, RS512, ES256, ES384 and ES512
+type jwtVerifier struct {
+	jwksFile string
+	issuer   string
+	audience string
+
+	mux        sync.RWMutex
+	keys       map[string]crypto.PublicKey
+	lastReload time.Time
+	now        func() time.Time
+}
+
+// jwtHeader is the header of a JWT
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims contains the claims of a JWT that are used by thundernetes
+// titleIDs and buildIDs limit the GameServerBuilds that the caller is allowed to use
+type jwtClaims struct {
+	Subject   string       `json:"sub"`
+	Issuer    string       `json:"iss"`
+	Audience  jwtAudience  `json:"aud"`
+	ExpiresAt *json.Number `json:"exp"`
+	NotBefore *json.Number `json:"nbf"`
+	TitleIDs  []string     `json:"titleIDs"`
+	BuildIDs  []string     `json:"buildIDs"`
+}
+
+// jwtAudience is the aud claim, which can either be a string or an array of strings
+type jwtAudience []string
+
+func (a *jwtAudience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = []string{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// jwk is a JSON Web Key, only the fields of RSA and EC public keys are used
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// newJwtVerifier creates a new jwtVerifier and loads the JWKS file
+func newJwtVerifier(jwksFile, issuer, audience string) (*jwtVerifier, error) {
+	v := &jwtVerifier{
+		jwksFile: jwksFile,
+		issuer:   issuer,
+		audience: audience,
+		now:      time.Now,
+	}
+	if err := v.loadKeys(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// loadKeys loads the public keys of the JWKS file
+func (v *jwtVerifier) loadKeys() error {
+	b, err := ioutil.ReadFile(v.jwksFile)
+	if err != nil {
+		return err
+	}
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &jwks); err != nil {
+		return fmt.Errorf("invalid JWKS file %s: %w", v.jwksFile, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			return fmt.Errorf("invalid key %s in JWKS file %s: %w", k.Kid, v.jwksFile, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	v.keys = keys
+	v.lastReload = v.now()
+	return nil
+}
+
+// getKey returns the public key with the given key ID
+// if kid is empty and the JWKS contains a single key, that key is returned
+func (v *jwtVerifier) getKey(kid string) (crypto.PublicKey, bool) {
+	v.mux.RLock()
+	defer v.mux.RUnlock()
+	if kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, true
+		}
+	}
+	k, ok := v.keys[kid]
+	return k, ok
+}
+
+// verify verifies the signature and the claims of a JWT and returns the caller it belongs to
+func (v *jwtVerifier) verify(token string) (*caller, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed JWT")
+	}
+
+	var header jwtHeader
+	if err := decodeJwtSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid JWT header: %w", err)
+	}
+
+	key, ok := v.getKey(header.Kid)
+	if !ok {
+		if err := v.reloadKeysIfNeeded(); err != nil {
+			return nil, err
+		}
+		if key, ok = v.getKey(header.Kid); !ok {
+			return nil, fmt.Errorf("unknown JWT key %s", header.Kid)
+		}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT signature: %w", err)
+	}
+	if err := verifyJwtSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeJwtSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	if err := v.validateClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &caller{
+		name:     claims.Subject,
+		titleIDs: claims.TitleIDs,
+		buildIDs: claims.BuildIDs,
+	}, nil
+}
+
+// reloadKeysIfNeeded reloads the JWKS file, at most once every jwksReloadInterval
+func (v *jwtVerifier) reloadKeysIfNeeded() error {
+	v.mux.RLock()
+	lastReload := v.lastReload
+	v.mux.RUnlock()
+	if v.now().Sub(lastReload) < jwksReloadInterval {
+		return nil
+	}
+	return v.loadKeys()
+}
+
+// validateClaims validates the time based claims, as well as the issuer and the audience if they are configured
+func (v *jwtVerifier) validateClaims(claims *jwtClaims) error {
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return errors.New("JWT does not contain an exp claim")
+	}
+	exp, err := claims.ExpiresAt.Int64()
+	if err != nil {
+		return fmt.Errorf("invalid exp claim: %w", err)
+	}
+	if now.After(time.Unix(exp, 0).Add(jwtLeeway)) {
+		return errors.New("JWT has expired")
+	}
+	if claims.NotBefore != nil {
+		nbf, err := claims.NotBefore.Int64()
+		if err != nil {
+			return fmt.Errorf("invalid nbf claim: %w", err)
+		}
+		if now.Add(jwtLeeway).Before(time.Unix(nbf, 0)) {
+			return errors.New("JWT is not valid yet")
+		}
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("invalid JWT issuer %s", claims.Issuer)
+	}
+	if v.audience != "" && (len(claims.Audience) == 0 || !containsOrEmpty(claims.Audience, v.audience)) {
+		return errors.New("invalid JWT audience")
+	}
+	if claims.Subject == "" {
+		return errors.New("JWT does not contain a sub claim")
+	}
+	return nil
+}
+
+// verifyJwtSignature verifies the signature of a JWT with the given algorithm and public key
+func verifyJwtSignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %s", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("JWT algorithm %s does not match the RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return errors.New("invalid JWT signature")
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("JWT algorithm %s does not match the EC key", alg)
+		}
+		// the signature is the concatenation of the fixed size r and s values
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid JWT signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("invalid JWT signature")
+		}
+	default:
+		return errors.New("unsupported JWT key type")
+	}
+	return nil
+}
+
+// publicKey returns the RSA or EC public key of the JWK
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+// decodeJwtSegment decodes a base64url encoded JSON segment of a JWT
+func decodeJwtSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(strings.NewReader(string(b)))
+	d.UseNumber()
+	return d.Decode(v)
+}
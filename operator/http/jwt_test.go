@@ -0,0 +1,155 @@
+package http
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JWT verification tests", func() {
+	var (
+		dir      string
+		rsaKey   *rsa.PrivateKey
+		ecKey    *ecdsa.PrivateKey
+		verifier *jwtVerifier
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "jwks")
+		Expect(err).ToNot(HaveOccurred())
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		jwks := map[string][]jwk{"keys": {
+			{Kty: "RSA", Kid: "rsa", N: encodeSegment(rsaKey.N.Bytes()), E: encodeSegment(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{Kty: "EC", Kid: "ec", Crv: "P-256", X: encodeSegment(ecKey.X.Bytes()), Y: encodeSegment(ecKey.Y.Bytes())},
+		}}
+		b, err := json.Marshal(jwks)
+		Expect(err).ToNot(HaveOccurred())
+		jwksFile := filepath.Join(dir, "jwks.json")
+		Expect(ioutil.WriteFile(jwksFile, b, 0600)).To(Succeed())
+
+		verifier, err = newJwtVerifier(jwksFile, "https://issuer", "thundernetes")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should accept a valid RSA signed JWT", func() {
+		token := signTestJwt("RS256", "rsa", rsaKey, newTestJwtClaims())
+		c, err := verifier.verify(token)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.name).To(Equal("matchmaker"))
+		Expect(c.titleIDs).To(Equal([]string{"testTitleID"}))
+		Expect(c.isAllowed("testTitleID", buildID1)).To(BeTrue())
+		Expect(c.isAllowed("otherTitleID", buildID1)).To(BeFalse())
+	})
+	It("should accept a valid EC signed JWT", func() {
+		token := signTestJwt("ES256", "ec", ecKey, newTestJwtClaims())
+		_, err := verifier.verify(token)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should reject an expired JWT", func() {
+		claims := newTestJwtClaims()
+		claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		_, err := verifier.verify(signTestJwt("RS256", "rsa", rsaKey, claims))
+		Expect(err).To(MatchError("JWT has expired"))
+	})
+	It("should reject a JWT without an exp claim", func() {
+		claims := newTestJwtClaims()
+		delete(claims, "exp")
+		_, err := verifier.verify(signTestJwt("RS256", "rsa", rsaKey, claims))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should reject a JWT for another audience", func() {
+		claims := newTestJwtClaims()
+		claims["aud"] = []string{"other"}
+		_, err := verifier.verify(signTestJwt("RS256", "rsa", rsaKey, claims))
+		Expect(err).To(MatchError("invalid JWT audience"))
+	})
+	It("should reject a JWT from another issuer", func() {
+		claims := newTestJwtClaims()
+		claims["iss"] = "https://other"
+		_, err := verifier.verify(signTestJwt("RS256", "rsa", rsaKey, claims))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should reject a JWT with an invalid signature", func() {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = verifier.verify(signTestJwt("RS256", "rsa", otherKey, newTestJwtClaims()))
+		Expect(err).To(MatchError("invalid JWT signature"))
+	})
+	It("should reject a JWT whose algorithm does not match the key", func() {
+		_, err := verifier.verify(signTestJwt("ES256", "rsa", ecKey, newTestJwtClaims()))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should reject an unsigned JWT", func() {
+		_, err := verifier.verify(signTestJwt("none", "rsa", nil, newTestJwtClaims()))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should reject a JWT signed with an unknown key", func() {
+		_, err := verifier.verify(signTestJwt("RS256", "unknown", rsaKey, newTestJwtClaims()))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func newTestJwtClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":      "matchmaker",
+		"iss":      "https://issuer",
+		"aud":      "thundernetes",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+		"titleIDs": []string{"testTitleID"},
+	}
+}
+
+// signTestJwt returns a JWT with the given claims, signed with the given key
+// the key is not used for the "none" algorithm
+func signTestJwt(alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	header, err := json.Marshal(jwtHeader{Alg: alg, Kid: kid})
+	Expect(err).ToNot(HaveOccurred())
+	payload, err := json.Marshal(claims)
+	Expect(err).ToNot(HaveOccurred())
+	signed := encodeSegment(header) + "." + encodeSegment(payload)
+	if key == nil {
+		return signed + "."
+	}
+
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		Expect(err).ToNot(HaveOccurred())
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		Expect(err).ToNot(HaveOccurred())
+		// the signature is the concatenation of the fixed size r and s values
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + encodeSegment(signature)
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
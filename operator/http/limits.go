@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/playfab/thundernetes/operator/controllers"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// defaultLimitsKey is the key of the limits that apply to the TitleIDs and BuildIDs without their own limits
+	defaultLimitsKey = "*"
+	// activeQuotaRetryAfter is the Retry-After of the allocations that are rejected because of the maximum number of Active sessions
+	// Active sessions end on their own, so there is no exact time to suggest
+	activeQuotaRetryAfter = 5 * time.Second
+
+	throttleReasonRateLimit   = "RateLimit"
+	throttleReasonActiveQuota = "ActiveQuota"
+)
+
+// AllocationLimits contains the allocation limits per TitleID and per BuildID
+// the limits with the "*" key apply to every TitleID or BuildID that does not have its own limits
+type AllocationLimits struct {
+	Titles map[string]Limits `json:"titles,omitempty"`
+	Builds map[string]Limits `json:"builds,omitempty"`
+}
+
+// Limits are the allocation limits of a TitleID or a BuildID, a zero value means unlimited
+type Limits struct {
+	// AllocationsPerSecond is the rate at which the tokens of the token bucket are refilled
+	AllocationsPerSecond float64 `json:"allocationsPerSecond,omitempty"`
+	// Burst is the size of the token bucket, it defaults to AllocationsPerSecond rounded up
+	Burst int `json:"burst,omitempty"`
+	// MaxActive is the maximum number of concurrent Active sessions
+	MaxActive int `json:"maxActive,omitempty"`
+}
+
+// ReadAllocationLimitsFile reads the AllocationLimits from a YAML or JSON file
+func ReadAllocationLimitsFile(path string) (*AllocationLimits, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var limits AllocationLimits
+	if err := yaml.UnmarshalStrict(b, &limits); err != nil {
+		return nil, fmt.Errorf("invalid allocation limits file %s: %w", path, err)
+	}
+	for _, m := range []map[string]Limits{limits.Titles, limits.Builds} {
+		for k, l := range m {
+			if l.AllocationsPerSecond < 0 || l.Burst < 0 || l.MaxActive < 0 {
+				return nil, fmt.Errorf("invalid allocation limits for %s: limits cannot be negative", k)
+			}
+		}
+	}
+	return &limits, nil
+}
+
+// allocationLimiter enforces the AllocationLimits on the allocations
+// every TitleID and BuildID gets its own token bucket, which is created on its first allocation
+type allocationLimiter struct {
+	limits AllocationLimits
+	mux    sync.Mutex
+	titles map[string]*rate.Limiter
+	builds map[string]*rate.Limiter
+	now    func() time.Time
+}
+
+// newAllocationLimiter creates a new allocationLimiter
+func newAllocationLimiter(limits *AllocationLimits) *allocationLimiter {
+	return &allocationLimiter{
+		limits: *limits,
+		titles: make(map[string]*rate.Limiter),
+		builds: make(map[string]*rate.Limiter),
+		now:    time.Now,
+	}
+}
+
+// getLimits returns the limits for the key, or the default limits if the key has no limits of its own
+func getLimits(m map[string]Limits, key string) Limits {
+	if l, ok := m[key]; ok {
+		return l
+	}
+	return m[defaultLimitsKey]
+}
+
+// getRateLimiter returns the token bucket for the key, or nil if there is no rate limit
+// it must be called with the mutex held
+func getRateLimiter(limiters map[string]*rate.Limiter, l Limits, key string) *rate.Limiter {
+	if l.AllocationsPerSecond == 0 {
+		return nil
+	}
+	if limiter, ok := limiters[key]; ok {
+		return limiter
+	}
+	burst := l.Burst
+	if burst == 0 {
+		burst = int(math.Ceil(l.AllocationsPerSecond))
+	}
+	limiter := rate.NewLimiter(rate.Limit(l.AllocationsPerSecond), burst)
+	limiters[key] = limiter
+	return limiter
+}
+
+// allocationReservation holds the tokens that an allowed allocation took from the token buckets
+type allocationReservation struct {
+	reservations []*rate.Reservation
+	now          func() time.Time
+}
+
+// cancel returns the tokens to the token buckets, it's used when the allowed allocation does not happen
+// so that the retries of the allocations that fail, e.g. while there are no StandingBy servers, do not use up the rate limit
+func (r *allocationReservation) cancel() {
+	if r == nil {
+		return
+	}
+	now := r.now()
+	for _, res := range r.reservations {
+		res.CancelAt(now)
+	}
+}
+
+// allow checks the limits of the TitleID and the BuildID of the GameServerBuild before an allocation from it
+// it takes a token from both token buckets only if the allocation is allowed, and returns them in the reservation
+// the Active sessions are counted from the cache, so concurrent allocations can exceed MaxActive by a few sessions
+// the token buckets are kept in memory, so every replica of the API server enforces the limits on its own
+func (l *allocationLimiter) allow(ctx context.Context, c client.Client, gsb *mpsv1alpha1.GameServerBuild) (*allocationReservation, error) {
+	titleLimits := getLimits(l.limits.Titles, gsb.Spec.TitleID)
+	buildLimits := getLimits(l.limits.Builds, gsb.Spec.BuildID)
+
+	if titleLimits.MaxActive > 0 || buildLimits.MaxActive > 0 {
+		titleActive, buildActive, err := countActiveGameServers(ctx, c, gsb.Spec.TitleID, gsb.Spec.BuildID)
+		if err != nil {
+			return nil, newApiError(http.StatusInternalServerError, err, "error listing")
+		}
+		if titleLimits.MaxActive > 0 && titleActive >= titleLimits.MaxActive {
+			return nil, l.throttled(gsb, throttleReasonActiveQuota, activeQuotaRetryAfter,
+				fmt.Errorf("TitleID %s has %d Active sessions", gsb.Spec.TitleID, titleActive), "maximum number of Active sessions reached")
+		}
+		if buildLimits.MaxActive > 0 && buildActive >= buildLimits.MaxActive {
+			return nil, l.throttled(gsb, throttleReasonActiveQuota, activeQuotaRetryAfter,
+				fmt.Errorf("BuildID %s has %d Active sessions", gsb.Spec.BuildID, buildActive), "maximum number of Active sessions reached")
+		}
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	now := l.now()
+	var reservations []*rate.Reservation
+	for _, limiter := range []*rate.Limiter{
+		getRateLimiter(l.titles, titleLimits, gsb.Spec.TitleID),
+		getRateLimiter(l.builds, buildLimits, gsb.Spec.BuildID),
+	} {
+		if limiter == nil {
+			continue
+		}
+		r := limiter.ReserveN(now, 1)
+		reservations = append(reservations, r)
+		if delay := r.DelayFrom(now); delay > 0 {
+			// return the tokens, the allocation does not happen
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+			return nil, l.throttled(gsb, throttleReasonRateLimit, delay,
+				fmt.Errorf("allocation rate limit of TitleID %s or BuildID %s exceeded", gsb.Spec.TitleID, gsb.Spec.BuildID), "allocation rate limit exceeded")
+		}
+	}
+	return &allocationReservation{reservations: reservations, now: l.now}, nil
+}
+
+// throttled updates the throttled allocations metric and returns a 429 error with the Retry-After
+func (l *allocationLimiter) throttled(gsb *mpsv1alpha1.GameServerBuild, reason string, retryAfter time.Duration, err error, msg string) error {
+	controllers.AllocationsThrottledCounter.WithLabelValues(gsb.Name, gsb.Spec.TitleID, reason).Inc()
+	ae := newApiError(http.StatusTooManyRequests, err, msg)
+	ae.retryAfter = retryAfter
+	return ae
+}
+
+// countActiveGameServers returns the number of Active GameServers of the TitleID and of the BuildID
+func countActiveGameServers(ctx context.Context, c client.Client, titleID, buildID string) (int, int, error) {
+	var gameServers mpsv1alpha1.GameServerList
+	err := c.List(ctx, &gameServers, &client.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"status.state": string(mpsv1alpha1.GameServerStateActive)}),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	var titleActive, buildActive int
+	for _, gs := range gameServers.Items {
+		if gs.Status.State != mpsv1alpha1.GameServerStateActive {
+			continue
+		}
+		if gs.Spec.TitleID == titleID {
+			titleActive++
+		}
+		if gs.Spec.BuildID == buildID {
+			buildActive++
+		}
+	}
+	return titleActive, buildActive, nil
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("allocation limits tests", func() {
+	var (
+		k8sClient client.Client
+		gsb       *mpsv1alpha1.GameServerBuild
+		now       time.Time
+	)
+
+	BeforeEach(func() {
+		k8sClient = newTestSimpleK8s()
+		now = time.Now()
+		gsb = &mpsv1alpha1.GameServerBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: buildName1, Namespace: "default"},
+			Spec:       mpsv1alpha1.GameServerBuildSpec{TitleID: "testTitleID", BuildID: buildID1},
+		}
+	})
+
+	newLimiter := func(limits *AllocationLimits) *allocationLimiter {
+		l := newAllocationLimiter(limits)
+		l.now = func() time.Time { return now }
+		return l
+	}
+
+	// allow checks the limits and drops the reservation, the tokens of an allowed allocation are kept
+	allow := func(l *allocationLimiter, gsb *mpsv1alpha1.GameServerBuild) error {
+		_, err := l.allow(context.Background(), k8sClient, gsb)
+		return err
+	}
+
+	It("should allow allocations without limits", func() {
+		l := newLimiter(&AllocationLimits{})
+		for i := 0; i < 10; i++ {
+			Expect(allow(l, gsb)).To(Succeed())
+		}
+	})
+	It("should rate limit the allocations of a build", func() {
+		l := newLimiter(&AllocationLimits{Builds: map[string]Limits{buildID1: {AllocationsPerSecond: 1, Burst: 2}}})
+		Expect(allow(l, gsb)).To(Succeed())
+		Expect(allow(l, gsb)).To(Succeed())
+		err := allow(l, gsb)
+		var ae *apiError
+		Expect(err).To(BeAssignableToTypeOf(ae))
+		ae = err.(*apiError)
+		Expect(ae.statusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(ae.retryAfter).To(Equal(time.Second))
+
+		// the bucket is refilled over time
+		now = now.Add(time.Second)
+		Expect(allow(l, gsb)).To(Succeed())
+	})
+	It("should apply the default limits to titles without their own limits", func() {
+		l := newLimiter(&AllocationLimits{Titles: map[string]Limits{
+			defaultLimitsKey: {AllocationsPerSecond: 1},
+			"otherTitleID":   {AllocationsPerSecond: 100},
+		}})
+		Expect(allow(l, gsb)).To(Succeed())
+		Expect(allow(l, gsb)).ToNot(Succeed())
+
+		// every title has its own token bucket
+		other := gsb.DeepCopy()
+		other.Spec.TitleID = "anotherTitleID"
+		Expect(allow(l, other)).To(Succeed())
+	})
+	It("should not take a token from the title when the build is rate limited", func() {
+		l := newLimiter(&AllocationLimits{
+			Titles: map[string]Limits{"testTitleID": {AllocationsPerSecond: 1, Burst: 2}},
+			Builds: map[string]Limits{buildID1: {AllocationsPerSecond: 1}},
+		})
+		Expect(allow(l, gsb)).To(Succeed())
+		Expect(allow(l, gsb)).ToNot(Succeed())
+		other := gsb.DeepCopy()
+		other.Spec.BuildID = "3b4a7d11-1b6a-4c1a-8b2f-6a5d8f0e4c21"
+		Expect(allow(l, other)).To(Succeed())
+	})
+	It("should limit the Active sessions of a title", func() {
+		for i, buildID := range []string{buildID1, "3b4a7d11-1b6a-4c1a-8b2f-6a5d8f0e4c21"} {
+			gs := &mpsv1alpha1.GameServer{
+				ObjectMeta: metav1.ObjectMeta{Name: gsName + string(rune('a'+i)), Namespace: "default"},
+				Spec:       mpsv1alpha1.GameServerSpec{TitleID: "testTitleID", BuildID: buildID},
+				Status:     mpsv1alpha1.GameServerStatus{State: mpsv1alpha1.GameServerStateActive},
+			}
+			Expect(k8sClient.Create(context.Background(), gs)).To(Succeed())
+		}
+		l := newLimiter(&AllocationLimits{Titles: map[string]Limits{"testTitleID": {MaxActive: 3}}})
+		Expect(allow(l, gsb)).To(Succeed())
+		l = newLimiter(&AllocationLimits{Titles: map[string]Limits{"testTitleID": {MaxActive: 2}}})
+		err := allow(l, gsb)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*apiError).retryAfter).To(Equal(activeQuotaRetryAfter))
+		l = newLimiter(&AllocationLimits{Builds: map[string]Limits{buildID1: {MaxActive: 2}}})
+		Expect(allow(l, gsb)).To(Succeed())
+	})
+	It("should return the tokens of a cancelled reservation", func() {
+		l := newLimiter(&AllocationLimits{Titles: map[string]Limits{"testTitleID": {AllocationsPerSecond: 1}}, Builds: map[string]Limits{buildID1: {AllocationsPerSecond: 1}}})
+		r, err := l.allow(context.Background(), k8sClient, gsb)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(allow(l, gsb)).ToNot(Succeed())
+		r.cancel()
+		Expect(allow(l, gsb)).To(Succeed())
+	})
+	It("should not take a token when there are no StandingBy servers to allocate", func() {
+		Expect(k8sClient.Create(context.Background(), gsb)).To(Succeed())
+		l := newLimiter(&AllocationLimits{Builds: map[string]Limits{buildID1: {AllocationsPerSecond: 1}}})
+		for i := 0; i < 3; i++ {
+			_, err := allocate(context.Background(), k8sClient, l, &AllocateArgs{SessionID: sessionID1, BuildID: buildID1})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not enough standingBy"))
+		}
+	})
+	It("should return TooManyRequests with Retry-After from the allocate handler", func() {
+		Expect(k8sClient.Create(context.Background(), gsb)).To(Succeed())
+		// the fake client ignores field selectors, so the Active GameServer does not have the BuildID label
+		// to not be mistaken for an earlier allocation of the same session
+		gs := &mpsv1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: gsName, Namespace: "default"},
+			Spec:       mpsv1alpha1.GameServerSpec{TitleID: "testTitleID", BuildID: buildID1},
+			Status:     mpsv1alpha1.GameServerStatus{State: mpsv1alpha1.GameServerStateActive},
+		}
+		Expect(k8sClient.Create(context.Background(), gs)).To(Succeed())
+		h := &allocateHandler{
+			client:  k8sClient,
+			limiter: newLimiter(&AllocationLimits{Builds: map[string]Limits{buildID1: {MaxActive: 1}}}),
+		}
+		b, _ := json.Marshal(AllocateArgs{SessionID: sessionID1, BuildID: buildID1})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/allocate", bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusTooManyRequests))
+		Expect(res.Header.Get("Retry-After")).To(Equal("5"))
+	})
+	It("should return the retry delay on ResourceExhausted gRPC errors", func() {
+		ae := newApiError(http.StatusTooManyRequests, errors.New("rate limit"), "allocation rate limit exceeded")
+		ae.retryAfter = 1500 * time.Millisecond
+		st := status.Convert(grpcError(context.Background(), ae))
+		Expect(st.Code()).To(Equal(codes.ResourceExhausted))
+		Expect(st.Details()).To(HaveLen(1))
+		Expect(st.Details()[0].(*errdetails.RetryInfo).RetryDelay.AsDuration()).To(Equal(1500 * time.Millisecond))
+		Expect(retryAfterSeconds(ae.retryAfter)).To(Equal(2))
+	})
+	It("should read the allocation limits file", func() {
+		dir, err := ioutil.TempDir("", "limits")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "limits.yaml")
+
+		Expect(ioutil.WriteFile(path, []byte("titles:\n  \"*\":\n    allocationsPerSecond: 10\n    burst: 20\nbuilds:\n  "+buildID1+":\n    maxActive: 100\n"), 0600)).To(Succeed())
+		limits, err := ReadAllocationLimitsFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(limits.Titles[defaultLimitsKey]).To(Equal(Limits{AllocationsPerSecond: 10, Burst: 20}))
+		Expect(limits.Builds[buildID1]).To(Equal(Limits{MaxActive: 100}))
+
+		Expect(ioutil.WriteFile(path, []byte("titles:\n  \"*\":\n    maxActive: -1\n"), 0600)).To(Succeed())
+		_, err = ReadAllocationLimitsFile(path)
+		Expect(err).To(HaveOccurred())
+
+		Expect(ioutil.WriteFile(path, []byte("titles:\n  \"*\":\n    allocationsPerMinute: 1\n"), 0600)).To(Succeed())
+		_, err = ReadAllocationLimitsFile(path)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,284 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/playfab/thundernetes/operator/controllers"
+)
+
+const (
+	metricsApiListeningPort = 6443
+	// DefaultMetricsApiCertDir is the directory of the serving certificate of the metrics API, it's mounted from the Secret of a cert-manager Certificate
+	DefaultMetricsApiCertDir = "/tmp/k8s-metrics-api/serving-certs"
+	// metricsSamplingPeriod is the period of the sampling of the allocation counters
+	metricsSamplingPeriod = 10 * time.Second
+	// the ConfigMap with the CA and the headers that the API server uses when proxying requests to aggregated APIs
+	extensionApiserverAuthenticationNamespace = "kube-system"
+	extensionApiserverAuthenticationName      = "extension-apiserver-authentication"
+)
+
+// MetricsApiOptions configures the metrics API server
+type MetricsApiOptions struct {
+	// CertDir is the directory with the serving certificate (tls.crt) and private key (tls.key)
+	CertDir string
+}
+
+// MetricsApiServer serves the custom (custom.metrics.k8s.io) and the external (external.metrics.k8s.io) metrics APIs
+// for the GameServerBuilds, so that HorizontalPodAutoscalers and other autoscalers can scale them on their metrics
+// it is registered as an aggregated API with APIServices, so the Kubernetes API server proxies the requests to it
+// the requests are authenticated with the request header client certificate of the Kubernetes API server
+// and the users on whose behalf they are made are authorized with SubjectAccessReviews
+type MetricsApiServer struct {
+	client      client.Client
+	handler     *metricsApiHandler
+	certificate *certificateFiles
+	// requestHeader contains the CA and the headers that the Kubernetes API server uses to proxy the requests
+	requestHeader *requestHeaderOptions
+}
+
+// requestHeaderOptions contains the request header authentication options of the Kubernetes API server
+type requestHeaderOptions struct {
+	clientCAs       *x509.CertPool
+	allowedNames    []string
+	usernameHeaders []string
+	groupHeaders    []string
+}
+
+// NewMetricsApiServer creates a new MetricsApiServer and adds it to the manager
+func NewMetricsApiServer(mgr ctrl.Manager, options *MetricsApiOptions) error {
+	// the cache is not started yet, so we use the API reader
+	requestHeader, err := loadRequestHeaderOptions(context.Background(), mgr.GetAPIReader())
+	if err != nil {
+		return err
+	}
+	server := &MetricsApiServer{
+		client: mgr.GetClient(),
+		handler: &metricsApiHandler{
+			client:      mgr.GetClient(),
+			allocations: newCounterRates(controllers.AllocationsCounter, metricsRateWindow),
+			unfulfilled: newCounterRates(controllers.AllocationsUnfulfilledCounter, metricsRateWindow),
+		},
+		certificate: &certificateFiles{
+			certFile: filepath.Join(options.CertDir, certificateFileName),
+			keyFile:  filepath.Join(options.CertDir, privateKeyFileName),
+		},
+		requestHeader: requestHeader,
+	}
+	// fail early if the certificate is not mounted
+	if _, err := server.certificate.getCertificate(nil); err != nil {
+		return err
+	}
+	return mgr.Add(server)
+}
+
+// loadRequestHeaderOptions reads the request header authentication options from the extension-apiserver-authentication ConfigMap
+func loadRequestHeaderOptions(ctx context.Context, reader client.Reader) (*requestHeaderOptions, error) {
+	var cm corev1.ConfigMap
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: extensionApiserverAuthenticationNamespace, Name: extensionApiserverAuthenticationName}, &cm); err != nil {
+		return nil, err
+	}
+	caBundle := cm.Data["requestheader-client-ca-file"]
+	if caBundle == "" {
+		return nil, errors.New("the Kubernetes API server is not configured with a request header client CA")
+	}
+	options := &requestHeaderOptions{clientCAs: x509.NewCertPool()}
+	if !options.clientCAs.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, errors.New("request header client CA does not contain any valid PEM encoded certificates")
+	}
+	// the lists are JSON arrays
+	for key, value := range map[string]*[]string{
+		"requestheader-allowed-names":    &options.allowedNames,
+		"requestheader-username-headers": &options.usernameHeaders,
+		"requestheader-group-headers":    &options.groupHeaders,
+	} {
+		if cm.Data[key] == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(cm.Data[key]), value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	if len(options.usernameHeaders) == 0 {
+		options.usernameHeaders = []string{"X-Remote-User"}
+	}
+	if len(options.groupHeaders) == 0 {
+		options.groupHeaders = []string{"X-Remote-Group"}
+	}
+	return options, nil
+}
+
+// NeedLeaderElection returns false since all the controller Pods serve the metrics API
+func (s *MetricsApiServer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start samples the allocation counters and serves the metrics API until the context is done
+func (s *MetricsApiServer) Start(ctx context.Context) error {
+	log := log.FromContext(ctx)
+	addr := os.Getenv("METRICS_API_LISTEN")
+	if addr == "" {
+		addr = fmt.Sprintf(":%d", metricsApiListeningPort)
+	}
+
+	go func() {
+		ticker := time.NewTicker(metricsSamplingPeriod)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			s.handler.allocations.sample(now)
+			s.handler.unfulfilled.sample(now)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: s.authorize(s.handler),
+	}
+	done := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		log.Info("shutting down metrics API server")
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Error(err, "error shutting down the metrics API server")
+		}
+		close(done)
+	}()
+
+	log.Info("serving metrics API server", "addr", addr)
+	tlsConfig := &tls.Config{
+		GetCertificate: s.certificate.getCertificate,
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		ClientCAs:      s.requestHeader.clientCAs,
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"http/1.1"},
+		VerifyPeerCertificate: func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+				return errors.New("no verified client certificate")
+			}
+			return verifyClientCertificate(verifiedChains[0][0], &ClientAuthOptions{AllowedSubjects: s.requestHeader.allowedNames})
+		},
+	}
+	if err := customListenAndServeTLS(srv, tlsConfig); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	<-done
+	return nil
+}
+
+// authorize authorizes the user of every request with a SubjectAccessReview, before it reaches the handler
+// the user and the groups are set by the Kubernetes API server in the request headers, which can be trusted since the client certificate was verified
+func (s *MetricsApiServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var user string
+		for _, header := range s.requestHeader.usernameHeaders {
+			if user = r.Header.Get(header); user != "" {
+				break
+			}
+		}
+		if user == "" {
+			writeStatus(ctx, w, apierrors.NewUnauthorized("the request does not have a user"))
+			return
+		}
+		var groups []string
+		for _, header := range s.requestHeader.groupHeaders {
+			groups = append(groups, r.Header.Values(header)...)
+		}
+
+		sar := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user,
+				Groups: groups,
+			},
+		}
+		req, ok := parseMetricsRequest(r.URL.Path)
+		if !ok || req.discovery {
+			sar.Spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{Path: r.URL.Path, Verb: "get"}
+		} else if req.group == customMetricsGroup {
+			sar.Spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+				Namespace:   req.namespace,
+				Verb:        "get",
+				Group:       req.group,
+				Resource:    gameServerBuildsGroup,
+				Subresource: req.metric,
+				Name:        req.name,
+			}
+		} else {
+			sar.Spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+				Namespace: req.namespace,
+				Verb:      "get",
+				Group:     req.group,
+				Resource:  req.metric,
+			}
+		}
+		if err := s.client.Create(ctx, sar); err != nil {
+			writeStatus(ctx, w, err)
+			return
+		}
+		if !sar.Status.Allowed {
+			writeStatus(ctx, w, apierrors.NewForbidden(schema.GroupResource{}, r.URL.Path, fmt.Errorf("user %s is not allowed to get the metric: %s", user, sar.Status.Reason)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// certificateFiles loads the serving certificate from its files and reloads it when they change
+// the files are mounted from a Secret, which is updated by cert-manager when the certificate is renewed
+type certificateFiles struct {
+	certFile string
+	keyFile  string
+	mux      sync.Mutex
+	modTime  time.Time
+	cert     *tls.Certificate
+}
+
+// getCertificate returns the current serving certificate, it's used as tls.Config.GetCertificate
+// if the files changed but the new certificate is invalid, the current one keeps being used
+func (c *certificateFiles) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		if c.cert != nil {
+			return c.cert, nil
+		}
+		return nil, err
+	}
+	if c.cert == nil || !info.ModTime().Equal(c.modTime) {
+		cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			if c.cert != nil {
+				return c.cert, nil
+			}
+			return nil, err
+		}
+		c.cert = &cert
+		c.modTime = info.ModTime()
+	}
+	return c.cert, nil
+}
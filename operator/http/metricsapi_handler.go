@@ -0,0 +1,381 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/playfab/thundernetes/operator/controllers"
+)
+
+const (
+	customMetricsGroup    = "custom.metrics.k8s.io"
+	externalMetricsGroup  = "external.metrics.k8s.io"
+	metricsVersion        = "v1beta1"
+	gameServerBuildsGroup = "gameserverbuilds.mps.playfab.com"
+	// metricsRateWindow is the window over which the allocation rates are computed
+	metricsRateWindow = time.Minute
+)
+
+// buildMetric is a metric of a GameServerBuild that is served by the custom and the external metrics APIs
+type buildMetric struct {
+	name string
+	// windowSeconds is the window of the rate metrics, 0 for the metrics that are read from the status
+	windowSeconds int64
+	value         func(h *metricsApiHandler, gsb *mpsv1alpha1.GameServerBuild) float64
+}
+
+// buildMetrics are the metrics of every GameServerBuild
+// the counts and the ratio come from the GameServerBuild status, the rates from the allocation counters of the API server
+// the allocation counters are kept in memory, so the rates only contain the allocations of this replica, they are accurate with a single replica
+var buildMetrics = []buildMetric{
+	{name: "standingby_gameservers", value: func(_ *metricsApiHandler, gsb *mpsv1alpha1.GameServerBuild) float64 {
+		return float64(gsb.Status.CurrentStandingBy)
+	}},
+	{name: "active_gameservers", value: func(_ *metricsApiHandler, gsb *mpsv1alpha1.GameServerBuild) float64 {
+		return float64(gsb.Status.CurrentActive)
+	}},
+	{name: "standingby_ratio", value: func(_ *metricsApiHandler, gsb *mpsv1alpha1.GameServerBuild) float64 {
+		total := gsb.Status.CurrentStandingBy + gsb.Status.CurrentActive
+		if total == 0 {
+			return 0
+		}
+		return float64(gsb.Status.CurrentStandingBy) / float64(total)
+	}},
+	{name: "active_ratio", value: func(_ *metricsApiHandler, gsb *mpsv1alpha1.GameServerBuild) float64 {
+		total := gsb.Status.CurrentStandingBy + gsb.Status.CurrentActive
+		if total == 0 {
+			return 0
+		}
+		return float64(gsb.Status.CurrentActive) / float64(total)
+	}},
+	{name: "allocations_per_second", windowSeconds: int64(metricsRateWindow.Seconds()), value: func(h *metricsApiHandler, gsb *mpsv1alpha1.GameServerBuild) float64 {
+		return h.allocations.rate(gsb.Name)
+	}},
+	{name: "allocations_unfulfilled_per_second", windowSeconds: int64(metricsRateWindow.Seconds()), value: func(h *metricsApiHandler, gsb *mpsv1alpha1.GameServerBuild) float64 {
+		return h.unfulfilled.rate(gsb.Name)
+	}},
+}
+
+// findBuildMetric returns the buildMetric with the specified name, or nil if there is none
+func findBuildMetric(name string) *buildMetric {
+	for i := range buildMetrics {
+		if buildMetrics[i].name == name {
+			return &buildMetrics[i]
+		}
+	}
+	return nil
+}
+
+// metricsRequest is a parsed request to the custom or the external metrics API
+type metricsRequest struct {
+	// group is either customMetricsGroup or externalMetricsGroup
+	group string
+	// discovery is true for the requests to the API group version, which list the available metrics
+	discovery bool
+	namespace string
+	// name is the name of the GameServerBuild for the custom metrics API, "*" for all of them
+	name   string
+	metric string
+}
+
+// parseMetricsRequest parses the path of a request to the metrics APIs
+// the supported paths are
+// /apis/custom.metrics.k8s.io/v1beta1/namespaces/{namespace}/gameserverbuilds.mps.playfab.com/{name}/{metric}
+// /apis/external.metrics.k8s.io/v1beta1/namespaces/{namespace}/{metric}
+// and the discovery paths of the two API group versions
+func parseMetricsRequest(path string) (*metricsRequest, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "apis" || parts[2] != metricsVersion {
+		return nil, false
+	}
+	req := &metricsRequest{group: parts[1]}
+	switch {
+	case len(parts) == 3 && (req.group == customMetricsGroup || req.group == externalMetricsGroup):
+		req.discovery = true
+	case len(parts) == 8 && req.group == customMetricsGroup && parts[3] == "namespaces" && parts[5] == gameServerBuildsGroup:
+		req.namespace, req.name, req.metric = parts[4], parts[6], parts[7]
+	case len(parts) == 6 && req.group == externalMetricsGroup && parts[3] == "namespaces":
+		req.namespace, req.metric = parts[4], parts[5]
+	default:
+		return nil, false
+	}
+	return req, true
+}
+
+// metricsApiHandler serves the metrics of the GameServerBuilds as the custom and the external metrics APIs
+type metricsApiHandler struct {
+	client      client.Client
+	allocations *counterRates
+	unfulfilled *counterRates
+}
+
+func (h *metricsApiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeStatus(ctx, w, apierrors.NewMethodNotSupported(schema.GroupResource{Group: customMetricsGroup}, r.Method))
+		return
+	}
+	req, ok := parseMetricsRequest(r.URL.Path)
+	if !ok {
+		writeStatus(ctx, w, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+		return
+	}
+	if req.discovery {
+		writeJSON(ctx, w, http.StatusOK, newMetricsResourceList(req.group))
+		return
+	}
+	metric := findBuildMetric(req.metric)
+	if metric == nil {
+		writeStatus(ctx, w, apierrors.NewNotFound(schema.GroupResource{Group: req.group, Resource: req.metric}, req.name))
+		return
+	}
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeStatus(ctx, w, apierrors.NewBadRequest(err.Error()))
+		return
+	}
+
+	var builds []mpsv1alpha1.GameServerBuild
+	if req.group == customMetricsGroup && req.name != "*" {
+		var gsb mpsv1alpha1.GameServerBuild
+		if err := h.client.Get(ctx, types.NamespacedName{Namespace: req.namespace, Name: req.name}, &gsb); err != nil {
+			writeStatus(ctx, w, err)
+			return
+		}
+		builds = append(builds, gsb)
+	} else {
+		var gsbList mpsv1alpha1.GameServerBuildList
+		if err := h.client.List(ctx, &gsbList, client.InNamespace(req.namespace)); err != nil {
+			writeStatus(ctx, w, err)
+			return
+		}
+		for _, gsb := range gsbList.Items {
+			if selector.Matches(buildMetricLabels(&gsb)) {
+				builds = append(builds, gsb)
+			}
+		}
+	}
+
+	now := metav1.Now()
+	if req.group == customMetricsGroup {
+		writeJSON(ctx, w, http.StatusOK, h.newMetricValueList(builds, metric, now))
+	} else {
+		writeJSON(ctx, w, http.StatusOK, h.newExternalMetricValueList(builds, metric, now))
+	}
+}
+
+// buildMetricLabels returns the labels that the label selectors of the metrics APIs are matched against
+// they are the labels of the GameServerBuild, plus the BuildName and the BuildID labels of its GameServers
+func buildMetricLabels(gsb *mpsv1alpha1.GameServerBuild) labels.Set {
+	set := labels.Set{}
+	for k, v := range gsb.Labels {
+		set[k] = v
+	}
+	set[controllers.LabelBuildName] = gsb.Name
+	set[controllers.LabelBuildID] = gsb.Spec.BuildID
+	return set
+}
+
+// newMetricValueList returns the custom metrics API response for the GameServerBuilds
+func (h *metricsApiHandler) newMetricValueList(builds []mpsv1alpha1.GameServerBuild, metric *buildMetric, now metav1.Time) *metricValueList {
+	list := &metricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "MetricValueList", APIVersion: customMetricsGroup + "/" + metricsVersion},
+		Items:    []metricValue{},
+	}
+	for i := range builds {
+		gsb := &builds[i]
+		list.Items = append(list.Items, metricValue{
+			DescribedObject: objectReference{
+				Kind:       "GameServerBuild",
+				Namespace:  gsb.Namespace,
+				Name:       gsb.Name,
+				APIVersion: mpsv1alpha1.GroupVersion.String(),
+			},
+			MetricName:    metric.name,
+			Timestamp:     now,
+			WindowSeconds: windowSeconds(metric),
+			Value:         newMetricQuantity(metric.value(h, gsb)),
+		})
+	}
+	return list
+}
+
+// newExternalMetricValueList returns the external metrics API response for the GameServerBuilds
+func (h *metricsApiHandler) newExternalMetricValueList(builds []mpsv1alpha1.GameServerBuild, metric *buildMetric, now metav1.Time) *externalMetricValueList {
+	list := &externalMetricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "ExternalMetricValueList", APIVersion: externalMetricsGroup + "/" + metricsVersion},
+		Items:    []externalMetricValue{},
+	}
+	for i := range builds {
+		gsb := &builds[i]
+		list.Items = append(list.Items, externalMetricValue{
+			MetricName:    metric.name,
+			MetricLabels:  map[string]string{controllers.LabelBuildName: gsb.Name, controllers.LabelBuildID: gsb.Spec.BuildID},
+			Timestamp:     now,
+			WindowSeconds: windowSeconds(metric),
+			Value:         newMetricQuantity(metric.value(h, gsb)),
+		})
+	}
+	return list
+}
+
+// newMetricsResourceList returns the discovery document of the custom or the external metrics API group version
+// the custom metrics are resources of the GameServerBuilds, the external metrics are resources on their own
+func newMetricsResourceList(group string) *metav1.APIResourceList {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: group + "/" + metricsVersion,
+	}
+	for _, metric := range buildMetrics {
+		if group == customMetricsGroup {
+			list.APIResources = append(list.APIResources, metav1.APIResource{Name: gameServerBuildsGroup + "/" + metric.name, Namespaced: true, Kind: "MetricValueList", Verbs: []string{"get"}})
+		} else {
+			list.APIResources = append(list.APIResources, metav1.APIResource{Name: metric.name, Namespaced: true, Kind: "ExternalMetricValueList", Verbs: []string{"get"}})
+		}
+	}
+	return list
+}
+
+// newMetricQuantity returns the value of a metric as a Quantity with a milli precision, so that ratios and rates are not rounded to integers
+func newMetricQuantity(value float64) resource.Quantity {
+	return *resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
+}
+
+// windowSeconds returns the window of a rate metric, or nil for the other metrics
+func windowSeconds(metric *buildMetric) *int64 {
+	if metric.windowSeconds == 0 {
+		return nil
+	}
+	w := metric.windowSeconds
+	return &w
+}
+
+// writeStatus writes an error as a Kubernetes Status, which is what the callers of the metrics APIs expect
+func writeStatus(ctx context.Context, w http.ResponseWriter, err error) {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok {
+		status = apierrors.NewInternalError(err)
+	}
+	s := status.Status()
+	s.TypeMeta = metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}
+	writeJSON(ctx, w, int(s.Code), s)
+}
+
+// counterRates computes the per second rates of a counter, for each BuildName, over a sliding window
+// the counter is sampled periodically, the rate is the increase between the oldest and the newest sample of the window
+type counterRates struct {
+	counter *prometheus.CounterVec
+	window  time.Duration
+	mux     sync.RWMutex
+	samples []counterSample
+}
+
+// counterSample contains the values of a counter for each BuildName at a point in time
+type counterSample struct {
+	time   time.Time
+	values map[string]float64
+}
+
+// newCounterRates creates a new counterRates for the counter
+func newCounterRates(counter *prometheus.CounterVec, window time.Duration) *counterRates {
+	return &counterRates{counter: counter, window: window}
+}
+
+// sample records the current values of the counter and drops the samples that are older than the window
+func (r *counterRates) sample(now time.Time) {
+	values := make(map[string]float64)
+	ch := make(chan prometheus.Metric)
+	go func() {
+		r.counter.Collect(ch)
+		close(ch)
+	}()
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			continue
+		}
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == controllers.LabelBuildName {
+				values[label.GetValue()] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.samples = append(r.samples, counterSample{time: now, values: values})
+	i := 0
+	for i < len(r.samples)-1 && now.Sub(r.samples[i].time) > r.window {
+		i++
+	}
+	r.samples = r.samples[i:]
+}
+
+// rate returns the per second rate of the counter for the BuildName, or 0 if there are not enough samples
+// a BuildName that is missing from a sample has not been counted yet, so its value is 0
+func (r *counterRates) rate(buildName string) float64 {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	if len(r.samples) < 2 {
+		return 0
+	}
+	first, last := r.samples[0], r.samples[len(r.samples)-1]
+	elapsed := last.time.Sub(first.time).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return (last.values[buildName] - first.values[buildName]) / elapsed
+}
+
+// objectReference, metricValue and metricValueList are the types of the custom metrics API (custom.metrics.k8s.io/v1beta1)
+// they are defined here, since they only need to be serialized
+type objectReference struct {
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+type metricValue struct {
+	DescribedObject objectReference       `json:"describedObject"`
+	MetricName      string                `json:"metricName"`
+	Timestamp       metav1.Time           `json:"timestamp"`
+	WindowSeconds   *int64                `json:"windowSeconds,omitempty"`
+	Value           resource.Quantity     `json:"value"`
+	Selector        *metav1.LabelSelector `json:"selector"`
+}
+
+type metricValueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []metricValue `json:"items"`
+}
+
+// externalMetricValue and externalMetricValueList are the types of the external metrics API (external.metrics.k8s.io/v1beta1)
+type externalMetricValue struct {
+	MetricName    string            `json:"metricName"`
+	MetricLabels  map[string]string `json:"metricLabels"`
+	Timestamp     metav1.Time       `json:"timestamp"`
+	WindowSeconds *int64            `json:"window,omitempty"`
+	Value         resource.Quantity `json:"value"`
+}
+
+type externalMetricValueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []externalMetricValue `json:"items"`
+}
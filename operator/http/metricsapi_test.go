@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const metricsTestBuildID2 = "3ab70f1e-6b6a-4a2b-9e16-2d0d7b1e2e4b"
+
+var _ = Describe("metrics API tests", func() {
+	newTestMetricsApiHandler := func() *metricsApiHandler {
+		client := newTestSimpleK8s()
+		for i, name := range []string{"build1", "build2"} {
+			gsb := mpsv1alpha1.GameServerBuild{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec:       mpsv1alpha1.GameServerBuildSpec{BuildID: []string{buildID1, metricsTestBuildID2}[i]},
+				Status:     mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 2, CurrentActive: 2 * (i + 1)},
+			}
+			Expect(client.Create(context.Background(), &gsb)).To(Succeed())
+		}
+		counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"BuildName"})
+		return &metricsApiHandler{
+			client:      client,
+			allocations: newCounterRates(counter, time.Minute),
+			unfulfilled: newCounterRates(counter, time.Minute),
+		}
+	}
+	get := func(h http.Handler, path string) (int, []byte) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	It("should parse the paths of the metrics APIs", func() {
+		req, ok := parseMetricsRequest("/apis/custom.metrics.k8s.io/v1beta1/namespaces/default/gameserverbuilds.mps.playfab.com/build1/standingby_ratio")
+		Expect(ok).To(BeTrue())
+		Expect(*req).To(Equal(metricsRequest{group: customMetricsGroup, namespace: "default", name: "build1", metric: "standingby_ratio"}))
+		req, ok = parseMetricsRequest("/apis/external.metrics.k8s.io/v1beta1/namespaces/default/allocations_per_second")
+		Expect(ok).To(BeTrue())
+		Expect(*req).To(Equal(metricsRequest{group: externalMetricsGroup, namespace: "default", metric: "allocations_per_second"}))
+		req, ok = parseMetricsRequest("/apis/custom.metrics.k8s.io/v1beta1")
+		Expect(ok).To(BeTrue())
+		Expect(req.discovery).To(BeTrue())
+		for _, path := range []string{"/apis", "/apis/custom.metrics.k8s.io/v1beta2", "/apis/custom.metrics.k8s.io/v1beta1/namespaces/default/pods/pod1/cpu", "/apis/external.metrics.k8s.io/v1beta1/metric"} {
+			_, ok = parseMetricsRequest(path)
+			Expect(ok).To(BeFalse(), path)
+		}
+	})
+	It("should list the metrics of the GameServerBuilds", func() {
+		code, body := get(newTestMetricsApiHandler(), "/apis/custom.metrics.k8s.io/v1beta1")
+		Expect(code).To(Equal(http.StatusOK))
+		var list metav1.APIResourceList
+		Expect(json.Unmarshal(body, &list)).To(Succeed())
+		Expect(list.GroupVersion).To(Equal("custom.metrics.k8s.io/v1beta1"))
+		Expect(list.APIResources).To(HaveLen(len(buildMetrics)))
+		Expect(list.APIResources[0].Name).To(Equal("gameserverbuilds.mps.playfab.com/standingby_gameservers"))
+	})
+	It("should return the custom metric of a GameServerBuild", func() {
+		code, body := get(newTestMetricsApiHandler(), "/apis/custom.metrics.k8s.io/v1beta1/namespaces/default/gameserverbuilds.mps.playfab.com/build1/standingby_ratio")
+		Expect(code).To(Equal(http.StatusOK))
+		var list metricValueList
+		Expect(json.Unmarshal(body, &list)).To(Succeed())
+		Expect(list.Kind).To(Equal("MetricValueList"))
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].DescribedObject).To(Equal(objectReference{Kind: "GameServerBuild", Namespace: "default", Name: "build1", APIVersion: "mps.playfab.com/v1alpha1"}))
+		Expect(list.Items[0].Value.String()).To(Equal("500m"))
+	})
+	It("should return the external metric of the GameServerBuilds that match the label selector", func() {
+		code, body := get(newTestMetricsApiHandler(), "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/active_gameservers?labelSelector=BuildName%3Dbuild2")
+		Expect(code).To(Equal(http.StatusOK))
+		var list externalMetricValueList
+		Expect(json.Unmarshal(body, &list)).To(Succeed())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].MetricLabels).To(Equal(map[string]string{"BuildName": "build2", "BuildID": metricsTestBuildID2}))
+		Expect(list.Items[0].Value.Value()).To(Equal(int64(4)))
+	})
+	It("should return a NotFound Status for unknown metrics and GameServerBuilds", func() {
+		h := newTestMetricsApiHandler()
+		for _, path := range []string{
+			"/apis/custom.metrics.k8s.io/v1beta1/namespaces/default/gameserverbuilds.mps.playfab.com/build1/unknown",
+			"/apis/custom.metrics.k8s.io/v1beta1/namespaces/default/gameserverbuilds.mps.playfab.com/build3/standingby_ratio",
+		} {
+			code, body := get(h, path)
+			Expect(code).To(Equal(http.StatusNotFound), path)
+			var status metav1.Status
+			Expect(json.Unmarshal(body, &status)).To(Succeed())
+			Expect(status.Reason).To(Equal(metav1.StatusReasonNotFound))
+		}
+	})
+	It("should compute the allocation rates over the window", func() {
+		counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"BuildName"})
+		rates := newCounterRates(counter, time.Minute)
+		start := time.Now()
+		rates.sample(start)
+		Expect(rates.rate("build1")).To(Equal(0.0))
+
+		counter.WithLabelValues("build1").Add(30)
+		rates.sample(start.Add(30 * time.Second))
+		Expect(rates.rate("build1")).To(Equal(1.0))
+		Expect(rates.rate("build2")).To(Equal(0.0))
+
+		// the first sample is dropped, since it's older than the window
+		counter.WithLabelValues("build1").Add(60)
+		rates.sample(start.Add(90 * time.Second))
+		Expect(rates.rate("build1")).To(Equal(1.0))
+	})
+	It("should read the request header options", func() {
+		client := newTestSimpleK8s()
+		cm := corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: extensionApiserverAuthenticationName, Namespace: extensionApiserverAuthenticationNamespace},
+			Data: map[string]string{
+				"requestheader-client-ca-file":   string(newTestCertificate("front-proxy-ca", nil, nil).certPEM),
+				"requestheader-allowed-names":    `["front-proxy-client"]`,
+				"requestheader-username-headers": `["X-Remote-User"]`,
+			},
+		}
+		Expect(client.Create(context.Background(), &cm)).To(Succeed())
+		options, err := loadRequestHeaderOptions(context.Background(), client)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.allowedNames).To(Equal([]string{"front-proxy-client"}))
+		Expect(options.groupHeaders).To(Equal([]string{"X-Remote-Group"}))
+	})
+})
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	playersPath       = "/api/v1/players/"
+	playersIndexField = "status.players"
+)
+
+type playerSessionsHandler struct {
+	client client.Client
+}
+
+func (h *playerSessionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r)
+}
+
+// handle serves GET /api/v1/players/{playerID}/sessions
+func (h *playerSessionsHandler) handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet {
+		badRequestError(ctx, w, errors.New("invalid method"), "Only GET is accepted")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, playersPath), "/")
+	if len(parts) != 2 || parts[1] != "sessions" {
+		notFoundError(ctx, w, errors.New("path not found"), fmt.Sprintf("Path %s not found", r.URL.Path))
+		return
+	}
+	rs, err := getPlayerSessions(ctx, h.client, parts[0])
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, rs)
+}
+
+// getPlayerSessions returns the existing GameServers that have the player in their initial, past or connected players
+// the deleted GameServers are not kept, so the sessions that ended and were cleaned up are not returned
+// it is used by both the v1 and the v2 REST API
+func getPlayerSessions(ctx context.Context, c client.Client, playerID string) (*PlayerSessionsResponse, error) {
+	if playerID == "" {
+		return nil, newValidationError([]FieldError{{Field: "playerID", Message: "must not be empty"}})
+	}
+
+	var gameServers mpsv1alpha1.GameServerList
+	if err := c.List(ctx, &gameServers, client.MatchingFields{playersIndexField: playerID}); err != nil {
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
+	}
+
+	sort.Slice(gameServers.Items, func(i, j int) bool {
+		return gameServers.Items[i].Name < gameServers.Items[j].Name
+	})
+
+	rs := &PlayerSessionsResponse{
+		PlayerID: playerID,
+		Sessions: make([]GameServerDetails, 0, len(gameServers.Items)),
+	}
+	for i := 0; i < len(gameServers.Items); i++ {
+		// callers only see the sessions of the builds they are allowed to use
+		if !isAllowedForContext(ctx, gameServers.Items[i].Spec.TitleID, gameServers.Items[i].Spec.BuildID) {
+			continue
+		}
+		rs.Sessions = append(rs.Sessions, newGameServerDetails(&gameServers.Items[i]))
+	}
+	return rs, nil
+}
+
+// getPlayersForGameServer returns the distinct IDs of the initial, the past and the currently connected players of a GameServer
+// the past players are the ones that were removed from the session, they are kept in the player history
+func getPlayersForGameServer(gs *mpsv1alpha1.GameServer) []string {
+	seen := make(map[string]struct{}, len(gs.Status.InitialPlayers)+len(gs.Status.PlayerHistory)+len(gs.Status.ConnectedPlayers))
+	players := make([]string, 0, len(gs.Status.InitialPlayers)+len(gs.Status.PlayerHistory)+len(gs.Status.ConnectedPlayers))
+	for _, list := range [][]string{gs.Status.InitialPlayers, gs.Status.PlayerHistory, gs.Status.ConnectedPlayers} {
+		for _, p := range list {
+			if _, ok := seen[p]; ok || p == "" {
+				continue
+			}
+			seen[p] = struct{}{}
+			players = append(players, p)
+		}
+	}
+	return players
+}
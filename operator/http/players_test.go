@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("player sessions tests", func() {
+	It("POST method should return error", func() {
+		req := httptest.NewRequest(http.MethodPost, playersPath+"player1/sessions", nil)
+		w := httptest.NewRecorder()
+		h := &playerSessionsHandler{}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+	It("unknown path should return NotFound", func() {
+		req := httptest.NewRequest(http.MethodGet, playersPath+"player1", nil)
+		w := httptest.NewRecorder()
+		h := &playerSessionsHandler{}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+	})
+	It("should return the sessions of a player", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		var gs mpsv1alpha1.GameServer
+		err = client.Get(context.Background(), types.NamespacedName{Name: gsName, Namespace: "default"}, &gs)
+		Expect(err).ToNot(HaveOccurred())
+		gs.Status.InitialPlayers = []string{"player1"}
+		err = client.Status().Update(context.Background(), &gs)
+		Expect(err).ToNot(HaveOccurred())
+
+		req := httptest.NewRequest(http.MethodGet, playersPath+"player1/sessions", nil)
+		w := httptest.NewRecorder()
+		h := &playerSessionsHandler{
+			client: client,
+		}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		var rs PlayerSessionsResponse
+		err = json.Unmarshal(body, &rs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rs.PlayerID).To(Equal("player1"))
+		Expect(len(rs.Sessions)).To(Equal(1))
+		Expect(rs.Sessions[0].SessionID).To(Equal(sessionID1))
+		Expect(rs.Sessions[0].InitialPlayers).To(ContainElement("player1"))
+	})
+	It("should return the sessions of a player that was removed from the session", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = updateSessionPlayers(context.Background(), client, sessionID1, &UpdatePlayersArgs{PlayersToAdd: []string{"player1", "player2"}})
+		Expect(err).ToNot(HaveOccurred())
+		gs, err := updateSessionPlayers(context.Background(), client, sessionID1, &UpdatePlayersArgs{PlayersToRemove: []string{"player1"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gs.Status.InitialPlayers).To(Equal([]string{"player2"}))
+		Expect(gs.Status.PlayerHistory).To(Equal([]string{"player1", "player2"}))
+		// the fake client does not use the field indexes, so we check the indexed values as well
+		Expect(getPlayersForGameServer(gs)).To(ContainElement("player1"))
+
+		req := httptest.NewRequest(http.MethodGet, playersPath+"player1/sessions", nil)
+		w := httptest.NewRecorder()
+		h := &playerSessionsHandler{
+			client: client,
+		}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		var rs PlayerSessionsResponse
+		err = json.Unmarshal(body, &rs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(rs.Sessions)).To(Equal(1))
+		Expect(rs.Sessions[0].SessionID).To(Equal(sessionID1))
+	})
+	It("should return distinct initial, past and connected players", func() {
+		gs := &mpsv1alpha1.GameServer{
+			Status: mpsv1alpha1.GameServerStatus{
+				InitialPlayers:   []string{"player1", "player2"},
+				PlayerHistory:    []string{"player0", "player1", "player2"},
+				ConnectedPlayers: []string{"player2", "player3"},
+			},
+		}
+		Expect(getPlayersForGameServer(gs)).To(Equal([]string{"player1", "player2", "player0", "player3"}))
+	})
+})
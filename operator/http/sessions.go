@@ -0,0 +1,216 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/playfab/thundernetes/operator/controllers"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const sessionsPath = "/api/v1/sessions/"
+
+type sessionHandler struct {
+	client client.Client
+}
+
+func (h *sessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r)
+}
+
+// handle serves GET and DELETE /api/v1/sessions/{sessionID} and PATCH /api/v1/sessions/{sessionID}/players
+func (h *sessionHandler) handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, sessionsPath), "/")
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "players") {
+		notFoundError(ctx, w, errors.New("path not found"), fmt.Sprintf("Path %s not found", r.URL.Path))
+		return
+	}
+
+	if len(parts) == 1 && r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		badRequestError(ctx, w, errors.New("invalid method"), "Only GET and DELETE are accepted")
+		return
+	}
+	if len(parts) == 2 && r.Method != http.MethodPatch {
+		badRequestError(ctx, w, errors.New("invalid method"), "Only PATCH is accepted")
+		return
+	}
+
+	sessionID := parts[0]
+
+	switch {
+	case r.Method == http.MethodDelete:
+		if err := terminateSession(ctx, h.client, sessionID); err != nil {
+			writeError(ctx, w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2:
+		var args UpdatePlayersArgs
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			badRequestError(ctx, w, err, "cannot deserialize json")
+			return
+		}
+		gs, err := updateSessionPlayers(ctx, h.client, sessionID, &args)
+		if err != nil {
+			writeError(ctx, w, err)
+			return
+		}
+		writeJSON(ctx, w, http.StatusOK, newGameServerDetails(gs))
+	default:
+		gs, err := getGameServerForSession(ctx, h.client, sessionID)
+		if err != nil {
+			writeError(ctx, w, err)
+			return
+		}
+		writeJSON(ctx, w, http.StatusOK, newGameServerDetails(gs))
+	}
+}
+
+// getGameServerForSession returns the GameServer that hosts the given session
+// if the caller is not allowed to use the build of the GameServer, a forbidden error is returned
+// it is used by both the REST and the gRPC API
+func getGameServerForSession(ctx context.Context, c client.Client, sessionID string) (*mpsv1alpha1.GameServer, error) {
+	if !isValidUUID(sessionID) {
+		return nil, newApiError(http.StatusBadRequest, errors.New("invalid sessionID"), "invalid arguments")
+	}
+
+	var gameServers mpsv1alpha1.GameServerList
+	err := c.List(ctx, &gameServers, &client.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"status.sessionID": sessionID}),
+	})
+	if err != nil {
+		return nil, newApiError(http.StatusInternalServerError, err, "error listing")
+	}
+
+	if len(gameServers.Items) == 0 {
+		return nil, newApiError(http.StatusNotFound, errors.New("session not found"), fmt.Sprintf("Session with ID %s not found", sessionID))
+	}
+
+	// sessionIDs are unique within a build, but they could be reused across builds
+	if len(gameServers.Items) > 1 {
+		return nil, newApiError(http.StatusInternalServerError, errors.New("multiple servers found"), fmt.Sprintf("Multiple servers found for sessionID %s", sessionID))
+	}
+
+	gs := &gameServers.Items[0]
+	if err := authorize(ctx, gs.Spec.TitleID, gs.Spec.BuildID); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// terminateSession deletes the GameServer that hosts the given session
+// the GameServerBuild controller will create a new StandingBy GameServer in its place, if needed
+// it is used by both the REST and the gRPC API
+func terminateSession(ctx context.Context, c client.Client, sessionID string) error {
+	gs, err := getGameServerForSession(ctx, c, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Delete(ctx, gs); err != nil {
+		if kerrors.IsNotFound(err) {
+			return newApiError(http.StatusNotFound, err, fmt.Sprintf("Session with ID %s not found", sessionID))
+		}
+		return newApiError(http.StatusInternalServerError, err, "cannot delete game server")
+	}
+	return nil
+}
+
+// updateSessionPlayers adds and removes players from the allowed players of an Active session
+// the sidecar picks up the change and returns the updated list to the game server on its next heartbeat
+// it is used by both the v1 and the v2 REST API
+func updateSessionPlayers(ctx context.Context, c client.Client, sessionID string, args *UpdatePlayersArgs) (*mpsv1alpha1.GameServer, error) {
+	if details := validateUpdatePlayersArgs(args); len(details) > 0 {
+		return nil, newValidationError(details)
+	}
+
+	gs, err := getGameServerForSession(ctx, c, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if gs.Status.State != mpsv1alpha1.GameServerStateActive {
+		return nil, newApiError(http.StatusBadRequest, fmt.Errorf("GameServer %s is %s", gs.Name, gs.Status.State), "players can only be updated on Active sessions")
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// get the latest version of the GameServer on every try
+		if err := c.Get(ctx, types.NamespacedName{Name: gs.Name, Namespace: gs.Namespace}, gs); err != nil {
+			if kerrors.IsNotFound(err) {
+				return newApiError(http.StatusNotFound, err, fmt.Sprintf("Session with ID %s not found", sessionID))
+			}
+			return err
+		}
+		// the session may have ended, or the GameServer may have been reallocated, since it was looked up
+		if gs.Status.State != mpsv1alpha1.GameServerStateActive || gs.Status.SessionID != sessionID {
+			return newApiError(http.StatusConflict, fmt.Errorf("GameServer %s is %s with session %s", gs.Name, gs.Status.State, gs.Status.SessionID),
+				fmt.Sprintf("Session with ID %s is no longer Active", sessionID))
+		}
+		// the removed players are kept in the history, so that the session can still be found by their IDs
+		gs.Status.PlayerHistory = updatePlayerList(updatePlayerList(gs.Status.PlayerHistory, gs.Status.InitialPlayers, nil), args.PlayersToAdd, nil)
+		gs.Status.InitialPlayers = updatePlayerList(gs.Status.InitialPlayers, args.PlayersToAdd, args.PlayersToRemove)
+		return c.Status().Update(ctx, gs)
+	})
+	if err != nil {
+		var ae *apiError
+		if errors.As(err, &ae) {
+			return nil, ae
+		}
+		return nil, newApiError(http.StatusInternalServerError, err, "cannot update game server")
+	}
+	return gs, nil
+}
+
+// updatePlayerList returns a new list with the players added and removed, keeping the original order
+// players that are already in the list are not added twice
+func updatePlayerList(players, toAdd, toRemove []string) []string {
+	removed := make(map[string]struct{}, len(toRemove))
+	for _, p := range toRemove {
+		removed[p] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(players)+len(toAdd))
+	result := make([]string, 0, len(players)+len(toAdd))
+	for _, list := range [][]string{players, toAdd} {
+		for _, p := range list {
+			if _, ok := removed[p]; ok {
+				continue
+			}
+			if _, ok := seen[p]; ok {
+				continue
+			}
+			seen[p] = struct{}{}
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// newGameServerDetails returns the GameServerDetails for the given GameServer
+func newGameServerDetails(gs *mpsv1alpha1.GameServer) GameServerDetails {
+	return GameServerDetails{
+		Name:             gs.Name,
+		Namespace:        gs.Namespace,
+		BuildName:        gs.Labels[controllers.LabelBuildName],
+		BuildID:          gs.Spec.BuildID,
+		NodeName:         gs.Status.NodeName,
+		IPV4Address:      gs.Status.PublicIP,
+		Ports:            gs.Status.Ports,
+		State:            string(gs.Status.State),
+		Health:           string(gs.Status.Health),
+		SessionID:        gs.Status.SessionID,
+		SessionCookie:    gs.Status.SessionCookie,
+		InitialPlayers:   gs.Status.InitialPlayers,
+		ConnectedPlayers: gs.Status.ConnectedPlayers,
+	}
+}
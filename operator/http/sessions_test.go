@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sessionEndingClient ends the session of the GameServer it returns, like a session that ends between the lookup and the update
+type sessionEndingClient struct {
+	client.Client
+}
+
+func (c *sessionEndingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if err := c.Client.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	if gs, ok := obj.(*mpsv1alpha1.GameServer); ok {
+		gs.Status.State = mpsv1alpha1.GameServerStateGameCompleted
+	}
+	return nil
+}
+
+var _ = Describe("session lookup tests", func() {
+	It("POST method should return error", func() {
+		req := httptest.NewRequest(http.MethodPost, sessionsPath+sessionID1, nil)
+		w := httptest.NewRecorder()
+		h := &sessionHandler{}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+	It("sessionID should be a GUID", func() {
+		req := httptest.NewRequest(http.MethodGet, sessionsPath+"NOT_A_GUID", nil)
+		w := httptest.NewRecorder()
+		h := &sessionHandler{}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+	It("should return NotFound on an empty list", func() {
+		req := httptest.NewRequest(http.MethodGet, sessionsPath+sessionID1, nil)
+		w := httptest.NewRecorder()
+		h := &sessionHandler{
+			client: newTestSimpleK8s(),
+		}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusNotFound))
+	})
+	It("should return the game server details for an existing session", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		req := httptest.NewRequest(http.MethodGet, sessionsPath+sessionID1, nil)
+		w := httptest.NewRecorder()
+		h := &sessionHandler{
+			client: client,
+		}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		var gsd GameServerDetails
+		err = json.Unmarshal(body, &gsd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gsd.Name).To(Equal(gsName))
+		Expect(gsd.BuildName).To(Equal(buildName1))
+		Expect(gsd.SessionID).To(Equal(sessionID1))
+		Expect(gsd.State).To(Equal(string(mpsv1alpha1.GameServerStateActive)))
+	})
+	It("GET method on players should return error", func() {
+		req := httptest.NewRequest(http.MethodGet, sessionsPath+sessionID1+"/players", nil)
+		w := httptest.NewRecorder()
+		h := &sessionHandler{}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+	It("should not update players of a StandingBy server", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateStandingBy)
+		Expect(err).ToNot(HaveOccurred())
+		req := httptest.NewRequest(http.MethodPatch, sessionsPath+sessionID1+"/players", bytes.NewBufferString(`{"playersToAdd":["player3"]}`))
+		w := httptest.NewRecorder()
+		h := &sessionHandler{
+			client: client,
+		}
+		h.handle(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+	It("should add and remove players of an Active session", func() {
+		client := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(client, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		req := httptest.NewRequest(http.MethodPatch, sessionsPath+sessionID1+"/players", bytes.NewBufferString(`{"playersToAdd":["player1","player2"]}`))
+		w := httptest.NewRecorder()
+		h := &sessionHandler{
+			client: client,
+		}
+		h.handle(w, req)
+		res := w.Result()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		res.Body.Close()
+
+		req = httptest.NewRequest(http.MethodPatch, sessionsPath+sessionID1+"/players", bytes.NewBufferString(`{"playersToAdd":["player3"],"playersToRemove":["player1"]}`))
+		w = httptest.NewRecorder()
+		h.handle(w, req)
+		res = w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		body, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		var gsd GameServerDetails
+		err = json.Unmarshal(body, &gsd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gsd.InitialPlayers).To(Equal([]string{"player2", "player3"}))
+	})
+	It("should not update players of a session that ended after it was looked up", func() {
+		c := newTestSimpleK8s()
+		err := createTestGameServerAndBuild(c, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = updateSessionPlayers(context.Background(), &sessionEndingClient{Client: c}, sessionID1, &UpdatePlayersArgs{PlayersToAdd: []string{"player1"}})
+		var ae *apiError
+		Expect(errors.As(err, &ae)).To(BeTrue())
+		Expect(ae.statusCode).To(Equal(http.StatusConflict))
+	})
+	It("should update player lists", func() {
+		Expect(updatePlayerList([]string{"a", "b"}, []string{"b", "c"}, []string{"a"})).To(Equal([]string{"b", "c"}))
+		Expect(updatePlayerList(nil, []string{"a"}, nil)).To(Equal([]string{"a"}))
+	})
+})
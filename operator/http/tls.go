@@ -0,0 +1,173 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// TLSOptions configures the TLS security of the API server
+type TLSOptions struct {
+	// Namespace is the namespace of the Secrets
+	Namespace string
+	// SecretName is the name of the Secret that contains the server certificate (tls.crt) and private key (tls.key)
+	SecretName string
+	// CASecretName is the name of the Secret whose ca.crt key contains the CA bundle that the client certificates are verified against
+	// if it's the same as SecretName and the Secret does not contain a CA bundle, the server certificate is used, which works for self-signed certificates
+	CASecretName string
+	// ClientAuth contains the allow-lists for the client certificates
+	ClientAuth ClientAuthOptions
+	// AllowUnverifiedClients keeps the API server running without verifying the client certificates if there is no CA bundle,
+	// like before client authentication was added
+	// it's ignored if CASecretName is a different Secret or if there are allow-lists, the API server then requires a CA bundle
+	AllowUnverifiedClients bool
+}
+
+// allowsUnverifiedClients returns true if the client certificates are not verified when there is no CA bundle
+func (o *TLSOptions) allowsUnverifiedClients() bool {
+	return o.AllowUnverifiedClients && o.CASecretName == o.SecretName && !o.ClientAuth.hasAllowLists()
+}
+
+// ClientAuthOptions configures which callers are allowed to use the API, based on their TLS client certificates
+// if both lists are empty, all client certificates that are signed by the CA are allowed
+type ClientAuthOptions struct {
+	// AllowedSubjects contains the subjects of the callers that are allowed to use the API
+	// each entry can either be a Common Name (e.g. "matchmaker") or a full subject (e.g. "CN=matchmaker,O=Contoso")
+	AllowedSubjects []string
+	// AllowedSANs contains the Subject Alternative Names (DNS names, email addresses, IP addresses or URIs)
+	// of the callers that are allowed to use the API
+	AllowedSANs []string
+}
+
+// hasAllowLists returns true if any of the allow-lists contain entries
+func (c *ClientAuthOptions) hasAllowLists() bool {
+	return c != nil && (len(c.AllowedSubjects) > 0 || len(c.AllowedSANs) > 0)
+}
+
+// certificateStore holds the server certificate and the client CA bundle that are currently used by the API server
+// they can be replaced while the API server is running, new TLS connections will use the new ones
+type certificateStore struct {
+	mux       sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// update replaces the server certificate and the client CA bundle, an empty CA bundle disables the client certificate verification
+// the caller is responsible for allowing an empty CA bundle only if the options allow unverified clients
+// it returns the parsed server certificate
+func (s *certificateStore) update(certPEMBlock, keyPEMBlock, caBundle []byte) (*x509.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	cert.Leaf = leaf
+
+	// without a CA bundle the client certificates are not verified, like before client authentication was added
+	var clientCAs *x509.CertPool
+	if len(caBundle) > 0 {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("CA bundle does not contain any valid PEM encoded certificates")
+		}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.cert = &cert
+	s.clientCAs = clientCAs
+	return leaf, nil
+}
+
+// getCertificate returns the current server certificate, it's used as tls.Config.GetCertificate
+func (s *certificateStore) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	if s.cert == nil {
+		return nil, errors.New("no certificate loaded")
+	}
+	return s.cert, nil
+}
+
+// getClientCAs returns the current client CA bundle, or nil if the client certificates are not verified
+func (s *certificateStore) getClientCAs() *x509.CertPool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.clientCAs
+}
+
+// newTLSConfig creates the TLS configuration for the HTTP or the gRPC server
+// client certificates are required and verified against the CA bundle, unless no CA bundle is loaded and there are no allow-lists
+// if any allow-lists are configured, a client certificate must also match at least one of their entries, and all clients are rejected without a CA bundle
+// the certificates are read from the store on every new connection, so they can be rotated without restarting the listener
+func newTLSConfig(store *certificateStore, clientAuth *ClientAuthOptions, nextProtos []string) *tls.Config {
+	config := &tls.Config{
+		GetCertificate: store.getCertificate,
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     nextProtos,
+		VerifyPeerCertificate: func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+				return errors.New("no verified client certificate")
+			}
+			return verifyClientCertificate(verifiedChains[0][0], clientAuth)
+		},
+	}
+	// the client CA bundle can only be set per connection via GetConfigForClient
+	config.GetConfigForClient = func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+		c := config.Clone()
+		c.GetConfigForClient = nil
+		c.ClientCAs = store.getClientCAs()
+		if c.ClientCAs == nil {
+			if clientAuth.hasAllowLists() {
+				return nil, errors.New("no CA bundle is loaded, the client certificates cannot be verified")
+			}
+			c.ClientAuth = tls.NoClientCert
+			c.VerifyPeerCertificate = nil
+		}
+		return c, nil
+	}
+	return config
+}
+
+// verifyClientCertificate returns an error if there are allow-lists configured and the certificate does not match any of their entries
+func verifyClientCertificate(cert *x509.Certificate, clientAuth *ClientAuthOptions) error {
+	if !clientAuth.hasAllowLists() {
+		return nil
+	}
+
+	for _, subject := range clientAuth.AllowedSubjects {
+		if subject == cert.Subject.CommonName || subject == cert.Subject.String() {
+			return nil
+		}
+	}
+
+	for _, san := range getSANs(cert) {
+		for _, allowed := range clientAuth.AllowedSANs {
+			if san == allowed {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("client certificate with subject %s is not allowed", cert.Subject.String())
+}
+
+// getSANs returns all the Subject Alternative Names of a certificate as strings
+func getSANs(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.IPAddresses)+len(cert.URIs))
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
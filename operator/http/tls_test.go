@@ -0,0 +1,198 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TLS client authentication tests", func() {
+	var (
+		ca     *testCertificate
+		server *testCertificate
+	)
+	withLocalhostIP := func(t *x509.Certificate) {
+		t.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+
+	BeforeEach(func() {
+		ca = newTestCertificate("test-ca", nil, nil)
+		server = newTestCertificate("localhost", ca, withLocalhostIP)
+	})
+
+	// get starts a TLS server with the given CA bundle and client authentication options and does a GET request with the client certificate
+	// the server certificate is trusted if it's either signed by the CA or it's the CA itself
+	get := func(caBundle []byte, clientAuth *ClientAuthOptions, clientCert *testCertificate) (*http.Response, error) {
+		store := &certificateStore{}
+		_, err := store.update(server.certPEM, server.keyPEM, caBundle)
+		Expect(err).ToNot(HaveOccurred())
+
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		srv.TLS = newTLSConfig(store, clientAuth, nil)
+		srv.StartTLS()
+		defer srv.Close()
+
+		return newTestTLSClient(clientCert, ca, server).Get(srv.URL)
+	}
+
+	It("should reject an invalid CA bundle", func() {
+		store := &certificateStore{}
+		_, err := store.update(server.certPEM, server.keyPEM, []byte("not a certificate"))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should not require a client certificate without a CA bundle", func() {
+		res, err := get(nil, &ClientAuthOptions{}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("should reject all clients without a CA bundle if there are allow-lists", func() {
+		_, err := get(nil, &ClientAuthOptions{AllowedSubjects: []string{"lobby"}}, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = get(nil, &ClientAuthOptions{AllowedSANs: []string{"lobby.contoso.com"}}, newTestCertificate("lobby", ca, nil))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should only use a self-signed server certificate as the CA bundle", func() {
+		Expect(isSelfSigned(server.certPEM)).To(BeFalse())
+		Expect(isSelfSigned(ca.certPEM)).To(BeTrue())
+		Expect(isSelfSigned(newTestCertificate("localhost", nil, withLocalhostIP).certPEM)).To(BeTrue())
+		Expect(isSelfSigned([]byte("not a certificate"))).To(BeFalse())
+	})
+	It("should reject a client without a certificate", func() {
+		_, err := get(ca.certPEM, &ClientAuthOptions{}, nil)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should reject a client certificate that is not signed by the CA", func() {
+		otherCA := newTestCertificate("other-ca", nil, nil)
+		_, err := get(ca.certPEM, &ClientAuthOptions{}, newTestCertificate("matchmaker", otherCA, nil))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should accept a client certificate that is signed by the CA", func() {
+		res, err := get(ca.certPEM, &ClientAuthOptions{}, newTestCertificate("matchmaker", ca, nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("should accept a self-signed server certificate that is also used by the client", func() {
+		server = newTestCertificate("localhost", nil, withLocalhostIP)
+		res, err := get(server.certPEM, &ClientAuthOptions{}, server)
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("should accept a client whose subject is allowed", func() {
+		res, err := get(ca.certPEM, &ClientAuthOptions{AllowedSubjects: []string{"lobby", "matchmaker"}}, newTestCertificate("matchmaker", ca, nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("should accept a client whose full subject is allowed", func() {
+		res, err := get(ca.certPEM, &ClientAuthOptions{AllowedSubjects: []string{"CN=matchmaker,O=thundernetes"}}, newTestCertificate("matchmaker", ca, nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("should reject a client whose subject is not allowed", func() {
+		_, err := get(ca.certPEM, &ClientAuthOptions{AllowedSubjects: []string{"lobby"}}, newTestCertificate("matchmaker", ca, nil))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should accept a client whose SAN is allowed", func() {
+		clientCert := newTestCertificate("matchmaker", ca, func(t *x509.Certificate) {
+			t.DNSNames = []string{"matchmaker.contoso.com"}
+		})
+		res, err := get(ca.certPEM, &ClientAuthOptions{AllowedSubjects: []string{"lobby"}, AllowedSANs: []string{"matchmaker.contoso.com"}}, clientCert)
+		Expect(err).ToNot(HaveOccurred())
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+	})
+	It("should reject a client whose SAN is not allowed", func() {
+		clientCert := newTestCertificate("matchmaker", ca, func(t *x509.Certificate) {
+			t.DNSNames = []string{"matchmaker.fabrikam.com"}
+		})
+		_, err := get(ca.certPEM, &ClientAuthOptions{AllowedSANs: []string{"matchmaker.contoso.com"}}, clientCert)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// newTestTLSClient returns an HTTP client that presents the client certificate and trusts the given server certificates
+// it does not reuse connections, so every request does a new TLS handshake
+func newTestTLSClient(clientCert *testCertificate, trusted ...*testCertificate) *http.Client {
+	rootCAs := x509.NewCertPool()
+	for _, t := range trusted {
+		rootCAs.AddCert(t.cert)
+	}
+	tlsConfig := &tls.Config{RootCAs: rootCAs}
+	if clientCert != nil {
+		cert, err := tls.X509KeyPair(clientCert.certPEM, clientCert.keyPEM)
+		Expect(err).ToNot(HaveOccurred())
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, DisableKeepAlives: true}}
+}
+
+// testCertificate is a certificate and its private key, used for testing TLS
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCertificate creates a certificate with the given Common Name
+// if parent is nil, the certificate is a self-signed CA certificate, otherwise it is signed by the parent
+// customize can be used to modify the certificate template before the certificate is created
+func newTestCertificate(commonName string, parent *testCertificate, customize func(*x509.Certificate)) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	Expect(err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"thundernetes"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	if customize != nil {
+		customize(template)
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	Expect(err).ToNot(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
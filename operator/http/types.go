@@ -1,6 +1,7 @@
 package http
 
 import (
+	"fmt"
 	"net"
 	"regexp"
 	"time"
@@ -20,12 +21,49 @@ func isValidUUID(uuid string) bool {
 	return r.MatchString(uuid)
 }
 
+// FieldError describes why a field of a request is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 // validateAllocateArgs validates an instance of the AllocateArgs struct.
-func validateAllocateArgs(aa *AllocateArgs) bool {
-	if !isValidUUID(aa.SessionID) || !isValidUUID(aa.BuildID) {
-		return false
+// it returns a FieldError for every invalid field
+func validateAllocateArgs(aa *AllocateArgs) []FieldError {
+	var details []FieldError
+	if !isValidUUID(aa.SessionID) {
+		details = append(details, FieldError{Field: "sessionID", Message: "must be a UUID"})
+	}
+	if !isValidUUID(aa.BuildID) {
+		details = append(details, FieldError{Field: "buildID", Message: "must be a UUID"})
+	}
+	return details
+}
+
+// UpdatePlayersArgs contains the players to add to or remove from the allowed players of an Active session
+type UpdatePlayersArgs struct {
+	PlayersToAdd    []string `json:"playersToAdd"`
+	PlayersToRemove []string `json:"playersToRemove"`
+}
+
+// validateUpdatePlayersArgs validates an instance of the UpdatePlayersArgs struct.
+// it returns a FieldError for every invalid field
+func validateUpdatePlayersArgs(ua *UpdatePlayersArgs) []FieldError {
+	var details []FieldError
+	if len(ua.PlayersToAdd) == 0 && len(ua.PlayersToRemove) == 0 {
+		details = append(details, FieldError{Field: "playersToAdd", Message: "either playersToAdd or playersToRemove must not be empty"})
+	}
+	for i, p := range ua.PlayersToAdd {
+		if p == "" {
+			details = append(details, FieldError{Field: fmt.Sprintf("playersToAdd[%d]", i), Message: "must not be empty"})
+		}
+	}
+	for i, p := range ua.PlayersToRemove {
+		if p == "" {
+			details = append(details, FieldError{Field: fmt.Sprintf("playersToRemove[%d]", i), Message: "must not be empty"})
+		}
 	}
-	return true
+	return details
 }
 
 // RequestMultiplayerServerResponse contains details that are returned on a successful GameServer allocation call
@@ -35,6 +73,60 @@ type RequestMultiplayerServerResponse struct {
 	SessionID   string
 }
 
+// GameServerDetails contains details about a GameServer that are returned by the session and build lookup calls
+type GameServerDetails struct {
+	Name             string
+	Namespace        string
+	BuildName        string
+	BuildID          string
+	NodeName         string
+	IPV4Address      string
+	Ports            string
+	State            string
+	Health           string
+	SessionID        string
+	SessionCookie    string
+	InitialPlayers   []string
+	ConnectedPlayers []string
+}
+
+// PlayerSessionsResponse contains the existing GameServers that have a player in their initial or connected players
+type PlayerSessionsResponse struct {
+	PlayerID string
+	Sessions []GameServerDetails
+}
+
+// BuildDetails contains details about a GameServerBuild that are returned by the build listing call
+type BuildDetails struct {
+	Name                string
+	Namespace           string
+	BuildID             string
+	TitleID             string
+	StandingBy          int
+	Max                 int
+	CurrentInitializing int
+	CurrentStandingBy   int
+	CurrentActive       int
+	CrashesCount        int
+	Health              string
+}
+
+// ListBuildsResponse contains a page of GameServerBuilds
+// NextOffset is omitted when there are no more results
+type ListBuildsResponse struct {
+	Builds     []BuildDetails
+	Total      int
+	NextOffset int `json:",omitempty"`
+}
+
+// ListGameServersResponse contains a page of GameServers
+// NextOffset is omitted when there are no more results
+type ListGameServersResponse struct {
+	GameServers []GameServerDetails
+	Total       int
+	NextOffset  int `json:",omitempty"`
+}
+
 // tcpKeepAliveListener sets TCP keep-alive timeouts on accepted
 // connections. It's used by ListenAndServe and ListenAndServeTLS so
 // dead TCP connections (e.g. closing laptop mid-download) eventually
@@ -12,16 +12,21 @@ var _ = Describe("types tests", func() {
 	It("should return false on an invalid GUID", func() {
 		Expect(isValidUUID("NOT A VALID GUID")).To(BeFalse())
 	})
-	It("should return true on valid AllocateArgs", func() {
+	It("should return the invalid fields of UpdatePlayersArgs", func() {
+		Expect(validateUpdatePlayersArgs(&UpdatePlayersArgs{})).To(HaveLen(1))
+		Expect(validateUpdatePlayersArgs(&UpdatePlayersArgs{PlayersToAdd: []string{"player1", ""}})).To(Equal([]FieldError{{Field: "playersToAdd[1]", Message: "must not be empty"}}))
+		Expect(validateUpdatePlayersArgs(&UpdatePlayersArgs{PlayersToRemove: []string{"player1"}})).To(BeEmpty())
+	})
+	It("should return no invalid fields on valid AllocateArgs", func() {
 		Expect(validateAllocateArgs(&AllocateArgs{
 			SessionID: "396022c2-caed-4bdf-98bb-521f2dc4f2f3",
 			BuildID:   "b1b2d3e4-567f-4e4b-8f8b-f3a4b4a5b8e5",
-		})).To(BeTrue())
+		})).To(BeEmpty())
 	})
-	It("should return false on invalid AllocateArgs", func() {
+	It("should return the invalid fields of invalid AllocateArgs", func() {
 		Expect(validateAllocateArgs(&AllocateArgs{
 			SessionID: "396022c2-caed-4bdf-98bb-521f2dc4f2f3",
 			BuildID:   "WRONG",
-		})).To(BeFalse())
+		})).To(Equal([]FieldError{{Field: "buildID", Message: "must be a UUID"}}))
 	})
 })
@@ -0,0 +1,291 @@
+package http
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+const apiV2Path = "/api/v2/"
+
+// openAPIDocument is the OpenAPI document of the v2 API, it's served as JSON
+//
+//go:embed openapi.yaml
+var openAPIDocument []byte
+
+// apiV2Handler serves the v2 REST API
+// it uses the same logic as the v1 handlers, but the responses are camelCase JSON
+// and the errors are JSON ErrorResponseV2 objects
+type apiV2Handler struct {
+	client      client.Client
+	limiter     *allocationLimiter
+	openAPIJSON []byte
+}
+
+// newApiV2Handler creates a new apiV2Handler
+func newApiV2Handler(c client.Client, limiter *allocationLimiter) (*apiV2Handler, error) {
+	openAPIJSON, err := yaml.YAMLToJSON(openAPIDocument)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return &apiV2Handler{client: c, limiter: limiter, openAPIJSON: openAPIJSON}, nil
+}
+
+func (h *apiV2Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r)
+}
+
+// handle routes the requests of the v2 API: POST allocate, POST allocate/batch, GET builds, GET builds/{buildID}/gameservers,
+// GET and DELETE sessions/{sessionID}, PATCH sessions/{sessionID}/players, GET players/{playerID}/sessions and GET openapi.json
+func (h *apiV2Handler) handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiV2Path), "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "allocate":
+		if allowMethods(ctx, w, r, http.MethodPost) {
+			h.allocate(w, r)
+		}
+	case len(parts) == 2 && parts[0] == "allocate" && parts[1] == "batch":
+		if allowMethods(ctx, w, r, http.MethodPost) {
+			h.allocateBatch(w, r)
+		}
+	case len(parts) == 1 && parts[0] == "builds":
+		if allowMethods(ctx, w, r, http.MethodGet) {
+			h.listBuilds(w, r)
+		}
+	case len(parts) == 3 && parts[0] == "builds" && parts[2] == "gameservers":
+		if allowMethods(ctx, w, r, http.MethodGet) {
+			h.listGameServersForBuild(w, r, parts[1])
+		}
+	case len(parts) == 2 && parts[0] == "sessions":
+		if allowMethods(ctx, w, r, http.MethodGet, http.MethodDelete) {
+			h.session(w, r, parts[1])
+		}
+	case len(parts) == 3 && parts[0] == "sessions" && parts[2] == "players":
+		if allowMethods(ctx, w, r, http.MethodPatch) {
+			h.updatePlayers(w, r, parts[1])
+		}
+	case len(parts) == 3 && parts[0] == "players" && parts[2] == "sessions":
+		if allowMethods(ctx, w, r, http.MethodGet) {
+			h.playerSessions(w, r, parts[1])
+		}
+	case len(parts) == 1 && parts[0] == "openapi.json":
+		if allowMethods(ctx, w, r, http.MethodGet) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(h.openAPIJSON)
+		}
+	default:
+		writeErrorV2(ctx, w, newApiError(http.StatusNotFound, errors.New("path not found"), fmt.Sprintf("Path %s not found", r.URL.Path)))
+	}
+}
+
+func (h *apiV2Handler) allocate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var args AllocateArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		writeErrorV2(ctx, w, newApiError(http.StatusBadRequest, err, "cannot deserialize json"))
+		return
+	}
+	rs, err := allocate(ctx, h.client, h.limiter, &args)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, AllocateResponseV2(*rs))
+}
+
+func (h *apiV2Handler) allocateBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var args BatchAllocateArgsV2
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		writeErrorV2(ctx, w, newApiError(http.StatusBadRequest, err, "cannot deserialize json"))
+		return
+	}
+	responses, errs, err := allocateBatch(ctx, h.client, h.limiter, args.Allocations)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	rs := &BatchAllocateResponseV2{Results: make([]BatchAllocateResultV2, 0, len(responses))}
+	for i := range responses {
+		if errs[i] != nil {
+			e := newErrorV2(errs[i])
+			rs.Results = append(rs.Results, BatchAllocateResultV2{Error: &e})
+			continue
+		}
+		allocation := AllocateResponseV2(*responses[i])
+		rs.Results = append(rs.Results, BatchAllocateResultV2{Allocation: &allocation})
+	}
+	writeJSON(ctx, w, http.StatusOK, rs)
+}
+
+func (h *apiV2Handler) listBuilds(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	offset, limit, err := getPagingArgs(r)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	rs, err := listBuilds(ctx, h.client, r.URL.Query().Get("health"), offset, limit)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, newListBuildsResponseV2(rs))
+}
+
+func (h *apiV2Handler) listGameServersForBuild(w http.ResponseWriter, r *http.Request, buildID string) {
+	ctx := r.Context()
+	offset, limit, err := getPagingArgs(r)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	rs, err := listGameServersForBuild(ctx, h.client, buildID, r.URL.Query().Get("state"), offset, limit)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, &ListGameServersResponseV2{
+		GameServers: newGameServerDetailsListV2(rs.GameServers),
+		Total:       rs.Total,
+		NextOffset:  rs.NextOffset,
+	})
+}
+
+func (h *apiV2Handler) session(w http.ResponseWriter, r *http.Request, sessionID string) {
+	ctx := r.Context()
+	if r.Method == http.MethodDelete {
+		if err := terminateSession(ctx, h.client, sessionID); err != nil {
+			writeErrorV2(ctx, w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	gs, err := getGameServerForSession(ctx, h.client, sessionID)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, GameServerDetailsV2(newGameServerDetails(gs)))
+}
+
+func (h *apiV2Handler) updatePlayers(w http.ResponseWriter, r *http.Request, sessionID string) {
+	ctx := r.Context()
+	var args UpdatePlayersArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		writeErrorV2(ctx, w, newApiError(http.StatusBadRequest, err, "cannot deserialize json"))
+		return
+	}
+	gs, err := updateSessionPlayers(ctx, h.client, sessionID, &args)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, GameServerDetailsV2(newGameServerDetails(gs)))
+}
+
+func (h *apiV2Handler) playerSessions(w http.ResponseWriter, r *http.Request, playerID string) {
+	ctx := r.Context()
+	rs, err := getPlayerSessions(ctx, h.client, playerID)
+	if err != nil {
+		writeErrorV2(ctx, w, err)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, &PlayerSessionsResponseV2{
+		PlayerID: rs.PlayerID,
+		Sessions: newGameServerDetailsListV2(rs.Sessions),
+	})
+}
+
+// allowMethods returns true if the request method is one of the given methods
+// otherwise it returns a 405 error with the allowed methods
+func allowMethods(ctx context.Context, w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	msg := fmt.Sprintf("Only %s is accepted", methods[0])
+	if len(methods) > 1 {
+		msg = fmt.Sprintf("Only %s are accepted", strings.Join(methods, " and "))
+	}
+	writeErrorV2(ctx, w, newApiError(http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method), msg))
+	return false
+}
+
+// writeErrorV2 logs an error that was returned by the shared API logic and writes it as a JSON ErrorResponseV2
+func writeErrorV2(ctx context.Context, w http.ResponseWriter, err error) {
+	log := log.FromContext(ctx)
+	var ae *apiError
+	if !errors.As(err, &ae) {
+		ae = newApiError(http.StatusInternalServerError, err, "internal error")
+	}
+	if ae.statusCode >= http.StatusInternalServerError {
+		log.Error(ae.err, ae.msg)
+	} else {
+		log.Info(ae.msg)
+	}
+
+	if ae.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(ae.retryAfter)))
+	}
+	if ae.statusCode == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	writeJSON(ctx, w, ae.statusCode, &ErrorResponseV2{Error: newErrorV2(ae)})
+}
+
+// newErrorV2 converts an error that was returned by the shared API logic to an ErrorV2
+// errors that are not apiErrors are reported as internal errors
+func newErrorV2(err error) ErrorV2 {
+	var ae *apiError
+	if !errors.As(err, &ae) {
+		ae = newApiError(http.StatusInternalServerError, err, "internal error")
+	}
+	e := ErrorV2{
+		Status:  ae.statusCode,
+		Code:    errorCode(ae.statusCode),
+		Message: ae.Error(),
+		Details: ae.details,
+	}
+	if ae.retryAfter > 0 {
+		e.RetryAfterSeconds = retryAfterSeconds(ae.retryAfter)
+	}
+	return e
+}
+
+// errorCode returns the machine-readable code of the v2 errors for an HTTP status code
+// the codes are the names of the equivalent gRPC status codes, apart from MethodNotAllowed that has no gRPC equivalent
+func errorCode(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "InvalidArgument"
+	case http.StatusUnauthorized:
+		return "Unauthenticated"
+	case http.StatusForbidden:
+		return "PermissionDenied"
+	case http.StatusNotFound:
+		return "NotFound"
+	case http.StatusMethodNotAllowed:
+		return "MethodNotAllowed"
+	case http.StatusConflict:
+		return "FailedPrecondition"
+	case http.StatusTooManyRequests:
+		return "ResourceExhausted"
+	default:
+		return "Internal"
+	}
+}
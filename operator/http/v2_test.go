@@ -0,0 +1,231 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("v2 API tests", func() {
+	var (
+		k8sClient client.Client
+		h         *apiV2Handler
+	)
+
+	BeforeEach(func() {
+		k8sClient = newTestSimpleK8s()
+		var err error
+		h, err = newApiV2Handler(k8sClient, nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	serve := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		h.handle(w, req)
+		return w
+	}
+
+	expectError := func(w *httptest.ResponseRecorder, status int, code string) ErrorV2 {
+		Expect(w.Code).To(Equal(status))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+		var rs ErrorResponseV2
+		Expect(json.Unmarshal(w.Body.Bytes(), &rs)).To(Succeed())
+		Expect(rs.Error.Status).To(Equal(status))
+		Expect(rs.Error.Code).To(Equal(code))
+		Expect(rs.Error.Message).ToNot(BeEmpty())
+		return rs.Error
+	}
+
+	It("should return field details for invalid allocation arguments", func() {
+		w := serve(http.MethodPost, "/api/v2/allocate", AllocateArgs{SessionID: "NOT_A_GUID", BuildID: "NOT_A_GUID"})
+		e := expectError(w, http.StatusBadRequest, "InvalidArgument")
+		Expect(e.Details).To(Equal([]FieldError{
+			{Field: "sessionID", Message: "must be a UUID"},
+			{Field: "buildID", Message: "must be a UUID"},
+		}))
+	})
+	It("should return a JSON error for a malformed body", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/allocate", strings.NewReader("{"))
+		w := httptest.NewRecorder()
+		h.handle(w, req)
+		expectError(w, http.StatusBadRequest, "InvalidArgument")
+	})
+	It("should return MethodNotAllowed with the allowed methods", func() {
+		w := serve(http.MethodGet, "/api/v2/allocate", nil)
+		expectError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+		Expect(w.Header().Get("Allow")).To(Equal(http.MethodPost))
+		w = serve(http.MethodPost, "/api/v2/sessions/"+sessionID1, nil)
+		expectError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+		Expect(w.Header().Get("Allow")).To(Equal("GET, DELETE"))
+	})
+	It("should return NotFound for an unknown path", func() {
+		expectError(serve(http.MethodGet, "/api/v2/unknown", nil), http.StatusNotFound, "NotFound")
+	})
+	It("should return NotFound for an unknown build", func() {
+		expectError(serve(http.MethodPost, "/api/v2/allocate", AllocateArgs{SessionID: sessionID1, BuildID: buildID1}), http.StatusNotFound, "NotFound")
+	})
+	It("should return ResourceExhausted with Retry-After when there are no StandingBy servers", func() {
+		Expect(k8sClient.Create(context.Background(), &mpsv1alpha1.GameServerBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: buildName1, Namespace: "default"},
+			Spec:       mpsv1alpha1.GameServerBuildSpec{BuildID: buildID1},
+		})).To(Succeed())
+		w := serve(http.MethodPost, "/api/v2/allocate", AllocateArgs{SessionID: sessionID1, BuildID: buildID1})
+		e := expectError(w, http.StatusTooManyRequests, "ResourceExhausted")
+		Expect(w.Header().Get("Retry-After")).To(Equal("1"))
+		Expect(e.RetryAfterSeconds).To(Equal(1))
+	})
+	It("should return FailedPrecondition for a paused build", func() {
+		Expect(k8sClient.Create(context.Background(), &mpsv1alpha1.GameServerBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: buildName1, Namespace: "default"},
+			Spec:       mpsv1alpha1.GameServerBuildSpec{BuildID: buildID1, Paused: true},
+		})).To(Succeed())
+		w := serve(http.MethodPost, "/api/v2/allocate", AllocateArgs{SessionID: sessionID1, BuildID: buildID1})
+		e := expectError(w, http.StatusConflict, "FailedPrecondition")
+		Expect(e.Message).To(ContainSubstring("is paused"))
+	})
+	It("should return FailedPrecondition for a build that is being deleted", func() {
+		deleted := metav1.Now()
+		Expect(k8sClient.Create(context.Background(), &mpsv1alpha1.GameServerBuild{
+			ObjectMeta: metav1.ObjectMeta{Name: buildName1, Namespace: "default", DeletionTimestamp: &deleted},
+			Spec:       mpsv1alpha1.GameServerBuildSpec{BuildID: buildID1},
+		})).To(Succeed())
+		w := serve(http.MethodPost, "/api/v2/allocate", AllocateArgs{SessionID: sessionID1, BuildID: buildID1})
+		e := expectError(w, http.StatusConflict, "FailedPrecondition")
+		Expect(e.Message).To(ContainSubstring("is being deleted"))
+	})
+	It("should allocate and return camelCase JSON", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, "", mpsv1alpha1.GameServerStateStandingBy)
+		Expect(err).ToNot(HaveOccurred())
+		w := serve(http.MethodPost, "/api/v2/allocate", AllocateArgs{SessionID: sessionID1, BuildID: buildID1})
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+		var rs map[string]interface{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &rs)).To(Succeed())
+		Expect(rs).To(HaveKeyWithValue("sessionID", sessionID1))
+		Expect(rs).To(HaveKey("ipv4Address"))
+		Expect(rs).To(HaveKey("ports"))
+	})
+	It("should return a result for each allocation of a batch", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		w := serve(http.MethodPost, "/api/v2/allocate/batch", BatchAllocateArgsV2{Allocations: []AllocateArgs{
+			{SessionID: sessionID1, BuildID: buildID1},
+			{SessionID: sessionID2, BuildID: "NOT_A_GUID"},
+		}})
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var rs BatchAllocateResponseV2
+		Expect(json.Unmarshal(w.Body.Bytes(), &rs)).To(Succeed())
+		Expect(rs.Results).To(HaveLen(2))
+		Expect(rs.Results[0].Error).To(BeNil())
+		Expect(rs.Results[0].Allocation.SessionID).To(Equal(sessionID1))
+		Expect(rs.Results[1].Allocation).To(BeNil())
+		Expect(rs.Results[1].Error.Code).To(Equal("InvalidArgument"))
+		Expect(rs.Results[1].Error.Details).To(Equal([]FieldError{{Field: "buildID", Message: "must be a UUID"}}))
+	})
+	It("should reject an empty batch", func() {
+		expectError(serve(http.MethodPost, "/api/v2/allocate/batch", BatchAllocateArgsV2{}), http.StatusBadRequest, "InvalidArgument")
+	})
+	It("should return a session", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		w := serve(http.MethodGet, "/api/v2/sessions/"+sessionID1, nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var rs GameServerDetailsV2
+		Expect(json.Unmarshal(w.Body.Bytes(), &rs)).To(Succeed())
+		Expect(rs.Name).To(Equal(gsName))
+		Expect(rs.BuildName).To(Equal(buildName1))
+		Expect(rs.SessionID).To(Equal(sessionID1))
+		Expect(w.Body.String()).To(ContainSubstring(`"buildName"`))
+	})
+	It("should terminate a session", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, sessionID1, mpsv1alpha1.GameServerStateActive)
+		Expect(err).ToNot(HaveOccurred())
+		w := serve(http.MethodDelete, "/api/v2/sessions/"+sessionID1, nil)
+		Expect(w.Code).To(Equal(http.StatusNoContent))
+		expectError(serve(http.MethodGet, "/api/v2/sessions/"+sessionID1, nil), http.StatusNotFound, "NotFound")
+	})
+	It("should return field details for invalid player updates", func() {
+		w := serve(http.MethodPatch, "/api/v2/sessions/"+sessionID1+"/players", UpdatePlayersArgs{PlayersToAdd: []string{""}})
+		e := expectError(w, http.StatusBadRequest, "InvalidArgument")
+		Expect(e.Details).To(Equal([]FieldError{{Field: "playersToAdd[0]", Message: "must not be empty"}}))
+	})
+	It("should list builds and their game servers", func() {
+		err := createTestGameServerAndBuild(k8sClient, gsName, buildName1, buildID1, "", mpsv1alpha1.GameServerStateStandingBy)
+		Expect(err).ToNot(HaveOccurred())
+
+		w := serve(http.MethodGet, "/api/v2/builds", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var builds ListBuildsResponseV2
+		Expect(json.Unmarshal(w.Body.Bytes(), &builds)).To(Succeed())
+		Expect(builds.Total).To(Equal(1))
+		Expect(builds.Builds[0].BuildID).To(Equal(buildID1))
+
+		w = serve(http.MethodGet, "/api/v2/builds/"+buildID1+"/gameservers", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		var gameServers ListGameServersResponseV2
+		Expect(json.Unmarshal(w.Body.Bytes(), &gameServers)).To(Succeed())
+		Expect(gameServers.Total).To(Equal(1))
+		Expect(gameServers.GameServers[0].Name).To(Equal(gsName))
+	})
+	It("should return field details for invalid paging arguments", func() {
+		e := expectError(serve(http.MethodGet, "/api/v2/builds?offset=-1&limit=0", nil), http.StatusBadRequest, "InvalidArgument")
+		Expect(e.Details).To(HaveLen(2))
+		e = expectError(serve(http.MethodGet, "/api/v2/builds/"+buildID1+"/gameservers?state=Unknown", nil), http.StatusBadRequest, "InvalidArgument")
+		Expect(e.Details).To(Equal([]FieldError{{Field: "state", Message: "must be StandingBy, Active, Crashed or GameCompleted"}}))
+	})
+	It("should return the sessions of a player", func() {
+		w := serve(http.MethodGet, "/api/v2/players/player1/sessions", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(MatchJSON(`{"playerID":"player1","sessions":[]}`))
+	})
+	It("should serve an OpenAPI document that contains every route", func() {
+		w := serve(http.MethodGet, "/api/v2/openapi.json", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+		var doc struct {
+			OpenAPI string                            `json:"openapi"`
+			Paths   map[string]map[string]interface{} `json:"paths"`
+		}
+		Expect(json.Unmarshal(w.Body.Bytes(), &doc)).To(Succeed())
+		Expect(doc.OpenAPI).To(HavePrefix("3."))
+		Expect(doc.Paths).To(HaveKey("/allocate"))
+		Expect(doc.Paths["/allocate"]).To(HaveKey("post"))
+		Expect(doc.Paths["/allocate/batch"]).To(HaveKey("post"))
+		Expect(doc.Paths).To(HaveKey("/builds"))
+		Expect(doc.Paths).To(HaveKey("/builds/{buildID}/gameservers"))
+		Expect(doc.Paths["/sessions/{sessionID}"]).To(HaveKey("get"))
+		Expect(doc.Paths["/sessions/{sessionID}"]).To(HaveKey("delete"))
+		Expect(doc.Paths["/sessions/{sessionID}/players"]).To(HaveKey("patch"))
+		Expect(doc.Paths).To(HaveKey("/players/{playerID}/sessions"))
+	})
+	It("should return JSON errors for unauthenticated v2 requests", func() {
+		authenticator := &tokenAuthenticator{apiKeys: newApiKeyStore()}
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/builds", nil)
+		w := httptest.NewRecorder()
+		authenticator.middleware(h).ServeHTTP(w, req)
+		expectError(w, http.StatusUnauthorized, "Unauthenticated")
+		Expect(w.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+	})
+	It("should keep plain text errors on v1", func() {
+		req := httptest.NewRequest(http.MethodGet, sessionsPath+"NOT_A_GUID", nil)
+		w := httptest.NewRecorder()
+		(&sessionHandler{}).handle(w, req)
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Header().Get("Content-Type")).To(Equal("text/plain; charset=utf-8"))
+		Expect(w.Body.String()).To(HavePrefix("400 - invalid arguments"))
+	})
+})
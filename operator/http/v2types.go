@@ -0,0 +1,114 @@
+package http
+
+// the types of the v2 REST API have the same fields as their v1 counterparts, but they are serialized in camelCase
+
+// AllocateResponseV2 contains details that are returned on a successful GameServer allocation call
+type AllocateResponseV2 struct {
+	IPV4Address string `json:"ipv4Address"`
+	Ports       string `json:"ports"`
+	SessionID   string `json:"sessionID"`
+}
+
+// BatchAllocateArgsV2 contains the allocations of a batch allocation call
+type BatchAllocateArgsV2 struct {
+	Allocations []AllocateArgs `json:"allocations"`
+}
+
+// BatchAllocateResponseV2 contains a result for each allocation of a batch allocation call, in the same order
+type BatchAllocateResponseV2 struct {
+	Results []BatchAllocateResultV2 `json:"results"`
+}
+
+// BatchAllocateResultV2 contains either the allocated GameServer or the error of a single allocation of a batch
+type BatchAllocateResultV2 struct {
+	Allocation *AllocateResponseV2 `json:"allocation,omitempty"`
+	Error      *ErrorV2            `json:"error,omitempty"`
+}
+
+// GameServerDetailsV2 contains details about a GameServer that are returned by the session and build lookup calls
+type GameServerDetailsV2 struct {
+	Name             string   `json:"name"`
+	Namespace        string   `json:"namespace"`
+	BuildName        string   `json:"buildName"`
+	BuildID          string   `json:"buildID"`
+	NodeName         string   `json:"nodeName"`
+	IPV4Address      string   `json:"ipv4Address"`
+	Ports            string   `json:"ports"`
+	State            string   `json:"state"`
+	Health           string   `json:"health"`
+	SessionID        string   `json:"sessionID,omitempty"`
+	SessionCookie    string   `json:"sessionCookie,omitempty"`
+	InitialPlayers   []string `json:"initialPlayers,omitempty"`
+	ConnectedPlayers []string `json:"connectedPlayers,omitempty"`
+}
+
+// PlayerSessionsResponseV2 contains the existing GameServers that have a player in their initial or connected players
+type PlayerSessionsResponseV2 struct {
+	PlayerID string                `json:"playerID"`
+	Sessions []GameServerDetailsV2 `json:"sessions"`
+}
+
+// BuildDetailsV2 contains details about a GameServerBuild that are returned by the build listing call
+type BuildDetailsV2 struct {
+	Name                string `json:"name"`
+	Namespace           string `json:"namespace"`
+	BuildID             string `json:"buildID"`
+	TitleID             string `json:"titleID"`
+	StandingBy          int    `json:"standingBy"`
+	Max                 int    `json:"max"`
+	CurrentInitializing int    `json:"currentInitializing"`
+	CurrentStandingBy   int    `json:"currentStandingBy"`
+	CurrentActive       int    `json:"currentActive"`
+	CrashesCount        int    `json:"crashesCount"`
+	Health              string `json:"health"`
+}
+
+// ListBuildsResponseV2 contains a page of GameServerBuilds
+// NextOffset is omitted when there are no more results
+type ListBuildsResponseV2 struct {
+	Builds     []BuildDetailsV2 `json:"builds"`
+	Total      int              `json:"total"`
+	NextOffset int              `json:"nextOffset,omitempty"`
+}
+
+// ListGameServersResponseV2 contains a page of GameServers
+// NextOffset is omitted when there are no more results
+type ListGameServersResponseV2 struct {
+	GameServers []GameServerDetailsV2 `json:"gameServers"`
+	Total       int                   `json:"total"`
+	NextOffset  int                   `json:"nextOffset,omitempty"`
+}
+
+// ErrorResponseV2 is the body of every v2 error response
+type ErrorResponseV2 struct {
+	Error ErrorV2 `json:"error"`
+}
+
+// ErrorV2 describes an error of the v2 API
+// Code is a machine-readable name of the Status, Details contains the invalid fields of a request
+// RetryAfterSeconds has the value of the Retry-After header, so that it's also available for the errors of a batch allocation
+type ErrorV2 struct {
+	Status            int          `json:"status"`
+	Code              string       `json:"code"`
+	Message           string       `json:"message"`
+	Details           []FieldError `json:"details,omitempty"`
+	RetryAfterSeconds int          `json:"retryAfterSeconds,omitempty"`
+}
+
+// newGameServerDetailsListV2 converts a list of v1 GameServerDetails to the v2 type
+func newGameServerDetailsListV2(list []GameServerDetails) []GameServerDetailsV2 {
+	result := make([]GameServerDetailsV2, 0, len(list))
+	for _, gsd := range list {
+		result = append(result, GameServerDetailsV2(gsd))
+	}
+	return result
+}
+
+// newListBuildsResponseV2 converts a v1 ListBuildsResponse to the v2 type
+func newListBuildsResponseV2(rs *ListBuildsResponse) *ListBuildsResponseV2 {
+	builds := make([]BuildDetailsV2, 0, len(rs.Builds))
+	for _, b := range rs.Builds {
+		builds = append(builds, BuildDetailsV2(b))
+	}
+	return &ListBuildsResponseV2{Builds: builds, Total: rs.Total, NextOffset: rs.NextOffset}
+}
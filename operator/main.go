@@ -20,11 +20,11 @@ import (
 	"context"
 	"flag"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -35,12 +35,12 @@ import (
 
 	"github.com/go-logr/logr"
 	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	mpsv1beta1 "github.com/playfab/thundernetes/operator/api/v1beta1"
 	"github.com/playfab/thundernetes/operator/controllers"
 
 	//+kubebuilder:scaffold:imports
 
 	"github.com/playfab/thundernetes/operator/http"
-	corev1 "k8s.io/api/core/v1"
 )
 
 var (
@@ -50,15 +50,14 @@ var (
 )
 
 const (
-	secretName          = "tls-secret"
-	certificateFileName = "tls.crt"
-	privateKeyFileName  = "tls.key"
+	secretName = "tls-secret"
 )
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(mpsv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(mpsv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -103,15 +102,11 @@ func main() {
 		setupLog.Error(err, "unable to start live API client")
 		os.Exit(1)
 	}
-	var crt, key []byte
+	var tlsOptions *http.TLSOptions
 	apiServiceSecurity := os.Getenv("API_SERVICE_SECURITY")
 
 	if apiServiceSecurity == "usetls" {
-		crt, key, err = getTlsSecret(k8sClient, namespace)
-		if err != nil {
-			setupLog.Error(err, "unable to get TLS secret")
-			os.Exit(1)
-		}
+		tlsOptions = getTlsOptions(namespace)
 	}
 
 	if err = initializePortRegistry(k8sClient, setupLog); err != nil {
@@ -138,14 +133,58 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "GameServerBuild")
 		os.Exit(1)
 	}
+	// the webhooks need the serving certificate of the webhook server, set ENABLE_WEBHOOKS=false to run the controller without them, e.g. locally
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&mpsv1alpha1.GameServerBuild{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GameServerBuild")
+			os.Exit(1)
+		}
+		if err = (&mpsv1alpha1.GameServer{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GameServer")
+			os.Exit(1)
+		}
+		if err = (&mpsv1beta1.GameServerBuild{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GameServerBuild")
+			os.Exit(1)
+		}
+		if err = (&mpsv1beta1.GameServer{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GameServer")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
-	err = http.NewApiServer(mgr, crt, key)
+	tokenAuthOptions := &http.TokenAuthOptions{
+		Namespace: namespace,
+		APIKeys:   os.Getenv("API_SERVICE_API_KEYS") == "true",
+		JWKSFile:  os.Getenv("API_SERVICE_JWKS_FILE"),
+		Issuer:    os.Getenv("API_SERVICE_JWT_ISSUER"),
+		Audience:  os.Getenv("API_SERVICE_JWT_AUDIENCE"),
+	}
+
+	var allocationLimits *http.AllocationLimits
+	if limitsFile := os.Getenv("API_SERVICE_ALLOCATION_LIMITS_FILE"); limitsFile != "" {
+		allocationLimits, err = http.ReadAllocationLimitsFile(limitsFile)
+		if err != nil {
+			setupLog.Error(err, "unable to read allocation limits")
+			os.Exit(1)
+		}
+	}
+
+	err = http.NewApiServer(mgr, tlsOptions, tokenAuthOptions, allocationLimits)
 	if err != nil {
 		setupLog.Error(err, "unable to create HTTP API Server", "API Server", "HTTP API Server")
 		os.Exit(1)
 	}
 
+	// the custom and external metrics APIs are registered as aggregated APIs, set ENABLE_METRICS_API=true after applying config/metricsapi
+	if os.Getenv("ENABLE_METRICS_API") == "true" {
+		if err = http.NewMetricsApiServer(mgr, &http.MetricsApiOptions{CertDir: http.DefaultMetricsApiCertDir}); err != nil {
+			setupLog.Error(err, "unable to create metrics API server")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -177,14 +216,34 @@ func initializePortRegistry(k8sClient client.Client, setupLog logr.Logger) error
 	return nil
 }
 
-func getTlsSecret(k8sClient client.Client, namespace string) ([]byte, []byte, error) {
-	var secret corev1.Secret
-	err := k8sClient.Get(context.Background(), types.NamespacedName{
-		Name:      secretName,
-		Namespace: namespace,
-	}, &secret)
-	if err != nil {
-		return nil, nil, err
+// getTlsOptions returns the TLS options of the API server
+// the CA bundle for the client certificates is read from the Secret named in API_SERVICE_CA_SECRET_NAME (default: tls-secret)
+// API_SERVICE_ALLOW_UNVERIFIED_CLIENTS=true keeps the API server running without client certificate verification if tls-secret has no CA bundle
+func getTlsOptions(namespace string) *http.TLSOptions {
+	caSecretName := os.Getenv("API_SERVICE_CA_SECRET_NAME")
+	if caSecretName == "" {
+		caSecretName = secretName
+	}
+	return &http.TLSOptions{
+		Namespace:    namespace,
+		SecretName:   secretName,
+		CASecretName: caSecretName,
+		ClientAuth: http.ClientAuthOptions{
+			AllowedSubjects: splitList(os.Getenv("API_SERVICE_ALLOWED_CLIENT_SUBJECTS")),
+			AllowedSANs:     splitList(os.Getenv("API_SERVICE_ALLOWED_CLIENT_SANS")),
+		},
+		AllowUnverifiedClients: os.Getenv("API_SERVICE_ALLOW_UNVERIFIED_CLIENTS") == "true",
+	}
+}
+
+// splitList returns the non-empty, trimmed values of a semicolon separated list
+// semicolons are used since subjects contain commas, e.g. "CN=matchmaker,O=Contoso;CN=lobby"
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
 	}
-	return []byte(secret.Data[certificateFileName]), []byte(secret.Data[privateKeyFileName]), nil
+	return values
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"regexp"
 	"sync"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -29,18 +30,21 @@ var (
 	userSetSessionDetails = &SessionDetails{
 		State: string(GameStateInvalid),
 	}
-	watchStopper = make(chan struct{})
-	mux          = &sync.RWMutex{}
+	// nextScheduledMaintenanceUtc is the next scheduled maintenance of the Node, it's set on the GameServer status by the controller
+	nextScheduledMaintenanceUtc string
+	watchStopper                = make(chan struct{})
+	mux                         = &sync.RWMutex{}
 )
 
 const logEveryHeartbeat = false
 
 type httpHandler struct {
-	k8sClient           dynamic.Interface
-	previousGameState   GameState
-	previousGameHealth  string
-	gameServerName      string
-	gameServerNamespace string
+	k8sClient                dynamic.Interface
+	previousGameState        GameState
+	previousGameHealth       string
+	previousConnectedPlayers []string
+	gameServerName           string
+	gameServerNamespace      string
 }
 
 func NewHttpHandler(k8sClient dynamic.Interface, gameServerName, gameServerNamespace string) httpHandler {
@@ -65,17 +69,27 @@ func (h *httpHandler) setupWatch() {
 	dynInformer := dynamicinformer.NewFilteredDynamicSharedInformerFactory(h.k8sClient, 0, h.gameServerNamespace, listOptions)
 	informer := dynInformer.ForResource(gameserverGVR).Informer()
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    gameServerAdded,
 		UpdateFunc: gameServerUpdated,
 	})
 
 	go informer.Run(watchStopper)
 }
 
+func gameServerAdded(obj interface{}) {
+	updateNextScheduledMaintenance(obj.(*unstructured.Unstructured))
+}
+
 func gameServerUpdated(oldObj, newObj interface{}) {
 	// dynamic client returns an unstructured object
 	old := oldObj.(*unstructured.Unstructured)
 	new := newObj.(*unstructured.Unstructured)
 
+	// the maintenance of the Node can be scheduled in any state
+	updateNextScheduledMaintenance(new)
+	// the controller asks an Active game server to terminate when it exceeds the MaxActiveDuration of its build
+	updateTerminationRequested(new)
+
 	// get the old and the new state from .status.state
 	oldState, oldStateExists, oldStateErr := unstructured.NestedString(old.Object, "status", "state")
 	newState, newStateExists, newStateErr := unstructured.NestedString(new.Object, "status", "state")
@@ -111,10 +125,59 @@ func gameServerUpdated(oldObj, newObj interface{}) {
 			State:          string(GameStateActive),
 		}
 		mux.Unlock()
+		// we keep watching after the allocation, since the allowed players of the session can be updated (late join/backfill)
+		return
+	}
+
+	// if the players of an Active GameServer were updated
+	if oldState == string(GameStateActive) && newState == string(GameStateActive) {
+		// we don't care about errors here, a missing or invalid value is treated as an empty list
+		oldInitialPlayers, _, _ := unstructured.NestedStringSlice(old.Object, "status", "initialPlayers")
+		newInitialPlayers, _, _ := unstructured.NestedStringSlice(new.Object, "status", "initialPlayers")
+		if stringSlicesEqual(oldInitialPlayers, newInitialPlayers) {
+			return
+		}
+
+		fmt.Printf("Got updated players for the session, initialPlayers:%#v\n", newInitialPlayers)
+
+		mux.Lock()
+		// copy the struct since the heartbeat handler might be reading the previous one
+		sd := *userSetSessionDetails
+		sd.InitialPlayers = newInitialPlayers
+		userSetSessionDetails = &sd
+		mux.Unlock()
+	}
+}
 
-		// closing the channel will cause the informer to stop
-		// we don't expect any more state changes so we close the watch to decrease the pressue on Kubernetes API server
-		close(watchStopper)
+// updateNextScheduledMaintenance sets the next scheduled maintenance from the .status.nextScheduledMaintenance of the GameServer
+func updateNextScheduledMaintenance(u *unstructured.Unstructured) {
+	// a missing or invalid value means that no maintenance is scheduled
+	maintenance, _, _ := unstructured.NestedString(u.Object, "status", "nextScheduledMaintenance")
+
+	mux.Lock()
+	defer mux.Unlock()
+	if maintenance != nextScheduledMaintenanceUtc {
+		fmt.Printf("Next scheduled maintenance updated to %q\n", maintenance)
+		nextScheduledMaintenanceUtc = maintenance
+	}
+}
+
+// updateTerminationRequested sets the state of the Active game session to Terminating if the GameServer has a .status.terminationRequestedTime,
+// so that the heartbeat responses contain the Terminate operation
+func updateTerminationRequested(u *unstructured.Unstructured) {
+	requested, _, _ := unstructured.NestedString(u.Object, "status", "terminationRequestedTime")
+	if requested == "" {
+		return
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	if userSetSessionDetails.State == string(GameStateActive) {
+		fmt.Printf("Termination requested at %s, the game server will be asked to terminate\n", requested)
+		// copy the struct since the heartbeat handler might be reading the previous one
+		sd := *userSetSessionDetails
+		sd.State = string(GameStateTerminating)
+		userSetSessionDetails = &sd
 	}
 }
 
@@ -172,6 +235,12 @@ func (h *httpHandler) heartbeatHandler(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if err := h.updateConnectedPlayersIfNeeded(ctx, &hb); err != nil {
+		fmt.Printf("error updating connected players %s\n", err.Error())
+		internalServerError(w, err, "error updating connected players")
+		return
+	}
+
 	if h.previousGameState != hb.CurrentGameState && hb.CurrentGameState == GameStateStandingBy {
 		if err := h.transitionStateToStandingBy(ctx, &hb); err != nil {
 			fmt.Printf("error updating state %s\n", err.Error())
@@ -184,6 +253,7 @@ func (h *httpHandler) heartbeatHandler(w http.ResponseWriter, req *http.Request)
 
 	mux.RLock()
 	sd := userSetSessionDetails
+	maintenance := nextScheduledMaintenanceUtc
 	mux.RUnlock()
 
 	if sd.State == string(GameStateInvalid) { // user has not set the status yet
@@ -210,8 +280,9 @@ func (h *httpHandler) heartbeatHandler(w http.ResponseWriter, req *http.Request)
 	}
 
 	hr := &HeartbeatResponse{
-		Operation:     op,
-		SessionConfig: *sc,
+		Operation:                   op,
+		SessionConfig:               *sc,
+		NextScheduledMaintenanceUtc: maintenance,
 	}
 	json, _ := json.Marshal(hr)
 	w.WriteHeader(http.StatusOK)
@@ -235,9 +306,34 @@ func (h *httpHandler) updateHealthIfNeeded(ctx context.Context, hb *HeartbeatReq
 	return nil
 }
 
+// updateConnectedPlayersIfNeeded patches the GameServer .Status with the IDs of the players connected to the game, if they have changed
+func (h *httpHandler) updateConnectedPlayersIfNeeded(ctx context.Context, hb *HeartbeatRequest) error {
+	connectedPlayers := getConnectedPlayerIDs(hb.CurrentPlayers)
+	if stringSlicesEqual(h.previousConnectedPlayers, connectedPlayers) {
+		return nil
+	}
+	fmt.Printf("Connected players are different than before, updating. Old players %v, new players %v\n", h.previousConnectedPlayers, connectedPlayers)
+	payload := map[string]interface{}{
+		"status": map[string]interface{}{
+			"connectedPlayers": connectedPlayers,
+		},
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = h.k8sClient.Resource(gameserverGVR).Namespace(h.gameServerNamespace).Patch(ctx, h.gameServerName, types.MergePatchType, payloadBytes, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return err
+	}
+	h.previousConnectedPlayers = connectedPlayers
+	return nil
+}
+
 func (h *httpHandler) transitionStateToStandingBy(ctx context.Context, hb *HeartbeatRequest) error {
 	fmt.Printf("State is different than before, updating. Old state %s, new state StandingBy\n", h.previousGameState)
-	payload := fmt.Sprintf("{\"status\":{\"state\":\"%s\"}}", hb.CurrentGameState)
+	// the standingByTime is set in the same patch, so that it is the time the state changed
+	payload := fmt.Sprintf("{\"status\":{\"state\":\"%s\",\"standingByTime\":\"%s\"}}", hb.CurrentGameState, time.Now().UTC().Format(time.RFC3339))
 	payloadBytes := []byte(payload)
 	_, err := h.k8sClient.Resource(gameserverGVR).Namespace(h.gameServerNamespace).Patch(ctx, h.gameServerName, types.MergePatchType, payloadBytes, metav1.PatchOptions{}, "status")
 
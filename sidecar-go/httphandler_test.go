@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -74,6 +75,140 @@ var _ = Describe("API server tests", func() {
 		hbr := HeartbeatResponse{}
 		_ = json.Unmarshal(resBody, &hbr)
 		Expect(hbr.Operation).To(Equal(GameOperationContinue))
+
+		u, err := h.k8sClient.Resource(gameserverGVR).Namespace(gameServerNamespace).Get(context.Background(), gameServerName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		state, _, _ := unstructured.NestedString(u.Object, "status", "state")
+		Expect(state).To(Equal(string(GameStateStandingBy)))
+		standingByTime, _, _ := unstructured.NestedString(u.Object, "status", "standingByTime")
+		_, err = time.Parse(time.RFC3339, standingByTime)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("heartbeat with connected players should update the GameServer", func() {
+		hb := &HeartbeatRequest{
+			CurrentGameState:  GameStateStandingBy,
+			CurrentGameHealth: "Healthy",
+			CurrentPlayers:    []ConnectedPlayer{{PlayerId: "player1"}, {PlayerId: "player2"}},
+		}
+		b, _ := json.Marshal(hb)
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessionHosts/sessionHostID", bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		h := NewHttpHandler(newDynamicInterface(), gameServerName, gameServerNamespace)
+		gs := createUnstructuredTestGameServer(gameServerName, gameServerNamespace)
+
+		_, err := h.k8sClient.Resource(gameserverGVR).Namespace(gameServerNamespace).Create(context.Background(), gs, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		h.heartbeatHandler(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+		u, err := h.k8sClient.Resource(gameserverGVR).Namespace(gameServerNamespace).Get(context.Background(), gameServerName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		connectedPlayers, _, err := unstructured.NestedStringSlice(u.Object, "status", "connectedPlayers")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(connectedPlayers).To(Equal([]string{"player1", "player2"}))
+	})
+	It("updated players of an Active GameServer should be returned on the heartbeat", func() {
+		oldGs := createUnstructuredTestGameServer(gameServerName, gameServerNamespace)
+		newGs := createUnstructuredTestGameServer(gameServerName, gameServerNamespace)
+		Expect(unstructured.SetNestedField(oldGs.Object, string(GameStateStandingBy), "status", "state")).To(Succeed())
+		Expect(unstructured.SetNestedField(newGs.Object, string(GameStateActive), "status", "state")).To(Succeed())
+		Expect(unstructured.SetNestedStringSlice(newGs.Object, []string{"player1"}, "status", "initialPlayers")).To(Succeed())
+		gameServerUpdated(oldGs, newGs)
+
+		updatedGs := newGs.DeepCopy()
+		Expect(unstructured.SetNestedStringSlice(updatedGs.Object, []string{"player1", "player2"}, "status", "initialPlayers")).To(Succeed())
+		gameServerUpdated(newGs, updatedGs)
+
+		hb := &HeartbeatRequest{
+			CurrentGameState:  GameStateActive,
+			CurrentGameHealth: "Healthy",
+		}
+		b, _ := json.Marshal(hb)
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessionHosts/sessionHostID", bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		h := NewHttpHandler(newDynamicInterface(), gameServerName, gameServerNamespace)
+		_, err := h.k8sClient.Resource(gameserverGVR).Namespace(gameServerNamespace).Create(context.Background(), createUnstructuredTestGameServer(gameServerName, gameServerNamespace), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		h.heartbeatHandler(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		resBody, err := ioutil.ReadAll(res.Body)
+		Expect(err).ToNot(HaveOccurred())
+		hbr := HeartbeatResponse{}
+		_ = json.Unmarshal(resBody, &hbr)
+		Expect(hbr.Operation).To(Equal(GameOperationActive))
+		Expect(hbr.SessionConfig.InitialPlayers).To(Equal([]string{"player1", "player2"}))
+	})
+	It("next scheduled maintenance of the GameServer should be returned on the heartbeat", func() {
+		gs := createUnstructuredTestGameServer(gameServerName, gameServerNamespace)
+		Expect(unstructured.SetNestedField(gs.Object, "2021-08-01T10:00:00Z", "status", "nextScheduledMaintenance")).To(Succeed())
+		gameServerAdded(gs)
+
+		// the GameServer is already StandingBy and Healthy, so the heartbeat does not patch it and no watch is needed
+		h := &httpHandler{
+			k8sClient:           newDynamicInterface(),
+			previousGameState:   GameStateStandingBy,
+			previousGameHealth:  "Healthy",
+			gameServerName:      gameServerName,
+			gameServerNamespace: gameServerNamespace,
+		}
+		hb := &HeartbeatRequest{
+			CurrentGameState:  GameStateStandingBy,
+			CurrentGameHealth: "Healthy",
+		}
+		b, _ := json.Marshal(hb)
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessionHosts/sessionHostID", bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		h.heartbeatHandler(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		hbr := HeartbeatResponse{}
+		Expect(json.NewDecoder(res.Body).Decode(&hbr)).To(Succeed())
+		Expect(hbr.NextScheduledMaintenanceUtc).To(Equal("2021-08-01T10:00:00Z"))
+
+		// the maintenance is cleared when the annotation is removed from the Node
+		gameServerUpdated(gs, createUnstructuredTestGameServer(gameServerName, gameServerNamespace))
+		mux.RLock()
+		defer mux.RUnlock()
+		Expect(nextScheduledMaintenanceUtc).To(BeEmpty())
+	})
+	It("termination requested for an Active GameServer should be returned on the heartbeat", func() {
+		oldGs := createUnstructuredTestGameServer(gameServerName, gameServerNamespace)
+		newGs := createUnstructuredTestGameServer(gameServerName, gameServerNamespace)
+		Expect(unstructured.SetNestedField(oldGs.Object, string(GameStateStandingBy), "status", "state")).To(Succeed())
+		Expect(unstructured.SetNestedField(newGs.Object, string(GameStateActive), "status", "state")).To(Succeed())
+		gameServerUpdated(oldGs, newGs)
+
+		terminatingGs := newGs.DeepCopy()
+		Expect(unstructured.SetNestedField(terminatingGs.Object, "2021-08-01T10:00:00Z", "status", "terminationRequestedTime")).To(Succeed())
+		gameServerUpdated(newGs, terminatingGs)
+
+		// the GameServer is already Active and Healthy, so the heartbeat does not patch it and no watch is needed
+		h := &httpHandler{
+			k8sClient:           newDynamicInterface(),
+			previousGameState:   GameStateActive,
+			previousGameHealth:  "Healthy",
+			gameServerName:      gameServerName,
+			gameServerNamespace: gameServerNamespace,
+		}
+		hb := &HeartbeatRequest{
+			CurrentGameState:  GameStateActive,
+			CurrentGameHealth: "Healthy",
+		}
+		b, _ := json.Marshal(hb)
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessionHosts/sessionHostID", bytes.NewReader(b))
+		w := httptest.NewRecorder()
+		h.heartbeatHandler(w, req)
+		res := w.Result()
+		defer res.Body.Close()
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		hbr := HeartbeatResponse{}
+		Expect(json.NewDecoder(res.Body).Decode(&hbr)).To(Succeed())
+		Expect(hbr.Operation).To(Equal(GameOperationTerminate))
 	})
 })
 
@@ -34,6 +34,29 @@ func validateHeartbeatRequestArgs(hb *HeartbeatRequest) error {
 	return nil
 }
 
+// getConnectedPlayerIDs returns the IDs of the connected players
+func getConnectedPlayerIDs(players []ConnectedPlayer) []string {
+	ids := make([]string, 0, len(players))
+	for _, p := range players {
+		ids = append(ids, p.PlayerId)
+	}
+	return ids
+}
+
+// stringSlicesEqual returns true if the two slices contain the same elements in the same order
+// nil and empty slices are considered equal
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func initializeKubernetesClient() (dynamic.Interface, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
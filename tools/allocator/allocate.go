@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	"github.com/google/uuid"
+	allocationclient "github.com/playfab/thundernetes/client"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	apiServiceName      = "thundernetes-controller-manager"
+	apiServiceNamespace = "thundernetes-system"
+	apiServicePortName  = "api"
+	apiServicePort      = 5000
+)
+
+// newAllocateCommand creates the command that allocates a game server
+func newAllocateCommand(o *options) *cobra.Command {
+	var req allocationclient.AllocateRequest
+	cmd := &cobra.Command{
+		Use:   "allocate --build-id <build-id> [--session-id <session-id>]",
+		Short: "Allocates a StandingBy game server of a build for a session",
+		Long: "Allocates a StandingBy game server of a build for a session.\n" +
+			"A new session ID is generated if it's not provided. If the session is already allocated, the game server that hosts it is returned.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if req.SessionID == "" {
+				req.SessionID = uuid.New().String()
+			}
+			ac, err := o.getAPIClient(cmd.Context())
+			if err != nil {
+				return err
+			}
+			ar, err := ac.Allocate(cmd.Context(), &req)
+			if err != nil {
+				return err
+			}
+			return o.printOutput(ar, func(w io.Writer) {
+				printRow(w, "SESSIONID", "IPV4ADDRESS", "PORTS")
+				printRow(w, ar.SessionID, ar.IPV4Address, ar.Ports)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&req.BuildID, "build-id", "", "the ID of the build")
+	cmd.Flags().StringVar(&req.SessionID, "session-id", "", "the ID of the session, a new UUID by default")
+	cmd.Flags().StringVar(&req.SessionCookie, "session-cookie", "", "the session cookie that is passed to the game server")
+	cmd.Flags().StringSliceVar(&req.InitialPlayers, "initial-players", nil, "the comma separated IDs of the initial players of the session")
+	cmd.MarkFlagRequired("build-id")
+	return cmd
+}
+
+// newTerminateCommand creates the command that terminates a session
+func newTerminateCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "terminate <session-id>",
+		Short: "Terminates a session by deleting the game server that hosts it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, err := o.getAPIClient(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err := ac.Terminate(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(o.out, "session %s terminated\n", args[0])
+			return nil
+		},
+	}
+}
+
+// getAPIClient returns a client for the thundernetes API service
+// TLS is used if the client certificate and key are set
+func (o *options) getAPIClient(ctx context.Context) (*allocationclient.Client, error) {
+	useTLS := o.tlsCert != "" && o.tlsKey != ""
+	baseURL := o.apiURL
+	if baseURL == "" {
+		host, err := o.getAPIServiceAddress(ctx)
+		if err != nil {
+			return nil, err
+		}
+		baseURL = "http://" + host
+		if useTLS {
+			baseURL = "https://" + host
+		}
+	}
+
+	opts := &allocationclient.Options{Token: o.token, ServerName: o.tlsServerName}
+	if useTLS {
+		cert, err := tls.LoadX509KeyPair(o.tlsCert, o.tlsKey)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load the client certificate: %w", err)
+		}
+		opts.ClientCertificate = &cert
+		caFile := o.tlsCA
+		if caFile == "" {
+			caFile = o.tlsCert
+		}
+		if opts.CACertificates, err = ioutil.ReadFile(caFile); err != nil {
+			return nil, fmt.Errorf("cannot read the CA certificates: %w", err)
+		}
+	}
+	return allocationclient.New(baseURL, opts)
+}
+
+// getAPIServiceAddress returns the host and port of the controller service
+// it's the load balancer address if the service has one, otherwise 127.0.0.1, e.g. for a kind cluster that maps the host ports
+func (o *options) getAPIServiceAddress(ctx context.Context) (string, error) {
+	c, err := o.getKubeClient()
+	if err != nil {
+		return "", err
+	}
+	var svc corev1.Service
+	if err := c.Get(ctx, types.NamespacedName{Name: apiServiceName, Namespace: apiServiceNamespace}, &svc); err != nil {
+		return "", fmt.Errorf("cannot get the %s/%s service, use --api-url to set the API address: %w", apiServiceNamespace, apiServiceName, err)
+	}
+
+	port := apiServicePort
+	for _, p := range svc.Spec.Ports {
+		if p.Name == apiServicePortName {
+			port = int(p.Port)
+		}
+	}
+	host := "127.0.0.1"
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			host = ingress.IP
+			break
+		}
+		if ingress.Hostname != "" {
+			host = ingress.Hostname
+			break
+		}
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	buildID1   = "85ffe8da-c82f-4035-86c5-9d2b5f42d6f5"
+	sessionID1 = "d5f075a4-517b-4bf4-8123-dfa0021aa169"
+)
+
+var _ = Describe("allocator tests", func() {
+	var kubeClient client.Client
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(mpsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		kubeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&mpsv1alpha1.GameServerBuild{
+				ObjectMeta: metav1.ObjectMeta{Name: "build1", Namespace: "default"},
+				Spec:       mpsv1alpha1.GameServerBuildSpec{BuildID: buildID1, TitleID: "1E03", StandingBy: 2, Max: 4},
+				Status:     mpsv1alpha1.GameServerBuildStatus{CurrentStandingBy: 1, CurrentActive: 1, Health: mpsv1alpha1.BuildHealthy},
+			},
+			&mpsv1alpha1.GameServerBuild{
+				ObjectMeta: metav1.ObjectMeta{Name: "build2", Namespace: "other"},
+				Status:     mpsv1alpha1.GameServerBuildStatus{Health: mpsv1alpha1.BuildUnhealthy},
+			},
+			newTestGameServer("gs1", "build1", buildID1, mpsv1alpha1.GameServerStateStandingBy, ""),
+			newTestGameServer("gs2", "build1", buildID1, mpsv1alpha1.GameServerStateActive, sessionID1),
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "gs2", Namespace: "default"},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			},
+		).Build()
+	})
+
+	run := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := newRootCommand(&options{kubeClient: kubeClient})
+		cmd.SetArgs(args)
+		cmd.SetOut(out)
+		cmd.SetErr(&bytes.Buffer{})
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+
+	It("should list the builds of a namespace as a table", func() {
+		out, err := run("list", "builds", "-n", "default")
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(strings.Fields(lines[0])).To(Equal([]string{"NAME", "BUILDID", "TITLEID", "STANDINGBY", "ACTIVE", "MAX", "CRASHES", "HEALTH"}))
+		Expect(strings.Fields(lines[1])).To(Equal([]string{"build1", buildID1, "1E03", "1/2", "1", "4", "0", "Healthy"}))
+	})
+	It("should list the builds of all namespaces filtered by health as JSON", func() {
+		out, err := run("list", "builds", "-A", "--health", "Unhealthy", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		var builds []buildSummary
+		Expect(json.Unmarshal([]byte(out), &builds)).To(Succeed())
+		Expect(builds).To(HaveLen(1))
+		Expect(builds[0].Name).To(Equal("build2"))
+		Expect(builds[0].Namespace).To(Equal("other"))
+	})
+	It("should list the game servers of a build filtered by state as YAML", func() {
+		out, err := run("list", "gameservers", "-n", "default", "--build-id", buildID1, "--state", "Active", "-o", "yaml")
+		Expect(err).ToNot(HaveOccurred())
+		var gameServers []gameServerSummary
+		Expect(yaml.Unmarshal([]byte(out), &gameServers)).To(Succeed())
+		Expect(gameServers).To(HaveLen(1))
+		Expect(gameServers[0].Name).To(Equal("gs2"))
+		Expect(gameServers[0].BuildName).To(Equal("build1"))
+		Expect(gameServers[0].SessionID).To(Equal(sessionID1))
+	})
+	It("should reject invalid filters and output formats", func() {
+		_, err := run("list", "gameservers", "-n", "default", "--state", "Unknown")
+		Expect(err).To(HaveOccurred())
+		_, err = run("list", "builds", "-n", "default", "-o", "xml")
+		Expect(err).To(HaveOccurred())
+	})
+	It("should describe a session", func() {
+		out, err := run("describe", "session", sessionID1, "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		var details sessionDetails
+		Expect(json.Unmarshal([]byte(out), &details)).To(Succeed())
+		Expect(details.SessionID).To(Equal(sessionID1))
+		Expect(details.InitialPlayers).To(Equal([]string{"player1"}))
+		Expect(details.GameServer.Name).To(Equal("gs2"))
+		Expect(details.PodPhase).To(Equal(string(corev1.PodRunning)))
+
+		out, err = run("describe", "session", sessionID1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(MatchRegexp(`Game Server:\s+default/gs2`))
+	})
+	It("should return an error for an unknown session", func() {
+		_, err := run("describe", "session", "9bb3bbb2-5031-42fd-8982-5a3f76ef2c8a")
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+	})
+	It("should allocate and terminate through the API service", func() {
+		var allocated map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/api/v2/allocate":
+				Expect(json.NewDecoder(r.Body).Decode(&allocated)).To(Succeed())
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"ipv4Address":"10.0.0.1","ports":"gameport:10000","sessionID":"` + sessionID1 + `"}`))
+			case r.Method == http.MethodDelete && r.URL.Path == "/api/v2/sessions/"+sessionID1:
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		out, err := run("allocate", "--api-url", server.URL, "--tls-cert", "", "--build-id", buildID1, "--session-id", sessionID1,
+			"--session-cookie", "cookie1", "--initial-players", "player1,player2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(allocated).To(Equal(map[string]interface{}{
+			"buildID":        buildID1,
+			"sessionID":      sessionID1,
+			"sessionCookie":  "cookie1",
+			"initialPlayers": []interface{}{"player1", "player2"},
+		}))
+		Expect(strings.Fields(strings.Split(out, "\n")[1])).To(Equal([]string{sessionID1, "10.0.0.1", "gameport:10000"}))
+
+		out, err = run("terminate", sessionID1, "--api-url", server.URL, "--tls-cert", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("terminated"))
+	})
+	It("should use the load balancer address of the controller service", func() {
+		Expect(kubeClient.Create(context.Background(), &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: apiServiceName, Namespace: apiServiceNamespace},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "api", Port: 5005}}},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "20.0.0.1"}},
+			}},
+		})).To(Succeed())
+		o := &options{kubeClient: kubeClient}
+		address, err := o.getAPIServiceAddress(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(address).To(Equal("20.0.0.1:5005"))
+	})
+})
+
+func newTestGameServer(name, buildName, buildID string, state mpsv1alpha1.GameServerState, sessionID string) *mpsv1alpha1.GameServer {
+	gs := &mpsv1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{labelBuildID: buildID, labelBuildName: buildName},
+		},
+		Spec:   mpsv1alpha1.GameServerSpec{BuildID: buildID, TitleID: "1E03"},
+		Status: mpsv1alpha1.GameServerStatus{State: state, Health: mpsv1alpha1.Healthy, SessionID: sessionID},
+	}
+	if sessionID != "" {
+		gs.Status.InitialPlayers = []string{"player1"}
+	}
+	return gs
+}
+
+func TestAllocator(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Allocator Suite")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sessionDetails contains the details of a session and of the GameServer that hosts it
+type sessionDetails struct {
+	SessionID        string            `json:"sessionID"`
+	SessionCookie    string            `json:"sessionCookie,omitempty"`
+	InitialPlayers   []string          `json:"initialPlayers,omitempty"`
+	ConnectedPlayers []string          `json:"connectedPlayers,omitempty"`
+	TitleID          string            `json:"titleID"`
+	GameServer       gameServerSummary `json:"gameServer"`
+	PodPhase         string            `json:"podPhase,omitempty"`
+}
+
+// newDescribeCommand creates the describe command and its subcommands
+func newDescribeCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "Shows the details of a session",
+	}
+	cmd.AddCommand(newDescribeSessionCommand(o))
+	return cmd
+}
+
+// newDescribeSessionCommand creates the command that shows the details of a session
+// the session is looked up in all namespaces, unless a namespace is set
+func newDescribeSessionCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "session <session-id>",
+		Short: "Shows the details of a session and of the game server that hosts it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sessionID := args[0]
+			c, err := o.getKubeClient()
+			if err != nil {
+				return err
+			}
+			var opts []client.ListOption
+			if o.namespace != "" && !o.allNamespaces {
+				opts = append(opts, client.InNamespace(o.namespace))
+			}
+			var gsList mpsv1alpha1.GameServerList
+			if err := c.List(cmd.Context(), &gsList, opts...); err != nil {
+				return err
+			}
+			var gs *mpsv1alpha1.GameServer
+			for i := range gsList.Items {
+				if gsList.Items[i].Status.SessionID == sessionID {
+					gs = &gsList.Items[i]
+					break
+				}
+			}
+			if gs == nil {
+				return fmt.Errorf("session %s not found", sessionID)
+			}
+
+			details := &sessionDetails{
+				SessionID:        sessionID,
+				SessionCookie:    gs.Status.SessionCookie,
+				InitialPlayers:   gs.Status.InitialPlayers,
+				ConnectedPlayers: gs.Status.ConnectedPlayers,
+				TitleID:          gs.Spec.TitleID,
+				GameServer:       newGameServerSummary(gs),
+			}
+			// the pod has the same name as the GameServer
+			var pod corev1.Pod
+			if err := c.Get(cmd.Context(), types.NamespacedName{Name: gs.Name, Namespace: gs.Namespace}, &pod); err == nil {
+				details.PodPhase = string(pod.Status.Phase)
+			} else if !apierrors.IsNotFound(err) {
+				return err
+			}
+
+			return o.printOutput(details, func(w io.Writer) {
+				printRow(w, "Session ID:", details.SessionID)
+				printRow(w, "Session Cookie:", valueOrNone(details.SessionCookie))
+				printRow(w, "Initial Players:", valueOrNone(strings.Join(details.InitialPlayers, ", ")))
+				printRow(w, "Connected Players:", valueOrNone(strings.Join(details.ConnectedPlayers, ", ")))
+				printRow(w, "Title ID:", details.TitleID)
+				printRow(w, "Game Server:", details.GameServer.Namespace+"/"+details.GameServer.Name)
+				printRow(w, "Build:", details.GameServer.BuildName+" ("+details.GameServer.BuildID+")")
+				printRow(w, "State:", valueOrNone(details.GameServer.State))
+				printRow(w, "Health:", valueOrNone(details.GameServer.Health))
+				printRow(w, "Node:", valueOrNone(details.GameServer.NodeName))
+				printRow(w, "Public IP:", valueOrNone(details.GameServer.PublicIP))
+				printRow(w, "Ports:", valueOrNone(details.GameServer.Ports))
+				printRow(w, "Pod Phase:", valueOrNone(details.PodPhase))
+			})
+		},
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// the labels that the controller sets on the GameServers of a GameServerBuild
+const (
+	labelBuildID   = "BuildID"
+	labelBuildName = "BuildName"
+)
+
+// buildSummary contains the details of a GameServerBuild that are printed by the list builds command
+type buildSummary struct {
+	Name                string `json:"name"`
+	Namespace           string `json:"namespace"`
+	BuildID             string `json:"buildID"`
+	TitleID             string `json:"titleID"`
+	StandingBy          int    `json:"standingBy"`
+	Max                 int    `json:"max"`
+	CurrentInitializing int    `json:"currentInitializing"`
+	CurrentStandingBy   int    `json:"currentStandingBy"`
+	CurrentActive       int    `json:"currentActive"`
+	CrashesCount        int    `json:"crashesCount"`
+	Health              string `json:"health"`
+}
+
+// gameServerSummary contains the details of a GameServer that are printed by the list gameservers command
+type gameServerSummary struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	BuildName string `json:"buildName"`
+	BuildID   string `json:"buildID"`
+	State     string `json:"state"`
+	Health    string `json:"health"`
+	NodeName  string `json:"nodeName"`
+	PublicIP  string `json:"publicIP"`
+	Ports     string `json:"ports"`
+	SessionID string `json:"sessionID,omitempty"`
+}
+
+// newListCommand creates the list command and its subcommands
+func newListCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists GameServerBuilds or GameServers",
+	}
+	cmd.AddCommand(newListBuildsCommand(o), newListGameServersCommand(o))
+	return cmd
+}
+
+// newListBuildsCommand creates the command that lists the GameServerBuilds
+func newListBuildsCommand(o *options) *cobra.Command {
+	var health string
+	cmd := &cobra.Command{
+		Use:     "builds",
+		Aliases: []string{"build", "gsb"},
+		Short:   "Lists the GameServerBuilds",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if health != "" && health != string(mpsv1alpha1.BuildHealthy) && health != string(mpsv1alpha1.BuildUnhealthy) {
+				return fmt.Errorf("invalid health %q, it must be %s or %s", health, mpsv1alpha1.BuildHealthy, mpsv1alpha1.BuildUnhealthy)
+			}
+			c, err := o.getKubeClient()
+			if err != nil {
+				return err
+			}
+			opts, err := o.listOptions()
+			if err != nil {
+				return err
+			}
+			var gsbList mpsv1alpha1.GameServerBuildList
+			if err := c.List(cmd.Context(), &gsbList, opts...); err != nil {
+				return err
+			}
+
+			builds := make([]buildSummary, 0, len(gsbList.Items))
+			for _, gsb := range gsbList.Items {
+				if health != "" && string(gsb.Status.Health) != health {
+					continue
+				}
+				builds = append(builds, newBuildSummary(&gsb))
+			}
+			return o.printOutput(builds, func(w io.Writer) {
+				header := []interface{}{"NAME", "BUILDID", "TITLEID", "STANDINGBY", "ACTIVE", "MAX", "CRASHES", "HEALTH"}
+				if o.allNamespaces {
+					header = append([]interface{}{"NAMESPACE"}, header...)
+				}
+				printRow(w, header...)
+				for _, b := range builds {
+					row := []interface{}{b.Name, b.BuildID, b.TitleID, fmt.Sprintf("%d/%d", b.CurrentStandingBy, b.StandingBy), b.CurrentActive, b.Max, b.CrashesCount, valueOrNone(b.Health)}
+					if o.allNamespaces {
+						row = append([]interface{}{b.Namespace}, row...)
+					}
+					printRow(w, row...)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVar(&health, "health", "", "only list the builds with this health: Healthy or Unhealthy")
+	return cmd
+}
+
+// newListGameServersCommand creates the command that lists the GameServers
+func newListGameServersCommand(o *options) *cobra.Command {
+	var buildID, buildName, state, nodeName string
+	cmd := &cobra.Command{
+		Use:     "gameservers",
+		Aliases: []string{"gameserver", "gs"},
+		Short:   "Lists the GameServers",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if state != "" && !isValidState(state) {
+				return fmt.Errorf("invalid state %q, it must be %s, %s, %s or %s", state, mpsv1alpha1.GameServerStateStandingBy,
+					mpsv1alpha1.GameServerStateActive, mpsv1alpha1.GameServerStateCrashed, mpsv1alpha1.GameServerStateGameCompleted)
+			}
+			c, err := o.getKubeClient()
+			if err != nil {
+				return err
+			}
+			opts, err := o.listOptions()
+			if err != nil {
+				return err
+			}
+			labels := client.MatchingLabels{}
+			if buildID != "" {
+				labels[labelBuildID] = buildID
+			}
+			if buildName != "" {
+				labels[labelBuildName] = buildName
+			}
+			var gsList mpsv1alpha1.GameServerList
+			if err := c.List(cmd.Context(), &gsList, append(opts, labels)...); err != nil {
+				return err
+			}
+
+			gameServers := make([]gameServerSummary, 0, len(gsList.Items))
+			for _, gs := range gsList.Items {
+				if state != "" && string(gs.Status.State) != state {
+					continue
+				}
+				if nodeName != "" && gs.Status.NodeName != nodeName {
+					continue
+				}
+				gameServers = append(gameServers, newGameServerSummary(&gs))
+			}
+			return o.printOutput(gameServers, func(w io.Writer) {
+				header := []interface{}{"NAME", "BUILD", "STATE", "HEALTH", "NODE", "PUBLICIP", "PORTS", "SESSIONID"}
+				if o.allNamespaces {
+					header = append([]interface{}{"NAMESPACE"}, header...)
+				}
+				printRow(w, header...)
+				for _, gs := range gameServers {
+					row := []interface{}{gs.Name, gs.BuildName, valueOrNone(gs.State), valueOrNone(gs.Health), valueOrNone(gs.NodeName),
+						valueOrNone(gs.PublicIP), valueOrNone(gs.Ports), valueOrNone(gs.SessionID)}
+					if o.allNamespaces {
+						row = append([]interface{}{gs.Namespace}, row...)
+					}
+					printRow(w, row...)
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVar(&buildID, "build-id", "", "only list the game servers of the build with this ID")
+	cmd.Flags().StringVar(&buildName, "build-name", "", "only list the game servers of the build with this name")
+	cmd.Flags().StringVar(&state, "state", "", "only list the game servers in this state: StandingBy, Active, Crashed or GameCompleted")
+	cmd.Flags().StringVar(&nodeName, "node", "", "only list the game servers on this node")
+	return cmd
+}
+
+// isValidState returns true if the state is one of the GameServer states
+func isValidState(state string) bool {
+	switch mpsv1alpha1.GameServerState(state) {
+	case mpsv1alpha1.GameServerStateStandingBy, mpsv1alpha1.GameServerStateActive, mpsv1alpha1.GameServerStateCrashed, mpsv1alpha1.GameServerStateGameCompleted:
+		return true
+	}
+	return false
+}
+
+func newBuildSummary(gsb *mpsv1alpha1.GameServerBuild) buildSummary {
+	return buildSummary{
+		Name:                gsb.Name,
+		Namespace:           gsb.Namespace,
+		BuildID:             gsb.Spec.BuildID,
+		TitleID:             gsb.Spec.TitleID,
+		StandingBy:          gsb.Spec.StandingBy,
+		Max:                 gsb.Spec.Max,
+		CurrentInitializing: gsb.Status.CurrentInitializing,
+		CurrentStandingBy:   gsb.Status.CurrentStandingBy,
+		CurrentActive:       gsb.Status.CurrentActive,
+		CrashesCount:        gsb.Status.CrashesCount,
+		Health:              string(gsb.Status.Health),
+	}
+}
+
+func newGameServerSummary(gs *mpsv1alpha1.GameServer) gameServerSummary {
+	return gameServerSummary{
+		Name:      gs.Name,
+		Namespace: gs.Namespace,
+		BuildName: gs.Labels[labelBuildName],
+		BuildID:   gs.Spec.BuildID,
+		State:     string(gs.Status.State),
+		Health:    string(gs.Status.Health),
+		NodeName:  gs.Status.NodeName,
+		PublicIP:  gs.Status.PublicIP,
+		Ports:     gs.Status.Ports,
+		SessionID: gs.Status.SessionID,
+	}
+}
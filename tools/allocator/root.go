@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// options contains the global flags of the tool and the clients that are created from them
+type options struct {
+	kubeconfig    string
+	context       string
+	namespace     string
+	allNamespaces bool
+	output        string
+
+	apiURL        string
+	tlsCert       string
+	tlsKey        string
+	tlsCA         string
+	tlsServerName string
+	token         string
+
+	out        io.Writer
+	kubeClient client.Client
+}
+
+// newRootCommand creates the allocator command and its subcommands
+func newRootCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "allocator",
+		Short:        "Allocates thundernetes game servers and inspects builds, game servers and sessions",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			o.out = cmd.OutOrStdout()
+			switch o.output {
+			case outputTable, outputJSON, outputYAML:
+				return nil
+			default:
+				return fmt.Errorf("invalid output format %q, it must be one of %s, %s or %s", o.output, outputTable, outputJSON, outputYAML)
+			}
+		},
+	}
+
+	f := cmd.PersistentFlags()
+	f.StringVar(&o.kubeconfig, "kubeconfig", "", "path to the kubeconfig file, defaults to $KUBECONFIG or ~/.kube/config")
+	f.StringVar(&o.context, "context", "", "the kubeconfig context to use, defaults to the current context")
+	f.StringVarP(&o.namespace, "namespace", "n", "", "the namespace of the builds and game servers, defaults to the namespace of the kubeconfig context")
+	f.BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "list the builds and game servers of all namespaces")
+	f.StringVarP(&o.output, "output", "o", outputTable, "the output format: table, json or yaml")
+	f.StringVar(&o.apiURL, "api-url", "", "the URL of the thundernetes API service, e.g. https://10.0.0.1:5000, defaults to the address of the controller service")
+	f.StringVar(&o.tlsCert, "tls-cert", os.Getenv("TLS_PUBLIC"), "the client certificate file for the API service, defaults to $TLS_PUBLIC")
+	f.StringVar(&o.tlsKey, "tls-key", os.Getenv("TLS_PRIVATE"), "the client key file for the API service, defaults to $TLS_PRIVATE")
+	f.StringVar(&o.tlsCA, "tls-ca", os.Getenv("TLS_CA"), "the CA file that the API service certificate is verified with, defaults to $TLS_CA or the client certificate if it's self-signed")
+	f.StringVar(&o.tlsServerName, "tls-server-name", os.Getenv("TLS_SERVER_NAME"), "the name that the API service certificate is verified with, defaults to $TLS_SERVER_NAME or the host of the API URL")
+	f.StringVar(&o.token, "token", os.Getenv("THUNDERNETES_API_TOKEN"), "the bearer token for the API service, defaults to $THUNDERNETES_API_TOKEN")
+
+	cmd.AddCommand(
+		newAllocateCommand(o),
+		newTerminateCommand(o),
+		newListCommand(o),
+		newDescribeCommand(o),
+	)
+	return cmd
+}
+
+// clientConfig returns the kubeconfig for the selected file and context
+func (o *options) clientConfig() clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.kubeconfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: o.context})
+}
+
+// getKubeClient returns a Kubernetes client for the selected kubeconfig context
+func (o *options) getKubeClient() (client.Client, error) {
+	if o.kubeClient != nil {
+		return o.kubeClient, nil
+	}
+	config, err := o.clientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the kubeconfig: %w", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := mpsv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	o.kubeClient, err = client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the Kubernetes client: %w", err)
+	}
+	return o.kubeClient, nil
+}
+
+// listOptions returns the namespace option of the list calls, which is empty if all namespaces are selected
+func (o *options) listOptions() ([]client.ListOption, error) {
+	if o.allNamespaces {
+		return nil, nil
+	}
+	namespace := o.namespace
+	if namespace == "" {
+		var err error
+		if namespace, _, err = o.clientConfig().Namespace(); err != nil {
+			return nil, fmt.Errorf("cannot get the namespace of the kubeconfig context: %w", err)
+		}
+	}
+	return []client.ListOption{client.InNamespace(namespace)}, nil
+}
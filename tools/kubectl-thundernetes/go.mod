@@ -0,0 +1,22 @@
+module github.com/playfab/thundernetes/tools/kubectl-thundernetes
+
+go 1.16
+
+require (
+	github.com/google/uuid v1.2.0
+	github.com/onsi/ginkgo v1.16.4
+	github.com/onsi/gomega v1.14.0
+	github.com/playfab/thundernetes/client v0.0.0
+	github.com/playfab/thundernetes/operator v0.0.0-20210706230151-28048dd54fdd
+	github.com/spf13/cobra v1.1.3
+	k8s.io/api v0.21.3
+	k8s.io/apimachinery v0.21.3
+	k8s.io/cli-runtime v0.21.3
+	k8s.io/client-go v0.21.3
+	sigs.k8s.io/controller-runtime v0.9.2
+	sigs.k8s.io/yaml v1.2.0
+)
+
+replace github.com/playfab/thundernetes/client => ../../client
+
+replace github.com/playfab/thundernetes/operator => ../../operator
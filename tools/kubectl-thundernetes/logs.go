@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// logLine is a line of the logs of a container
+type logLine struct {
+	container string
+	timestamp time.Time
+	text      string
+}
+
+// newLogsCommand creates the command that shows the logs of the game server and the sidecar containers of a GameServer together
+func newLogsCommand(o *options) *cobra.Command {
+	var follow, timestamps bool
+	var tail int64
+	cmd := &cobra.Command{
+		Use:   "logs <gameserver-name>",
+		Short: "Shows the logs of the game server and the sidecar containers of a GameServer, ordered by time",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := o.getCoreClient()
+			if err != nil {
+				return err
+			}
+			namespace, err := o.getNamespace()
+			if err != nil {
+				return err
+			}
+			// the pod has the same name as the GameServer
+			pod, err := cs.CoreV1().Pods(namespace).Get(cmd.Context(), args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			containers := make([]string, 0, len(pod.Spec.Containers))
+			for _, c := range pod.Spec.Containers {
+				containers = append(containers, c.Name)
+			}
+
+			logOptions := func(container string) *corev1.PodLogOptions {
+				opts := &corev1.PodLogOptions{Container: container, Follow: follow, Timestamps: true}
+				if tail >= 0 {
+					opts.TailLines = &tail
+				}
+				return opts
+			}
+			if follow {
+				return followLogs(cmd.Context(), o.out, cs, namespace, pod.Name, containers, logOptions, timestamps)
+			}
+
+			var lines []logLine
+			for _, container := range containers {
+				b, err := cs.CoreV1().Pods(namespace).GetLogs(pod.Name, logOptions(container)).DoRaw(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("cannot get the logs of container %s: %w", container, err)
+				}
+				lines = append(lines, parseLogLines(container, string(b))...)
+			}
+			sortLogLines(lines)
+			for _, l := range lines {
+				writeLogLine(o.out, l, timestamps)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "stream the logs")
+	cmd.Flags().BoolVar(&timestamps, "timestamps", false, "include the timestamps of the log lines")
+	cmd.Flags().Int64Var(&tail, "tail", -1, "the number of the most recent lines of each container to show, all lines by default")
+	return cmd
+}
+
+// followLogs streams the logs of the containers until all the streams end or the context is done
+// the lines of the containers are interleaved as they arrive
+func followLogs(ctx context.Context, out io.Writer, cs kubernetes.Interface, namespace, podName string, containers []string,
+	logOptions func(string) *corev1.PodLogOptions, timestamps bool) error {
+	var mux sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(chan error, len(containers))
+	for _, container := range containers {
+		wg.Add(1)
+		go func(container string) {
+			defer wg.Done()
+			stream, err := cs.CoreV1().Pods(namespace).GetLogs(podName, logOptions(container)).Stream(ctx)
+			if err != nil {
+				errs <- fmt.Errorf("cannot stream the logs of container %s: %w", container, err)
+				return
+			}
+			defer stream.Close()
+			scanner := bufio.NewScanner(stream)
+			for scanner.Scan() {
+				mux.Lock()
+				writeLogLine(out, parseLogLine(container, scanner.Text()), timestamps)
+				mux.Unlock()
+			}
+			if err := scanner.Err(); err != nil && ctx.Err() == nil {
+				errs <- fmt.Errorf("cannot stream the logs of container %s: %w", container, err)
+			}
+		}(container)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// parseLogLines parses the lines of the logs of a container that were requested with timestamps
+func parseLogLines(container, logs string) []logLine {
+	var lines []logLine
+	for _, s := range strings.Split(strings.TrimSuffix(logs, "\n"), "\n") {
+		if s == "" {
+			continue
+		}
+		lines = append(lines, parseLogLine(container, s))
+	}
+	return lines
+}
+
+// parseLogLine splits the timestamp that Kubernetes adds to a log line from its text
+// the timestamp is zero if the line does not start with one
+func parseLogLine(container, s string) logLine {
+	l := logLine{container: container, text: s}
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, s[:i]); err == nil {
+			l.timestamp = t
+			l.text = s[i+1:]
+		}
+	}
+	return l
+}
+
+// sortLogLines orders the lines of all the containers by their timestamp
+// the lines of each container keep their order, a line without a timestamp stays after the previous line of its container
+func sortLogLines(lines []logLine) {
+	last := map[string]time.Time{}
+	for i := range lines {
+		if lines[i].timestamp.IsZero() {
+			lines[i].timestamp = last[lines[i].container]
+		}
+		last[lines[i].container] = lines[i].timestamp
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].timestamp.Before(lines[j].timestamp) })
+}
+
+// writeLogLine writes a log line with the name of its container
+func writeLogLine(w io.Writer, l logLine, timestamps bool) {
+	if timestamps && !l.timestamp.IsZero() {
+		fmt.Fprintf(w, "[%s] %s %s\n", l.container, l.timestamp.Format(time.RFC3339Nano), l.text)
+		return
+	}
+	fmt.Fprintf(w, "[%s] %s\n", l.container, l.text)
+}
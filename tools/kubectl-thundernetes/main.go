@@ -0,0 +1,16 @@
+// kubectl-thundernetes is a kubectl plugin for the GameServerBuilds and the GameServers of thundernetes
+// install it by copying the binary to a directory in $PATH, then run it with "kubectl thundernetes"
+package main
+
+import (
+	"os"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func main() {
+	o := &options{configFlags: genericclioptions.NewConfigFlags(true)}
+	if err := newRootCommand(o).Execute(); err != nil {
+		os.Exit(1)
+	}
+}
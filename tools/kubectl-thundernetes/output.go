@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+// printOutput writes the value in the selected output format
+// the table format is written by the table function, the other formats are the JSON or YAML serialization of the value
+func (o *options) printOutput(v interface{}, table func(w io.Writer)) error {
+	switch o.output {
+	case outputJSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(o.out, string(b))
+		return err
+	case outputYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = o.out.Write(b)
+		return err
+	default:
+		w := tabwriter.NewWriter(o.out, 0, 8, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	}
+}
+
+// printRow writes the tab separated cells of a table row
+func printRow(w io.Writer, cells ...interface{}) {
+	s := make([]string, 0, len(cells))
+	for _, c := range cells {
+		s = append(s, fmt.Sprint(c))
+	}
+	fmt.Fprintln(w, strings.Join(s, "\t"))
+}
+
+// valueOrNone returns <none> for an empty table cell, like kubectl does
+func valueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
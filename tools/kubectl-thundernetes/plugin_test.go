@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	buildID1   = "85ffe8da-c82f-4035-86c5-9d2b5f42d6f5"
+	sessionID1 = "d5f075a4-517b-4bf4-8123-dfa0021aa169"
+)
+
+var _ = Describe("kubectl-thundernetes tests", func() {
+	var kubeClient client.Client
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(mpsv1alpha1.AddToScheme(scheme)).To(Succeed())
+		kubeClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&mpsv1alpha1.GameServerBuild{
+				ObjectMeta: metav1.ObjectMeta{Name: "build1", Namespace: "default"},
+				Spec:       mpsv1alpha1.GameServerBuildSpec{BuildID: buildID1, TitleID: "1E03", StandingBy: 2, Max: 4},
+				Status: mpsv1alpha1.GameServerBuildStatus{CurrentInitializing: 1, CurrentStandingBy: 1, CurrentActive: 1,
+					CrashesCount: 3, Health: mpsv1alpha1.BuildHealthy},
+			},
+			newTestGameServer("gs1", "node1", "", 10000),
+			newTestGameServer("gs2", "node1", mpsv1alpha1.GameServerStateStandingBy, 10001),
+			newTestGameServer("gs3", "node2", mpsv1alpha1.GameServerStateActive, 10002),
+		).Build()
+	})
+
+	run := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		o := &options{configFlags: genericclioptions.NewConfigFlags(false), kubeClient: kubeClient}
+		cmd := newRootCommand(o)
+		cmd.SetArgs(append(args, "-n", "default"))
+		cmd.SetOut(out)
+		cmd.SetErr(&bytes.Buffer{})
+		err := cmd.ExecuteContext(context.Background())
+		return out.String(), err
+	}
+
+	getBuild := func() *mpsv1alpha1.GameServerBuild {
+		var gsb mpsv1alpha1.GameServerBuild
+		Expect(kubeClient.Get(context.Background(), types.NamespacedName{Name: "build1", Namespace: "default"}, &gsb)).To(Succeed())
+		return &gsb
+	}
+
+	It("should show the summary of the builds as a table", func() {
+		out, err := run("summary")
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(strings.Fields(lines[1])).To(Equal([]string{"build1", buildID1, "1/2", "1/4", "1", "3", "Healthy", "false", "3", "2"}))
+	})
+	It("should show the ports and the nodes of a build as JSON", func() {
+		out, err := run("summary", "build1", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		var s buildSummary
+		Expect(json.Unmarshal([]byte(out), &s)).To(Succeed())
+		Expect(s.PortsInUse).To(Equal([]int32{10000, 10001, 10002}))
+		Expect(s.Nodes).To(Equal([]nodeSummary{
+			{Name: "node1", Initializing: 1, StandingBy: 1},
+			{Name: "node2", Active: 1},
+		}))
+
+		out, err = run("summary", "build1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(MatchRegexp(`Ports In Use:\s+10000, 10001, 10002`))
+	})
+	It("should scale a build", func() {
+		_, err := run("scale", "build1", "--standingby", "3")
+		Expect(err).ToNot(HaveOccurred())
+		gsb := getBuild()
+		Expect(gsb.Spec.StandingBy).To(Equal(3))
+		Expect(gsb.Spec.Max).To(Equal(4))
+
+		_, err = run("scale", "build1")
+		Expect(err).To(HaveOccurred())
+		_, err = run("scale", "build1", "--max", "-1")
+		Expect(err).To(HaveOccurred())
+	})
+	It("should cordon and uncordon a build", func() {
+		out, err := run("cordon", "build1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("cordoned"))
+		Expect(getBuild().IsCordoned()).To(BeTrue())
+
+		_, err = run("uncordon", "build1")
+		Expect(err).ToNot(HaveOccurred())
+		gsb := getBuild()
+		Expect(gsb.IsCordoned()).To(BeFalse())
+		Expect(gsb.Annotations).ToNot(HaveKey(mpsv1alpha1.CordonedAnnotation))
+	})
+	It("should pause and resume a build", func() {
+		out, err := run("pause", "build1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("paused"))
+		Expect(getBuild().Spec.Paused).To(BeTrue())
+
+		_, err = run("resume", "build1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getBuild().Spec.Paused).To(BeFalse())
+	})
+	It("should allocate a session on a build through the API service", func() {
+		var allocated map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(json.NewDecoder(r.Body).Decode(&allocated)).To(Succeed())
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ipv4Address":"10.0.0.1","ports":"gameport:10000","sessionID":"` + sessionID1 + `"}`))
+		}))
+		defer server.Close()
+
+		_, err := run("allocate", "build1", "--session-id", sessionID1, "--api-url", server.URL, "--api-tls-cert", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(allocated).To(HaveKeyWithValue("buildID", buildID1))
+		Expect(allocated).To(HaveKeyWithValue("sessionID", sessionID1))
+	})
+	It("should show the logs of all the containers of a game server", func() {
+		out := &bytes.Buffer{}
+		o := &options{
+			configFlags: genericclioptions.NewConfigFlags(false),
+			coreClient: kubefake.NewSimpleClientset(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "gs3", Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "gameserver"}, {Name: "thundernetes-sidecar"}}},
+			}),
+		}
+		cmd := newRootCommand(o)
+		cmd.SetArgs([]string{"logs", "gs3", "-n", "default"})
+		cmd.SetOut(out)
+		Expect(cmd.ExecuteContext(context.Background())).To(Succeed())
+		// the fake clientset returns the same logs for every container
+		Expect(out.String()).To(Equal("[gameserver] fake logs\n[thundernetes-sidecar] fake logs\n"))
+	})
+	It("should order the log lines of the containers by time", func() {
+		lines := append(
+			parseLogLines("gameserver", "2021-08-10T10:00:01.5Z game 1\ncontinued\n2021-08-10T10:00:03Z game 2\n"),
+			parseLogLines("thundernetes-sidecar", "2021-08-10T10:00:00Z sidecar 1\n2021-08-10T10:00:02Z sidecar 2\n")...,
+		)
+		sortLogLines(lines)
+		out := &bytes.Buffer{}
+		for _, l := range lines {
+			writeLogLine(out, l, false)
+		}
+		Expect(out.String()).To(Equal("[thundernetes-sidecar] sidecar 1\n" +
+			"[gameserver] game 1\n" +
+			"[gameserver] continued\n" +
+			"[thundernetes-sidecar] sidecar 2\n" +
+			"[gameserver] game 2\n"))
+
+		out.Reset()
+		writeLogLine(out, lines[0], true)
+		Expect(out.String()).To(Equal("[thundernetes-sidecar] 2021-08-10T10:00:00Z sidecar 1\n"))
+	})
+})
+
+func newTestGameServer(name, nodeName string, state mpsv1alpha1.GameServerState, hostPort int32) *mpsv1alpha1.GameServer {
+	return &mpsv1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{labelBuildName: "build1"},
+		},
+		Spec: mpsv1alpha1.GameServerSpec{
+			BuildID: buildID1,
+			TitleID: "1E03",
+			PodSpec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "gameserver",
+				Ports: []corev1.ContainerPort{{Name: "gameport", ContainerPort: 80, HostPort: hostPort}},
+			}}},
+		},
+		Status: mpsv1alpha1.GameServerStatus{State: state, NodeName: nodeName},
+	}
+}
+
+func TestKubectlThundernetes(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "kubectl-thundernetes Suite")
+}
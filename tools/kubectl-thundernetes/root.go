@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// options contains the flags of the plugin and the clients that are created from them
+// configFlags contains the standard kubectl flags, like --kubeconfig, --context and --namespace
+type options struct {
+	configFlags *genericclioptions.ConfigFlags
+	output      string
+
+	apiURL        string
+	tlsCert       string
+	tlsKey        string
+	tlsCA         string
+	tlsServerName string
+	token         string
+
+	out        io.Writer
+	kubeClient client.Client
+	coreClient kubernetes.Interface
+}
+
+// newRootCommand creates the thundernetes command and its subcommands
+func newRootCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "kubectl thundernetes",
+		Short:        "Manages thundernetes GameServerBuilds, GameServers and sessions",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			o.out = cmd.OutOrStdout()
+			switch o.output {
+			case outputTable, outputJSON, outputYAML:
+				return nil
+			default:
+				return fmt.Errorf("invalid output format %q, it must be one of %s, %s or %s", o.output, outputTable, outputJSON, outputYAML)
+			}
+		},
+	}
+
+	f := cmd.PersistentFlags()
+	o.configFlags.AddFlags(f)
+	f.StringVarP(&o.output, "output", "o", outputTable, "the output format: table, json or yaml")
+	f.StringVar(&o.apiURL, "api-url", "", "the URL of the thundernetes API service, e.g. https://10.0.0.1:5000, defaults to the address of the controller service")
+	f.StringVar(&o.tlsCert, "api-tls-cert", os.Getenv("TLS_PUBLIC"), "the client certificate file for the API service, defaults to $TLS_PUBLIC")
+	f.StringVar(&o.tlsKey, "api-tls-key", os.Getenv("TLS_PRIVATE"), "the client key file for the API service, defaults to $TLS_PRIVATE")
+	f.StringVar(&o.tlsCA, "api-tls-ca", os.Getenv("TLS_CA"), "the CA file that the API service certificate is verified with, defaults to $TLS_CA or the client certificate if it's self-signed")
+	f.StringVar(&o.tlsServerName, "api-tls-server-name", os.Getenv("TLS_SERVER_NAME"), "the name that the API service certificate is verified with, defaults to $TLS_SERVER_NAME or the host of the API URL")
+	f.StringVar(&o.token, "api-token", os.Getenv("THUNDERNETES_API_TOKEN"), "the bearer token for the API service, defaults to $THUNDERNETES_API_TOKEN")
+
+	cmd.AddCommand(
+		newSummaryCommand(o),
+		newScaleCommand(o),
+		newCordonCommand(o, true),
+		newCordonCommand(o, false),
+		newPauseCommand(o, true),
+		newPauseCommand(o, false),
+		newAllocateCommand(o),
+		newTerminateCommand(o),
+		newLogsCommand(o),
+	)
+	return cmd
+}
+
+// getKubeClient returns a Kubernetes client for the GameServerBuilds and the GameServers
+func (o *options) getKubeClient() (client.Client, error) {
+	if o.kubeClient != nil {
+		return o.kubeClient, nil
+	}
+	config, err := o.configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the kubeconfig: %w", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := mpsv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	o.kubeClient, err = client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the Kubernetes client: %w", err)
+	}
+	return o.kubeClient, nil
+}
+
+// getCoreClient returns a client-go clientset, which is needed to stream the pod logs
+func (o *options) getCoreClient() (kubernetes.Interface, error) {
+	if o.coreClient != nil {
+		return o.coreClient, nil
+	}
+	config, err := o.configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the kubeconfig: %w", err)
+	}
+	if o.coreClient, err = kubernetes.NewForConfig(config); err != nil {
+		return nil, fmt.Errorf("cannot create the Kubernetes client: %w", err)
+	}
+	return o.coreClient, nil
+}
+
+// getNamespace returns the selected namespace, or the namespace of the kubeconfig context
+func (o *options) getNamespace() (string, error) {
+	namespace, _, err := o.configFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return "", fmt.Errorf("cannot get the namespace of the kubeconfig context: %w", err)
+	}
+	return namespace, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newScaleCommand creates the command that sets the number of StandingBy GameServers of a GameServerBuild
+func newScaleCommand(o *options) *cobra.Command {
+	var standingBy, max int
+	cmd := &cobra.Command{
+		Use:   "scale <build-name> --standingby <count> [--max <count>]",
+		Short: "Sets the requested number of StandingBy GameServers, and optionally the maximum number of GameServers, of a GameServerBuild",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec := map[string]interface{}{}
+			if cmd.Flags().Changed("standingby") {
+				spec["standingBy"] = standingBy
+			}
+			if cmd.Flags().Changed("max") {
+				spec["max"] = max
+			}
+			if len(spec) == 0 {
+				return errors.New("at least one of --standingby and --max is required")
+			}
+			if standingBy < 0 || max < 0 {
+				return errors.New("--standingby and --max must not be negative")
+			}
+			if err := o.patchBuild(cmd, args[0], map[string]interface{}{"spec": spec}); err != nil {
+				return err
+			}
+			fmt.Fprintf(o.out, "gameserverbuild %s scaled\n", args[0])
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&standingBy, "standingby", 0, "the requested number of StandingBy GameServers")
+	cmd.Flags().IntVar(&max, "max", 0, "the maximum number of GameServers in any state")
+	return cmd
+}
+
+// newCordonCommand creates the command that cordons or uncordons a GameServerBuild
+// the controller does not create new GameServers for a cordoned GameServerBuild
+func newCordonCommand(o *options, cordon bool) *cobra.Command {
+	use, short, done := "cordon", "Stops the creation of new GameServers for a GameServerBuild", "cordoned"
+	if !cordon {
+		use, short, done = "uncordon", "Resumes the creation of new GameServers for a GameServerBuild", "uncordoned"
+	}
+	return &cobra.Command{
+		Use:   use + " <build-name>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// a null value removes the annotation
+			var value interface{}
+			if cordon {
+				value = "true"
+			}
+			patch := map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{mpsv1alpha1.CordonedAnnotation: value},
+				},
+			}
+			if err := o.patchBuild(cmd, args[0], patch); err != nil {
+				return err
+			}
+			fmt.Fprintf(o.out, "gameserverbuild %s %s\n", args[0], done)
+			return nil
+		},
+	}
+}
+
+// newPauseCommand creates the command that pauses or resumes a GameServerBuild
+// the controller does not create or delete the GameServers of a paused GameServerBuild, and its StandingBy GameServers are not allocated
+func newPauseCommand(o *options, pause bool) *cobra.Command {
+	use, short, done := "pause", "Stops the creation, deletion and allocation of the GameServers of a GameServerBuild", "paused"
+	if !pause {
+		use, short, done = "resume", "Resumes the creation, deletion and allocation of the GameServers of a GameServerBuild", "resumed"
+	}
+	return &cobra.Command{
+		Use:   use + " <build-name>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			patch := map[string]interface{}{
+				"spec": map[string]interface{}{"paused": pause},
+			}
+			if err := o.patchBuild(cmd, args[0], patch); err != nil {
+				return err
+			}
+			fmt.Fprintf(o.out, "gameserverbuild %s %s\n", args[0], done)
+			return nil
+		},
+	}
+}
+
+// patchBuild applies a JSON merge patch to a GameServerBuild of the selected namespace
+func (o *options) patchBuild(cmd *cobra.Command, name string, patch map[string]interface{}) error {
+	c, err := o.getKubeClient()
+	if err != nil {
+		return err
+	}
+	namespace, err := o.getNamespace()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	gsb := &mpsv1alpha1.GameServerBuild{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	return c.Patch(cmd.Context(), gsb, client.RawPatch(types.MergePatchType, b))
+}
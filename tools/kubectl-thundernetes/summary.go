@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	mpsv1alpha1 "github.com/playfab/thundernetes/operator/api/v1alpha1"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// the label that the controller sets on the GameServers of a GameServerBuild
+const labelBuildName = "BuildName"
+
+// buildSummary contains the state of a GameServerBuild and of its GameServers
+type buildSummary struct {
+	Name                string        `json:"name"`
+	Namespace           string        `json:"namespace"`
+	BuildID             string        `json:"buildID"`
+	TitleID             string        `json:"titleID"`
+	StandingBy          int           `json:"standingBy"`
+	Max                 int           `json:"max"`
+	CurrentInitializing int           `json:"currentInitializing"`
+	CurrentStandingBy   int           `json:"currentStandingBy"`
+	CurrentActive       int           `json:"currentActive"`
+	CrashesCount        int           `json:"crashesCount"`
+	Health              string        `json:"health"`
+	Cordoned            bool          `json:"cordoned"`
+	Paused              bool          `json:"paused"`
+	PortsInUse          []int32       `json:"portsInUse"`
+	Nodes               []nodeSummary `json:"nodes"`
+}
+
+// nodeSummary contains the number of GameServers of a GameServerBuild on a node, by state
+// the GameServers that are not scheduled yet are counted on a node with an empty name
+type nodeSummary struct {
+	Name         string `json:"name"`
+	Initializing int    `json:"initializing"`
+	StandingBy   int    `json:"standingBy"`
+	Active       int    `json:"active"`
+}
+
+// newSummaryCommand creates the command that shows a summary of one or all GameServerBuilds of a namespace
+func newSummaryCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "summary [build-name]",
+		Short: "Shows the StandingBy, Active and crashed GameServers, the host ports in use and the nodes of GameServerBuilds",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := o.getKubeClient()
+			if err != nil {
+				return err
+			}
+			namespace, err := o.getNamespace()
+			if err != nil {
+				return err
+			}
+
+			var builds []mpsv1alpha1.GameServerBuild
+			if len(args) == 1 {
+				var gsb mpsv1alpha1.GameServerBuild
+				if err := c.Get(cmd.Context(), types.NamespacedName{Name: args[0], Namespace: namespace}, &gsb); err != nil {
+					return err
+				}
+				builds = append(builds, gsb)
+			} else {
+				var gsbList mpsv1alpha1.GameServerBuildList
+				if err := c.List(cmd.Context(), &gsbList, client.InNamespace(namespace)); err != nil {
+					return err
+				}
+				builds = gsbList.Items
+			}
+
+			summaries := make([]buildSummary, 0, len(builds))
+			for i := range builds {
+				s, err := getBuildSummary(cmd.Context(), c, &builds[i])
+				if err != nil {
+					return err
+				}
+				summaries = append(summaries, *s)
+			}
+
+			if len(args) == 1 {
+				return o.printOutput(summaries[0], func(w io.Writer) { printBuildSummary(w, &summaries[0]) })
+			}
+			return o.printOutput(summaries, func(w io.Writer) {
+				printRow(w, "NAME", "BUILDID", "STANDINGBY", "ACTIVE", "INITIALIZING", "CRASHES", "HEALTH", "CORDONED", "PORTS", "NODES")
+				for _, s := range summaries {
+					printRow(w, s.Name, s.BuildID, fmt.Sprintf("%d/%d", s.CurrentStandingBy, s.StandingBy), fmt.Sprintf("%d/%d", s.CurrentActive, s.Max),
+						s.CurrentInitializing, s.CrashesCount, valueOrNone(s.Health), s.Cordoned, len(s.PortsInUse), len(s.Nodes))
+				}
+			})
+		},
+	}
+}
+
+// getBuildSummary returns the summary of a GameServerBuild
+// the counts are read from the GameServerBuild status, the ports and the nodes from its GameServers
+func getBuildSummary(ctx context.Context, c client.Client, gsb *mpsv1alpha1.GameServerBuild) (*buildSummary, error) {
+	var gsList mpsv1alpha1.GameServerList
+	if err := c.List(ctx, &gsList, client.InNamespace(gsb.Namespace), client.MatchingLabels{labelBuildName: gsb.Name}); err != nil {
+		return nil, err
+	}
+
+	s := &buildSummary{
+		Name:                gsb.Name,
+		Namespace:           gsb.Namespace,
+		BuildID:             gsb.Spec.BuildID,
+		TitleID:             gsb.Spec.TitleID,
+		StandingBy:          gsb.Spec.StandingBy,
+		Max:                 gsb.Spec.Max,
+		CurrentInitializing: gsb.Status.CurrentInitializing,
+		CurrentStandingBy:   gsb.Status.CurrentStandingBy,
+		CurrentActive:       gsb.Status.CurrentActive,
+		CrashesCount:        gsb.Status.CrashesCount,
+		Health:              string(gsb.Status.Health),
+		Cordoned:            gsb.IsCordoned(),
+		Paused:              gsb.Spec.Paused,
+		PortsInUse:          []int32{},
+		Nodes:               []nodeSummary{},
+	}
+
+	nodes := map[string]*nodeSummary{}
+	for _, gs := range gsList.Items {
+		for _, container := range gs.Spec.PodSpec.Containers {
+			for _, port := range container.Ports {
+				if port.HostPort != 0 {
+					s.PortsInUse = append(s.PortsInUse, port.HostPort)
+				}
+			}
+		}
+
+		n, ok := nodes[gs.Status.NodeName]
+		if !ok {
+			n = &nodeSummary{Name: gs.Status.NodeName}
+			nodes[gs.Status.NodeName] = n
+		}
+		switch gs.Status.State {
+		case "":
+			n.Initializing++
+		case mpsv1alpha1.GameServerStateStandingBy:
+			n.StandingBy++
+		case mpsv1alpha1.GameServerStateActive:
+			n.Active++
+		}
+	}
+	sort.Slice(s.PortsInUse, func(i, j int) bool { return s.PortsInUse[i] < s.PortsInUse[j] })
+	for _, n := range nodes {
+		s.Nodes = append(s.Nodes, *n)
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].Name < s.Nodes[j].Name })
+	return s, nil
+}
+
+// printBuildSummary writes the detailed summary of a single GameServerBuild
+func printBuildSummary(w io.Writer, s *buildSummary) {
+	printRow(w, "Name:", s.Namespace+"/"+s.Name)
+	printRow(w, "Build ID:", s.BuildID)
+	printRow(w, "Title ID:", s.TitleID)
+	printRow(w, "Health:", valueOrNone(s.Health))
+	printRow(w, "Cordoned:", s.Cordoned)
+	printRow(w, "Paused:", s.Paused)
+	printRow(w, "StandingBy:", fmt.Sprintf("%d/%d", s.CurrentStandingBy, s.StandingBy))
+	printRow(w, "Active:", s.CurrentActive)
+	printRow(w, "Initializing:", s.CurrentInitializing)
+	printRow(w, "Max:", s.Max)
+	printRow(w, "Crashes:", s.CrashesCount)
+	ports := make([]string, 0, len(s.PortsInUse))
+	for _, p := range s.PortsInUse {
+		ports = append(ports, fmt.Sprint(p))
+	}
+	printRow(w, "Ports In Use:", valueOrNone(strings.Join(ports, ", ")))
+	printRow(w, "Nodes:")
+	printRow(w, "  NAME", "INITIALIZING", "STANDINGBY", "ACTIVE")
+	for _, n := range s.Nodes {
+		name := n.Name
+		if name == "" {
+			name = "<unscheduled>"
+		}
+		printRow(w, "  "+name, n.Initializing, n.StandingBy, n.Active)
+	}
+}